@@ -14,6 +14,8 @@ import (
 	"github.com/QwaQ-dev/bala/internal/repository/postgres"
 	"github.com/QwaQ-dev/bala/internal/routes"
 	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/pkg/notify"
+	"github.com/QwaQ-dev/bala/pkg/payments"
 	"github.com/QwaQ-dev/bala/pkg/sl"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -34,8 +36,9 @@ func main() {
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "https://birlikbala.kz",
-		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS",
-		AllowHeaders:     "Content-Type, Authorization",
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,HEAD,OPTIONS",
+		AllowHeaders:     "Content-Type, Authorization, Upload-Offset, Upload-Length, Upload-Metadata, Tus-Resumable",
+		ExposeHeaders:    "Location, Upload-Offset, Upload-Length, Tus-Resumable",
 		AllowCredentials: true,
 	}))
 
@@ -45,7 +48,9 @@ func main() {
 		os.Exit(1)
 	}
 	uploadsPath := filepath.Join(cwd, "uploads")
-	app.Static("/uploads", uploadsPath)
+	// Only public assets are served statically, course videos and attachments go through /api/v1/media
+	app.Static("/uploads/photos", filepath.Join(uploadsPath, "photos"))
+	app.Static("/uploads/articles", filepath.Join(uploadsPath, "articles"))
 
 	log.Info("Starting bala backend", slog.String("env", cfg.Env))
 	db, err := postgres.InitDatabase(cfg.Database, log)
@@ -58,18 +63,91 @@ func main() {
 	articleRepo := postgres.NewArticleRepo(log, db)
 	checklistRepo := postgres.NewChecklistRepo(log, db)
 	courseRepo := postgres.NewCourseRepo(log, db)
+	diplomaRepo := postgres.NewDiplomaRepo(log, db)
+	certificateRepo := postgres.NewCertificateRepo(log, db)
+	progressRepo := postgres.NewProgressRepo(log, db)
+	uploadRepo := postgres.NewUploadRepo(log, db)
+	moduleRepo := postgres.NewModuleRepo(log, db)
+	webinarRepo := postgres.NewWebinarRepo(log, db)
+	reminderRepo := postgres.NewReminderRepo(log, db)
+	quizRepo := postgres.NewQuizRepo(log, db)
+	homeworkRepo := postgres.NewHomeworkRepo(log, db)
+	orderRepo := postgres.NewOrderRepo(log, db)
+	promoRepo := postgres.NewPromoRepo(log, db)
+	voucherRepo := postgres.NewVoucherRepo(log, db)
+	enrollmentRepo := postgres.NewEnrollmentRepo(log, db)
+	accessRequestRepo := postgres.NewAccessRequestRepo(log, db)
+	reviewRepo := postgres.NewReviewRepo(log, db)
+	planRepo := postgres.NewPlanRepo(log, db)
+	bundleRepo := postgres.NewBundleRepo(log, db)
+	pathRepo := postgres.NewPathRepo(log, db)
+	cohortRepo := postgres.NewCohortRepo(log, db)
+
+	reminderSender, err := notify.New(cfg.Reminders.Sender, cfg.Reminders.OutboxPath, log)
+	if err != nil {
+		log.Error("Error with reminder sender", sl.Err(err))
+		os.Exit(1)
+	}
+
+	paymentProvider, err := payments.New(cfg.Payments.Provider, cfg.Payments.WebhookSecret, cfg.Payments.PublicURL)
+	if err != nil {
+		log.Error("Error with payment provider", sl.Err(err))
+		os.Exit(1)
+	}
+	if paymentProvider.Name() == payments.ProviderMock {
+		log.Warn("mock payment provider is enabled: any order can be marked paid without a payment, do not use it in production")
+	}
 
 	userService := services.NewUserService(log, userRepo, cfg)
 	articleService := services.NewArticleService(articleRepo, log, cfg)
 	checklistService := services.NewChecklistService(checklistRepo, log, cfg)
-	courseService := services.NewCourseService(courseRepo, log, cfg, userRepo)
+	courseService := services.NewCourseService(courseRepo, log, cfg, userRepo, progressRepo, quizRepo, enrollmentRepo, accessRequestRepo, planRepo, pathRepo, cohortRepo)
+	certificateService := services.NewCertificateService(certificateRepo, log, cfg)
+	diplomaService := services.NewDiplomaService(diplomaRepo, log, cfg, userRepo, courseService, certificateService)
+	progressService := services.NewProgressService(progressRepo, log, cfg, courseRepo, courseService)
+	mediaService := services.NewMediaService(courseRepo, log, cfg, courseService, moduleRepo, homeworkRepo, accessRequestRepo)
+	uploadService := services.NewUploadService(uploadRepo, log, cfg, courseRepo)
+	moduleService := services.NewModuleService(moduleRepo, log, cfg, courseRepo)
+	webinarService := services.NewWebinarService(webinarRepo, log, cfg, courseRepo, cohortRepo)
+	reminderService := services.NewReminderService(reminderRepo, log, cfg, reminderSender)
+	calendarService := services.NewCalendarService(userRepo, log, cfg, webinarRepo)
+	quizService := services.NewQuizService(quizRepo, log, cfg, courseRepo, courseService)
+	homeworkService := services.NewHomeworkService(homeworkRepo, log, cfg, courseRepo, courseService, reminderSender)
+	promoService := services.NewPromoService(promoRepo, log, cfg, courseRepo, planRepo, bundleRepo)
+	voucherService := services.NewVoucherService(voucherRepo, log, cfg, courseRepo, courseService)
+	accessRequestService := services.NewAccessRequestService(accessRequestRepo, log, cfg, courseRepo, courseService, reminderSender)
+	reviewService := services.NewReviewService(reviewRepo, log, cfg, courseRepo, courseService)
+	planService := services.NewPlanService(planRepo, log, cfg, courseRepo)
+	bundleService := services.NewBundleService(bundleRepo, log, cfg, courseRepo, planRepo, courseService)
+	pathService := services.NewPathService(pathRepo, log, cfg, courseRepo, courseService)
+	cohortService := services.NewCohortService(cohortRepo, log, cfg, courseRepo, userRepo, enrollmentRepo, courseService)
+	orderService := services.NewOrderService(orderRepo, log, cfg, courseRepo, courseService, promoService, bundleService, paymentProvider)
 
 	userHandler := handlers.NewUserHandler(log, userService, cfg)
 	articleHandler := handlers.NewArticleHandler(articleService, log)
 	checklistHandler := handlers.NewChecklistHandler(checklistService, log)
 	courseHandler := handlers.NewCourseHandler(courseService, log)
-
-	routes.InitRoutes(app, log, cfg, userHandler, articleHandler, checklistHandler, courseHandler)
+	diplomaHandler := handlers.NewDiplomaHandler(diplomaService, log, cfg)
+	certificateHandler := handlers.NewCertificateHandler(certificateService, log)
+	progressHandler := handlers.NewProgressHandler(progressService, log)
+	mediaHandler := handlers.NewMediaHandler(mediaService, log)
+	uploadHandler := handlers.NewUploadHandler(uploadService, log)
+	moduleHandler := handlers.NewModuleHandler(moduleService, log)
+	webinarHandler := handlers.NewWebinarHandler(webinarService, log)
+	calendarHandler := handlers.NewCalendarHandler(calendarService, log)
+	quizHandler := handlers.NewQuizHandler(quizService, log)
+	homeworkHandler := handlers.NewHomeworkHandler(homeworkService, log)
+	orderHandler := handlers.NewOrderHandler(orderService, log)
+	promoHandler := handlers.NewPromoHandler(promoService, log)
+	voucherHandler := handlers.NewVoucherHandler(voucherService, log)
+	accessRequestHandler := handlers.NewAccessRequestHandler(accessRequestService, log)
+	reviewHandler := handlers.NewReviewHandler(reviewService, log)
+	planHandler := handlers.NewPlanHandler(planService, log)
+	bundleHandler := handlers.NewBundleHandler(bundleService, log)
+	pathHandler := handlers.NewPathHandler(pathService, log)
+	cohortHandler := handlers.NewCohortHandler(cohortService, log)
+
+	routes.InitRoutes(app, log, cfg, userHandler, articleHandler, checklistHandler, courseHandler, diplomaHandler, certificateHandler, progressHandler, mediaHandler, uploadHandler, moduleHandler, webinarHandler, calendarHandler, quizHandler, homeworkHandler, orderHandler, promoHandler, voucherHandler, accessRequestHandler, reviewHandler, planHandler, bundleHandler, pathHandler, cohortHandler)
 	log.Info("starting server", slog.String("address", cfg.Server.Port))
 
 	go func() {
@@ -79,12 +157,18 @@ func main() {
 		}
 	}()
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go reminderService.Run(jobsCtx)
+	go courseService.RunPublisher(jobsCtx)
+	go uploadService.RunCleanup(jobsCtx, uploadHandler.RemoveParts)
+
 	quit := make(chan os.Signal, 1)
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Info("Shutting down application...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
@@ -12,6 +13,12 @@ type Config struct {
 	JWTSecretKey string `yaml:"jwtsecretkey"`
 	Server       `yaml:"server"`
 	Database     `yaml:"database"`
+	Diploma      `yaml:"diploma"`
+	Media        `yaml:"media"`
+	Reminders    `yaml:"reminders"`
+	Payments     `yaml:"payments"`
+	Courses      `yaml:"courses"`
+	Uploads      `yaml:"uploads"`
 }
 
 type Server struct {
@@ -27,6 +34,39 @@ type Database struct {
 	DBusername string `yaml:"db_username"`
 }
 
+type Diploma struct {
+	FontPath string `yaml:"font_path"` // TTF/OTF font with Cyrillic glyphs, the bundled Go font is used when empty
+}
+
+type Media struct {
+	SigningKey   string        `yaml:"signing_key"` // falls back to jwtsecretkey when empty
+	SignedURLTTL time.Duration `yaml:"signed_url_ttl" env-default:"2h"`
+}
+
+type Reminders struct {
+	Offsets    []time.Duration `yaml:"offsets" env-default:"24h,1h"`                // how long before a webinar to notify
+	Interval   time.Duration   `yaml:"interval" env-default:"1m"`                   // how often due reminders are checked
+	Sender     string          `yaml:"sender" env-default:"log"`                    // log, file
+	OutboxPath string          `yaml:"outbox_path" env-default:"./reminders.jsonl"` // used by the file sender
+}
+
+type Payments struct {
+	Provider      string `yaml:"provider" env-required:"true"` // mock; required, mock is for development only
+	WebhookSecret string `yaml:"webhook_secret"`               // verifies provider webhooks
+	Currency      string `yaml:"currency" env-default:"KZT"`   // Course.Cost is stored in whole units of it
+	PublicURL     string `yaml:"public_url"`                   // API base URL for provider links, relative links when empty
+	ReturnURL     string `yaml:"return_url"`                   // where the provider sends the user after paying
+}
+
+type Courses struct {
+	PublishInterval time.Duration `yaml:"publish_interval" env-default:"1m"` // how often scheduled courses are checked
+}
+
+type Uploads struct {
+	SessionTTL      time.Duration `yaml:"session_ttl" env-default:"72h"`     // unfinished uploads without a chunk for this long are deleted
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"` // how often abandoned uploads are looked for
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG")
 	if configPath == "" {
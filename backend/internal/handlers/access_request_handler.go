@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+var receiptTypes = []string{"application/pdf", "image/jpeg", "image/png"}
+
+const maxReceiptSize = 10 * 1024 * 1024
+
+type AccessRequestHandler struct {
+	requestService *services.AccessRequestService
+	log            *slog.Logger
+}
+
+func NewAccessRequestHandler(requestService *services.AccessRequestService, log *slog.Logger) *AccessRequestHandler {
+	return &AccessRequestHandler{
+		requestService: requestService,
+		log:            log,
+	}
+}
+
+func (h *AccessRequestHandler) requestError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccessRequest), errors.Is(err, services.ErrInvalidAccess),
+		errors.Is(err, services.ErrCohortRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrAccessRequestNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Access request is not found"})
+	case errors.Is(err, services.ErrAlreadyHasAccess), errors.Is(err, postgres.ErrAccessRequestPending),
+		errors.Is(err, services.ErrAccessRequestDecided), errors.Is(err, services.ErrCohortFull):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("access request operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// RequestAccess form: contact, comment, receipt (optional PDF, JPEG or PNG up to 10 MB)
+func (h *AccessRequestHandler) RequestAccess(c *fiber.Ctx) error {
+	const op = "handlers.access_request_handler.RequestAccess"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var receiptPath string
+	if file, err := c.FormFile("receipt"); err == nil && file != nil {
+		if !contains(receiptTypes, file.Header.Get("Content-Type")) || file.Size > maxReceiptSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "receipt must be PDF, JPEG or PNG up to 10 MB"})
+		}
+		receiptPath, err = saveUpload(c, file, "receipts", log)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save receipt"})
+		}
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	req, err := h.requestService.CreateRequest(userID, courseID, c.FormValue("contact"), c.FormValue("comment"), receiptPath)
+	if err != nil {
+		removeUpload(receiptPath, log)
+		return h.requestError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"request": req})
+}
+
+func (h *AccessRequestHandler) GetMyRequests(c *fiber.Ctx) error {
+	const op = "handlers.access_request_handler.GetMyRequests"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	requests, err := h.requestService.GetMyRequests(userID)
+	if err != nil {
+		return h.requestError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"requests": requests})
+}
+
+// GetRequests is the admin queue, query: status (pending by default, "all" for every status), course_id, user_id
+func (h *AccessRequestHandler) GetRequests(c *fiber.Ctx) error {
+	const op = "handlers.access_request_handler.GetRequests"
+	log := h.log.With("op", op)
+
+	status := c.Query("status", structures.AccessRequestPending)
+	if status == "all" {
+		status = ""
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	requests, err := h.requestService.GetRequests(structures.AccessRequestFilter{
+		UserID:   c.QueryInt("user_id"),
+		CourseID: c.QueryInt("course_id"),
+		Status:   status,
+	}, adminID)
+	if err != nil {
+		return h.requestError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"requests": requests})
+}
+
+// Approve body (optional): {"comment":"...","expires_at":"2026-01-01T00:00:00Z","cohort_id":2},
+// cohort_id is required for courses with cohorts
+func (h *AccessRequestHandler) Approve(c *fiber.Ctx) error {
+	const op = "handlers.access_request_handler.Approve"
+	log := h.log.With("op", op)
+
+	id, decision, err := parseDecision(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	if err := h.requestService.Approve(id, adminID, decision); err != nil {
+		return h.requestError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "access request approved"})
+}
+
+// Reject body (optional): {"comment":"..."}, the comment is sent to the user
+func (h *AccessRequestHandler) Reject(c *fiber.Ctx) error {
+	const op = "handlers.access_request_handler.Reject"
+	log := h.log.With("op", op)
+
+	id, decision, err := parseDecision(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	if err := h.requestService.Reject(id, adminID, decision); err != nil {
+		return h.requestError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "access request rejected"})
+}
+
+func parseDecision(c *fiber.Ctx) (int, structures.AccessDecision, error) {
+	var decision structures.AccessDecision
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, decision, errors.New("invalid request ID")
+	}
+
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&decision); err != nil {
+			return 0, decision, errors.New("invalid request body")
+		}
+	}
+
+	return id, decision, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type BundleHandler struct {
+	bundleService *services.BundleService
+	log           *slog.Logger
+}
+
+func NewBundleHandler(bundleService *services.BundleService, log *slog.Logger) *BundleHandler {
+	return &BundleHandler{
+		bundleService: bundleService,
+		log:           log,
+	}
+}
+
+func (h *BundleHandler) bundleError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidBundle), errors.Is(err, services.ErrInvalidAccess),
+		errors.Is(err, services.ErrCohortRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCohortFull):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, postgres.ErrBundleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Bundle is not found"})
+	case errors.Is(err, postgres.ErrBundleInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("bundle operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// GetBundles lists bundles on sale, admins get inactive bundles too
+func (h *BundleHandler) GetBundles(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.GetBundles"
+	log := h.log.With("op", op)
+
+	role, _ := c.Locals("role").(string)
+
+	bundles, err := h.bundleService.GetBundles(role == structures.RoleAdmin)
+	if err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"bundles": bundles})
+}
+
+func (h *BundleHandler) GetBundle(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.GetBundle"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid bundle ID"})
+	}
+
+	role, _ := c.Locals("role").(string)
+
+	bundle, err := h.bundleService.GetBundle(id, role == structures.RoleAdmin)
+	if err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"bundle": bundle})
+}
+
+// CreateBundle body: {"title":"...","description":"...","cost":50000,"active":true,"courses":[{"course_id":1,"plan_id":2},{"course_id":3}]}
+func (h *BundleHandler) CreateBundle(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.CreateBundle"
+	log := h.log.With("op", op)
+
+	var req structures.BundleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	bundle, err := h.bundleService.CreateBundle(req)
+	if err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"bundle": bundle})
+}
+
+func (h *BundleHandler) UpdateBundle(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.UpdateBundle"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid bundle ID"})
+	}
+
+	var req structures.BundleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	bundle, err := h.bundleService.UpdateBundle(id, req)
+	if err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"bundle": bundle})
+}
+
+func (h *BundleHandler) DeleteBundle(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.DeleteBundle"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid bundle ID"})
+	}
+
+	if err := h.bundleService.DeleteBundle(id); err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "bundle deleted"})
+}
+
+// Grant gives a user every course of the bundle, body: {"user_id":1,"expires_at":"2026-01-01T00:00:00Z","cohort_ids":[2]},
+// cohort_ids holds a cohort for every course of the bundle that runs in cohorts
+func (h *BundleHandler) Grant(c *fiber.Ctx) error {
+	const op = "handlers.bundle_handler.Grant"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid bundle ID"})
+	}
+
+	var req structures.BundleGrant
+	if err := c.BodyParser(&req); err != nil || req.UserID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id is required"})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	err = h.bundleService.Grant(id, structures.Enrollment{
+		UserID:    req.UserID,
+		Source:    structures.EnrollmentManual,
+		GrantedBy: adminID,
+		ExpiresAt: req.ExpiresAt,
+	}, req.CohortIDs)
+	if err != nil {
+		return h.bundleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "Access has been given"})
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarHandler struct {
+	calendarService *services.CalendarService
+	log             *slog.Logger
+}
+
+func NewCalendarHandler(calendarService *services.CalendarService, log *slog.Logger) *CalendarHandler {
+	return &CalendarHandler{
+		calendarService: calendarService,
+		log:             log,
+	}
+}
+
+func calendarURL(c *fiber.Ctx, token string) string {
+	return c.BaseURL() + "/api/v1/calendar/" + token + ".ics"
+}
+
+// GetCalendarURL returns the personal feed link to subscribe to from a phone calendar
+func (h *CalendarHandler) GetCalendarURL(c *fiber.Ctx) error {
+	const op = "handlers.calendar_handler.GetCalendarURL"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	token, err := h.calendarService.Token(userID)
+	if err != nil {
+		log.Error("failed to get calendar token", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get calendar link"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"url": calendarURL(c, token)})
+}
+
+func (h *CalendarHandler) ResetCalendarURL(c *fiber.Ctx) error {
+	const op = "handlers.calendar_handler.ResetCalendarURL"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	token, err := h.calendarService.ResetToken(userID)
+	if err != nil {
+		log.Error("failed to reset calendar token", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to reset calendar link"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"url": calendarURL(c, token)})
+}
+
+// Feed serves the .ics file, calendar apps fetch it without cookies so the token is the only credential
+func (h *CalendarHandler) Feed(c *fiber.Ctx) error {
+	const op = "handlers.calendar_handler.Feed"
+	log := h.log.With("op", op)
+
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+
+	feed, err := h.calendarService.Feed(token)
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarNotFound) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		log.Error("failed to render calendar", sl.Err(err))
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(feed)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CertificateHandler struct {
+	certificateService *services.CertificateService
+	log                *slog.Logger
+}
+
+func NewCertificateHandler(certificateService *services.CertificateService, log *slog.Logger) *CertificateHandler {
+	return &CertificateHandler{
+		certificateService: certificateService,
+		log:                log,
+	}
+}
+
+func (h *CertificateHandler) Verify(c *fiber.Ctx) error {
+	const op = "handlers.certificate_handler.Verify"
+	log := h.log.With("op", op)
+
+	code := c.Params("code")
+
+	verification, err := h.certificateService.Verify(code)
+	if err != nil {
+		if errors.Is(err, postgres.ErrCertificateNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Certificate not found", "valid": false})
+		}
+		log.Error("failed to verify certificate", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to verify certificate"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"certificate": verification})
+}
+
+func (h *CertificateHandler) GetAllCertificates(c *fiber.Ctx) error {
+	const op = "handlers.certificate_handler.GetAllCertificates"
+	log := h.log.With("op", op)
+
+	certificates, err := h.certificateService.GetAll()
+	if err != nil {
+		log.Error("failed to fetch certificates", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch certificates"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"certificates": certificates})
+}
+
+func (h *CertificateHandler) Revoke(c *fiber.Ctx) error {
+	const op = "handlers.certificate_handler.Revoke"
+	log := h.log.With("op", op)
+
+	var req structures.CertificateRevokeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			log.Error("failed to parse request body", sl.Err(err))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+	}
+
+	if err := h.certificateService.Revoke(c.Params("code"), req.Reason); err != nil {
+		if errors.Is(err, postgres.ErrCertificateNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Certificate not found"})
+		}
+		log.Error("failed to revoke certificate", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to revoke certificate"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Certificate has been revoked"})
+}
@@ -52,7 +52,7 @@ func (h *ChecklistHandler) GetAllChecklists(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch checklists"})
 	}
 
-	log.Info("checklists", checklists)
+	log.Info("checklists fetched", slog.Int("count", len(checklists)))
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"checklists": checklists,
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type CohortHandler struct {
+	cohortService *services.CohortService
+	log           *slog.Logger
+}
+
+func NewCohortHandler(cohortService *services.CohortService, log *slog.Logger) *CohortHandler {
+	return &CohortHandler{
+		cohortService: cohortService,
+		log:           log,
+	}
+}
+
+func (h *CohortHandler) cohortError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCohort):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoAccess):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "You are not a curator of this cohort"})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrCohortNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cohort is not found"})
+	default:
+		log.Error("cohort operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *CohortHandler) GetCohorts(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.GetCohorts"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	cohorts, err := h.cohortService.GetCohorts(courseID)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"cohorts": cohorts})
+}
+
+// GetCourseCohorts lists the cohorts of a course with their free seats, to choose one at checkout
+func (h *CohortHandler) GetCourseCohorts(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.GetCourseCohorts"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	cohorts, err := h.cohortService.GetCourseCohorts(courseID)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"cohorts": cohorts})
+}
+
+// CreateCohort body: {"title":"...","starts_at":"2026-01-15T10:00:00Z","capacity":30,"curator_ids":[5,6]}
+func (h *CohortHandler) CreateCohort(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.CreateCohort"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.CohortRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	cohort, err := h.cohortService.CreateCohort(courseID, req)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"cohort": cohort})
+}
+
+func (h *CohortHandler) UpdateCohort(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.UpdateCohort"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("cohortId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid cohort ID"})
+	}
+
+	var req structures.CohortRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	cohort, err := h.cohortService.UpdateCohort(id, req)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"cohort": cohort})
+}
+
+func (h *CohortHandler) DeleteCohort(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.DeleteCohort"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("cohortId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid cohort ID"})
+	}
+
+	if err := h.cohortService.DeleteCohort(id); err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "cohort deleted"})
+}
+
+// GetMyCohorts lists the cohorts of the signed-in curator
+func (h *CohortHandler) GetMyCohorts(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.GetMyCohorts"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	cohorts, err := h.cohortService.GetMyCohorts(userID)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"cohorts": cohorts})
+}
+
+func (h *CohortHandler) GetLearners(c *fiber.Ctx) error {
+	const op = "handlers.cohort_handler.GetLearners"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("cohortId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid cohort ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	learners, err := h.cohortService.GetLearners(id, userID)
+	if err != nil {
+		return h.cohortError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"learners": learners})
+}
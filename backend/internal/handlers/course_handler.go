@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
 	"github.com/QwaQ-dev/bala/internal/services"
 	"github.com/QwaQ-dev/bala/internal/structures"
 	"github.com/QwaQ-dev/bala/pkg/sl"
@@ -23,6 +28,14 @@ func init() {
 	}
 }
 
+// Diploma templates are rendered server-side, so only raster images are accepted
+var diplomaTypes = []string{"image/jpeg", "image/png"}
+
+// Lesson materials that may be attached next to a video
+var attachmentTypes = []string{"application/pdf", "image/jpeg", "image/png", "application/zip"}
+
+const maxAttachmentSize = 100 * 1024 * 1024
+
 type CourseHandler struct {
 	courseService *services.CourseService
 	log           *slog.Logger
@@ -43,6 +56,74 @@ func ensureDir(path string, log *slog.Logger) error {
 	return nil
 }
 
+// uploadFilePath converts a public "/uploads/..." path stored in the database into a path on disk
+func uploadFilePath(publicPath string) string {
+	rel := strings.TrimPrefix(filepath.ToSlash(publicPath), "/")
+	rel = strings.TrimPrefix(rel, "uploads/")
+	return filepath.Join(uploadBaseDir, filepath.FromSlash(filepath.Clean("/"+rel)))
+}
+
+// saveUpload stores a multipart file under uploadBaseDir/<dir> and returns its public "/uploads/<dir>/..." path
+func saveUpload(c *fiber.Ctx, file *multipart.FileHeader, dir string, log *slog.Logger) (string, error) {
+	if err := ensureDir(filepath.Join(uploadBaseDir, dir), log); err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+	if err := c.SaveFile(file, filepath.Join(uploadBaseDir, dir, filename)); err != nil {
+		log.Error("failed to save file", slog.String("dir", dir), sl.Err(err))
+		return "", err
+	}
+
+	return "/uploads/" + dir + "/" + filename, nil
+}
+
+// removeUpload deletes a file previously stored by saveUpload, a missing file is not an error
+func removeUpload(publicPath string, log *slog.Logger) {
+	if publicPath == "" {
+		return
+	}
+	if err := os.Remove(uploadFilePath(publicPath)); err != nil && !os.IsNotExist(err) {
+		log.Warn("failed to remove file", slog.String("path", publicPath), sl.Err(err))
+	}
+}
+
+// copyUpload gives a stored file a second name next to it and returns its public path. The copy is a hard link
+// when the filesystem allows it, so removing either file later never affects the other.
+func copyUpload(publicPath string, log *slog.Logger) (string, error) {
+	src := uploadFilePath(publicPath)
+	name := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(src))
+	dst := filepath.Join(filepath.Dir(src), name)
+
+	if err := os.Link(src, dst); err != nil {
+		in, err := os.Open(src)
+		if err != nil {
+			log.Error("failed to open file", slog.String("path", publicPath), sl.Err(err))
+			return "", err
+		}
+		defer in.Close()
+
+		out, err := os.Create(dst)
+		if err != nil {
+			log.Error("failed to create file", slog.String("path", publicPath), sl.Err(err))
+			return "", err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			os.Remove(dst)
+			log.Error("failed to copy file", slog.String("path", publicPath), sl.Err(err))
+			return "", err
+		}
+		if err := out.Close(); err != nil {
+			os.Remove(dst)
+			log.Error("failed to copy file", slog.String("path", publicPath), sl.Err(err))
+			return "", err
+		}
+	}
+
+	return publicPath[:strings.LastIndex(publicPath, "/")+1] + name, nil
+}
+
 func (h *CourseHandler) CreateCourse(c *fiber.Ctx) error {
 	const op = "handlers.course_handler.CreateCourse"
 	log := h.log.With("op", op)
@@ -68,6 +149,17 @@ func (h *CourseHandler) CreateCourse(c *fiber.Ctx) error {
 		}
 	}
 
+	// new courses are drafts unless the admin publishes or schedules them right away
+	var publishAt *time.Time
+	if publishAtStr := c.FormValue("publish_at"); publishAtStr != "" {
+		t, err := time.Parse(time.RFC3339, publishAtStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "publish_at must be RFC3339"})
+		}
+		t = t.UTC()
+		publishAt = &t
+	}
+
 	// Handle image upload
 	imgPath := ""
 	if file, err := c.FormFile("img"); err == nil && file != nil {
@@ -87,6 +179,10 @@ func (h *CourseHandler) CreateCourse(c *fiber.Ctx) error {
 	// Handle diploma upload
 	diplomaPath := ""
 	if diploma, err := c.FormFile("diploma"); err == nil && diploma != nil {
+		if !contains(diplomaTypes, diploma.Header.Get("Content-Type")) {
+			log.Error("invalid diploma file type", slog.String("type", diploma.Header.Get("Content-Type")))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "diploma must be a PNG or JPEG image"})
+		}
 		dir := filepath.Join(uploadBaseDir, "diplomas")
 		if err := ensureDir(dir, log); err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create diplomas dir"})
@@ -100,21 +196,24 @@ func (h *CourseHandler) CreateCourse(c *fiber.Ctx) error {
 		diplomaPath = "/uploads/diplomas/" + filename
 	}
 
-	webinarLink := c.FormValue("webinar_link")
-	webinarDateStr := c.FormValue("webinar_date")
+	// the first webinar is optional, the schedule is managed through /admin/course/:id/webinars
+	var webinars []structures.Webinar
+	if webinarDateStr := c.FormValue("webinar_date"); webinarDateStr != "" {
+		date, err := time.Parse(time.RFC3339, webinarDateStr)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("invalid webinar_date: %v", err),
+			})
+		}
 
-	date, err := time.Parse(time.RFC3339, webinarDateStr)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf("invalid webinar_date: %v", err),
+		webinars = append(webinars, structures.Webinar{
+			Title:    c.FormValue("webinar_title"),
+			Link:     c.FormValue("webinar_link"),
+			Date:     date.UTC(),
+			Duration: services.DefaultWebinarDuration,
 		})
 	}
 
-	webinar := structures.Webinar{
-		Link: webinarLink,
-		Date: date.UTC(),
-	}
-
 	course := structures.Course{
 		Title:       title,
 		Description: description,
@@ -123,11 +222,16 @@ func (h *CourseHandler) CreateCourse(c *fiber.Ctx) error {
 		DiplomaPath: diplomaPath,
 		Diploma_x:   diplomaX,
 		Diploma_y:   diplomaY,
-		Webinars:    webinar,
+		Webinars:    webinars,
+		Status:      c.FormValue("status"),
+		PublishAt:   publishAt,
 	}
 
 	courseID, err := h.courseService.CreateCourse(course)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidCourseStatus) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
 		log.Error("failed to create course", sl.Err(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create course"})
 	}
@@ -160,8 +264,15 @@ func (h *CourseHandler) UploadVideos(c *fiber.Ctx) error {
 	if len(videos) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "no videos uploaded"})
 	}
-	if len(videos) != len(titles) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mismatched number of videos and titles"})
+	if len(videos) != len(titles) || len(videos) != len(form.Value["duration[]"]) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mismatched number of videos, titles and durations"})
+	}
+	durations := make([]int, len(videos))
+	for i, d := range form.Value["duration[]"] {
+		var ok bool
+		if durations[i], ok = parseVideoDuration(d); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "duration[] must be positive numbers of seconds"})
+		}
 	}
 
 	videoDir := filepath.Join(uploadBaseDir, "videos")
@@ -184,24 +295,23 @@ func (h *CourseHandler) UploadVideos(c *fiber.Ctx) error {
 		}
 		videoRelativePath := "/uploads/videos/" + videoFilename
 
-		log.Info("video path", videoRelativePath)
+		log.Info("video path", slog.String("path", videoRelativePath))
 
 		var filePath string
 		if i < len(extraFiles) && extraFiles[i] != nil && extraFiles[i].Size > 0 {
 			extraFile := extraFiles[i]
-			allowedTypes := []string{"application/pdf", "image/jpeg", "image/png", "application/zip"}
-			if !contains(allowedTypes, extraFile.Header.Get("Content-Type")) {
+			if !contains(attachmentTypes, extraFile.Header.Get("Content-Type")) {
 				log.Error("invalid extra file type", slog.Any("type", extraFile.Header.Get("Content-Type")))
 				continue
 			}
-			if extraFile.Size > 100*1024*1024 {
+			if extraFile.Size > maxAttachmentSize {
 				log.Error("extra file too large", slog.Any("size", extraFile.Size))
 				continue
 			}
 
 			extraFilename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), extraFile.Filename)
 			extraSavePath := filepath.Join(fileUploadDir, extraFilename)
-			log.Info("file path", extraSavePath)
+			log.Info("file path", slog.String("path", extraSavePath))
 
 			if err := c.SaveFile(extraFile, extraSavePath); err != nil {
 				log.Error("failed to save extra file", sl.Err(err))
@@ -211,7 +321,7 @@ func (h *CourseHandler) UploadVideos(c *fiber.Ctx) error {
 			}
 		}
 
-		if err := h.courseService.AddVideoToCourse(courseID, videoRelativePath, titles[i], filePath); err != nil {
+		if err := h.courseService.AddVideoToCourse(courseID, videoRelativePath, titles[i], filePath, durations[i]); err != nil {
 			log.Error("failed to save path to DB", sl.Err(err))
 			continue
 		}
@@ -239,21 +349,16 @@ func (h *CourseHandler) GetCourseByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
 	}
 
+	// 0 for anonymous visitors on the public route
 	user_id, _ := c.Locals("userId").(int)
 
-	course, err := h.courseService.GetCourseByID(course_id, user_id)
+	course, hasAccess, err := h.courseService.ViewCourse(course_id, user_id)
 	if err != nil {
-		switch err.Error() {
-		case "This user has no access for course":
-			log.Warn("user has no access", slog.Int("user_id", user_id), slog.Int("course_id", course_id))
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
-		default:
-			log.Error("course not found", slog.Int("course_id", course_id), sl.Err(err))
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
-		}
+		log.Error("course not found", slog.Int("course_id", course_id), sl.Err(err))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
 	}
 
-	return c.Status(200).JSON(fiber.Map{"course": course})
+	return c.Status(200).JSON(fiber.Map{"course": course, "has_access": hasAccess})
 }
 
 func (h *CourseHandler) UpdateCourse(c *fiber.Ctx) error {
@@ -289,23 +394,37 @@ func (h *CourseHandler) UpdateCourse(c *fiber.Ctx) error {
 		}
 	}
 
+	existingCourse, err := h.courseService.GetCourseByID(id, user_id)
+	if err != nil {
+		log.Error("failed to get existing course", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get existing course"})
+	}
+
 	file, err := c.FormFile("img")
-	var imgPath string
+	imgPath := existingCourse.Img
 	if err == nil && file != nil {
-		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
-		savePath := "./uploads/photos/" + filename
-		if err := c.SaveFile(file, savePath); err != nil {
-			log.Error("failed to save photo", sl.Err(err))
+		imgPath, err = saveUpload(c, file, "photos", log)
+		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save photo"})
 		}
-		imgPath = "/uploads/photos/" + filename
-	} else {
-		existingCourse, err := h.courseService.GetCourseByID(id, user_id)
-		if err != nil {
-			log.Error("failed to get existing course", sl.Err(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get existing course"})
+	}
+
+	diplomaPath := existingCourse.DiplomaPath
+	if diploma, err := c.FormFile("diploma"); err == nil && diploma != nil {
+		if !contains(diplomaTypes, diploma.Header.Get("Content-Type")) {
+			log.Error("invalid diploma file type", slog.String("type", diploma.Header.Get("Content-Type")))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "diploma must be a PNG or JPEG image"})
+		}
+		dir := filepath.Join(uploadBaseDir, "diplomas")
+		if err := ensureDir(dir, log); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create diplomas dir"})
+		}
+		filename := fmt.Sprintf("%s_%s", time.Now().Format("20060102150405"), diploma.Filename)
+		if err := c.SaveFile(diploma, filepath.Join(dir, filename)); err != nil {
+			log.Error("failed to save diploma", sl.Err(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save diploma"})
 		}
-		imgPath = existingCourse.Img
+		diplomaPath = "/uploads/diplomas/" + filename
 	}
 
 	course := structures.Course{
@@ -314,6 +433,7 @@ func (h *CourseHandler) UpdateCourse(c *fiber.Ctx) error {
 		Description: description,
 		Cost:        cost,
 		Img:         imgPath,
+		DiplomaPath: diplomaPath,
 	}
 
 	if err := h.courseService.UpdateCourse(&course); err != nil {
@@ -321,6 +441,13 @@ func (h *CourseHandler) UpdateCourse(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update course"})
 	}
 
+	if imgPath != existingCourse.Img {
+		removeUpload(existingCourse.Img, log)
+	}
+	if diplomaPath != existingCourse.DiplomaPath {
+		removeUpload(existingCourse.DiplomaPath, log)
+	}
+
 	return c.JSON(fiber.Map{"message": "course updated"})
 }
 
@@ -334,14 +461,132 @@ func (h *CourseHandler) DeleteCourse(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
 	}
 
-	if err := h.courseService.DeleteCourse(id); err != nil {
+	files, err := h.courseService.DeleteCourse(id)
+	if err != nil {
 		log.Error("Failed to delete course", sl.Err(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete course"})
 	}
 
+	for _, f := range files {
+		removeUpload(f, log)
+	}
+
 	return c.JSON(fiber.Map{"message": "course deleted"})
 }
 
+// UpdateVideo renames a video and optionally replaces its files. Form fields: title, video, file,
+// remove_file=true to drop the attachment. Replaced files are deleted from disk.
+func (h *CourseHandler) UpdateVideo(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.UpdateVideo"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("videoId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid video ID"})
+	}
+
+	existing, err := h.courseService.GetVideo(id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVideoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Video is not found"})
+		}
+		log.Error("failed to get video", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get video"})
+	}
+
+	video := existing
+	if title := strings.TrimSpace(c.FormValue("title")); title != "" {
+		video.Title = title
+	}
+
+	if preview := c.FormValue("is_preview"); preview != "" {
+		video.IsPreview = preview == "true"
+	}
+	if duration := c.FormValue("duration"); duration != "" {
+		video.Duration, err = strconv.Atoi(duration)
+		if err != nil || video.Duration < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "duration must be a non-negative number of seconds"})
+		}
+	}
+
+	if file, err := c.FormFile("file"); err == nil && file != nil {
+		if !contains(attachmentTypes, file.Header.Get("Content-Type")) || file.Size > maxAttachmentSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file must be PDF, JPEG, PNG or ZIP up to 100 MB"})
+		}
+		video.File, err = saveUpload(c, file, "files", log)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save file"})
+		}
+	} else if c.FormValue("remove_file") == "true" {
+		video.File = ""
+	}
+
+	if file, err := c.FormFile("video"); err == nil && file != nil {
+		video.Path, err = saveUpload(c, file, "videos", log)
+		if err != nil {
+			if video.File != existing.File {
+				removeUpload(video.File, log)
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save video"})
+		}
+	}
+
+	if err := h.courseService.UpdateVideo(video); err != nil {
+		log.Error("failed to update video", sl.Err(err))
+		if video.Path != existing.Path {
+			removeUpload(video.Path, log)
+		}
+		if video.File != existing.File {
+			removeUpload(video.File, log)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update video"})
+	}
+
+	if video.Path != existing.Path {
+		removeUpload(existing.Path, log)
+	}
+	if video.File != existing.File {
+		removeUpload(existing.File, log)
+	}
+
+	return c.JSON(fiber.Map{"message": "video updated", "video": video})
+}
+
+func (h *CourseHandler) DeleteVideo(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.DeleteVideo"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("videoId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid video ID"})
+	}
+
+	files, err := h.courseService.DeleteVideo(id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVideoNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Video is not found"})
+		}
+		log.Error("failed to delete video", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete video"})
+	}
+
+	for _, f := range files {
+		removeUpload(f, log)
+	}
+
+	return c.JSON(fiber.Map{"message": "video deleted"})
+}
+
+// parseVideoDuration parses a video duration in seconds. Lessons are completed by watched time against this duration,
+// so every uploaded video needs one.
+func parseVideoDuration(s string) (int, bool) {
+	d, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
 // Вспомогательная функция для проверки типа файла
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -356,7 +601,8 @@ func (h *CourseHandler) GetAllCourses(c *fiber.Ctx) error {
 	const op = "handlers.course_handler.GetAllCourses"
 	log := h.log.With("op", op)
 
-	courses, err := h.courseService.GetAllCourses()
+	role, _ := c.Locals("role").(string)
+	courses, err := h.courseService.GetAllCourses(role == structures.RoleAdmin)
 	if err != nil {
 		log.Error("failed to fetch courses", slog.Any("err", err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch courses"})
@@ -365,6 +611,95 @@ func (h *CourseHandler) GetAllCourses(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"courses": courses})
 }
 
+// SetStatus publishes, schedules, archives or unpublishes a course
+func (h *CourseHandler) SetStatus(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.SetStatus"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course id"})
+	}
+
+	var req structures.CourseStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.courseService.SetStatus(id, req); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCourseStatus):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrCourseNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "course not found"})
+		}
+		log.Error("failed to set course status", slog.Int("course_id", id), sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to set course status"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "course status updated"})
+}
+
+// DuplicateCourse copies a course with its content, plans, diploma template and upcoming webinars into a new draft.
+// Body (optional): {"title":"..."}. Every uploaded file gets its own copy, enrollments are not copied.
+func (h *CourseHandler) DuplicateCourse(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.DuplicateCourse"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course id"})
+	}
+
+	var req structures.CourseCopyRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			log.Error("failed to parse request body", sl.Err(err))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+	}
+
+	media, err := h.courseService.CourseMedia(id)
+	if err != nil {
+		if errors.Is(err, services.ErrCourseNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "course not found"})
+		}
+		log.Error("failed to get course media", slog.Int("course_id", id), sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to duplicate course"})
+	}
+
+	copies := make(map[string]string, len(media))
+	removeCopies := func() {
+		for _, p := range copies {
+			removeUpload(p, log)
+		}
+	}
+	for _, f := range media {
+		if _, ok := copies[f]; ok {
+			continue
+		}
+		p, err := copyUpload(f, log)
+		if err != nil {
+			removeCopies()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to copy course files"})
+		}
+		copies[f] = p
+	}
+
+	courseID, err := h.courseService.DuplicateCourse(id, req, copies)
+	if err != nil {
+		removeCopies()
+		if errors.Is(err, services.ErrCourseNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "course not found"})
+		}
+		log.Error("failed to duplicate course", slog.Int("course_id", id), sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to duplicate course"})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"course_id": courseID})
+}
+
 func (h *CourseHandler) GiveAccess(c *fiber.Ctx) error {
 	const op = "handlers.course_handler.GiveAccess"
 	log := h.log.With("op", op)
@@ -375,7 +710,26 @@ func (h *CourseHandler) GiveAccess(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	if err := h.courseService.GiveAccess(req.UserID, req.CourseID); err != nil {
+	adminID, _ := c.Locals("userId").(int)
+
+	err := h.courseService.GiveAccess(structures.Enrollment{
+		UserID:    req.UserID,
+		CourseID:  req.CourseID,
+		PlanID:    req.PlanID,
+		CohortID:  req.CohortID,
+		Source:    structures.EnrollmentManual,
+		GrantedBy: adminID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAccess), errors.Is(err, services.ErrCohortRequired):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, services.ErrCourseNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+		case errors.Is(err, services.ErrCohortFull):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		log.Error("Error with access", sl.Err(err))
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -401,6 +755,68 @@ func (h *CourseHandler) TakeAwayAccess(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Access has been taken away"})
 }
 
+// maxAccessImportSize limits the CSV accepted by ImportAccess
+const maxAccessImportSize = 2 << 20
+
+// ImportAccess grants or revokes access from a CSV sent as the "file" form field or as the raw body.
+// Query: action=grant|revoke, course_id (for rows without one), dry_run=true to only get the report.
+func (h *CourseHandler) ImportAccess(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.ImportAccess"
+	log := h.log.With("op", op)
+
+	data := c.Body()
+	if file, err := c.FormFile("file"); err == nil && file != nil {
+		if file.Size > maxAccessImportSize {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "file is too large"})
+		}
+		f, err := file.Open()
+		if err != nil {
+			log.Error("failed to open uploaded file", sl.Err(err))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "failed to read file"})
+		}
+		defer f.Close()
+
+		if data, err = io.ReadAll(f); err != nil {
+			log.Error("failed to read uploaded file", sl.Err(err))
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "failed to read file"})
+		}
+	}
+	if len(data) > maxAccessImportSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "file is too large"})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	report, err := h.courseService.ImportAccess(data, c.Query("action"), c.QueryInt("course_id"), adminID, c.QueryBool("dry_run"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAccess) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error("failed to import access", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{"report": report})
+}
+
+// GetEnrollments lists who has access and how they got it, query: user_id, course_id, cohort_id
+func (h *CourseHandler) GetEnrollments(c *fiber.Ctx) error {
+	const op = "handlers.course_handler.GetEnrollments"
+	log := h.log.With("op", op)
+
+	enrollments, err := h.courseService.GetEnrollments(structures.EnrollmentFilter{
+		UserID:   c.QueryInt("user_id"),
+		CourseID: c.QueryInt("course_id"),
+		CohortID: c.QueryInt("cohort_id"),
+	})
+	if err != nil {
+		log.Error("failed to get enrollments", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+
+	return c.JSON(fiber.Map{"enrollments": enrollments})
+}
+
 func (h *CourseHandler) GetAllCoursesWithAccess(c *fiber.Ctx) error {
 	userID, _ := c.Locals("userId").(int)
 
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/diploma"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/image/font/opentype"
+)
+
+type DiplomaHandler struct {
+	diplomaService *services.DiplomaService
+	log            *slog.Logger
+	font           *opentype.Font
+}
+
+func NewDiplomaHandler(diplomaService *services.DiplomaService, log *slog.Logger, cfg *config.Config) *DiplomaHandler {
+	f, err := diploma.LoadFont(cfg.Diploma.FontPath)
+	if err != nil {
+		log.Error("failed to load diploma font, using default one", sl.Err(err))
+		f, _ = diploma.LoadFont("")
+	}
+
+	return &DiplomaHandler{
+		diplomaService: diplomaService,
+		log:            log,
+		font:           f,
+	}
+}
+
+func (h *DiplomaHandler) GetDiploma(c *fiber.Ctx) error {
+	const op = "handlers.diploma_handler.GetDiploma"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		log.Error("invalid course ID", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	format := c.Query("format", "png")
+	if format != "png" && format != "pdf" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "format must be png or pdf"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	d, err := h.diplomaService.IssueDiploma(courseID, userID)
+	if err != nil {
+		if errors.Is(err, services.ErrNoAccess) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
+		}
+		if errors.Is(err, services.ErrFeatureNotIncluded) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Diploma is not included in your plan"})
+		}
+		if errors.Is(err, services.ErrQuizzesNotPassed) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Pass the course quizzes to get the diploma"})
+		}
+		if errors.Is(err, services.ErrCertificateRevoked) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Certificate has been revoked"})
+		}
+		log.Error("failed to issue diploma", slog.Int("course_id", courseID), sl.Err(err))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Diploma is not available"})
+	}
+
+	fields := make([]diploma.Field, 0, len(d.Fields))
+	for _, f := range d.Fields {
+		clr, err := diploma.ParseColor(f.Color)
+		if err != nil {
+			log.Warn("invalid diploma field color", slog.Int("field_id", f.Id), sl.Err(err))
+			clr = nil
+		}
+		fields = append(fields, diploma.Field{
+			Text:     f.Text,
+			X:        f.X,
+			Y:        f.Y,
+			FontSize: f.FontSize,
+			Color:    clr,
+			Align:    f.Align,
+		})
+	}
+
+	img, err := diploma.Render(uploadFilePath(d.TemplatePath), fields, h.font)
+	if err != nil {
+		log.Error("failed to render diploma", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to render diploma"})
+	}
+
+	var buf bytes.Buffer
+	if format == "pdf" {
+		err = diploma.EncodePDF(&buf, img)
+		c.Set(fiber.HeaderContentType, "application/pdf")
+	} else {
+		err = diploma.EncodePNG(&buf, img)
+		c.Set(fiber.HeaderContentType, "image/png")
+	}
+	if err != nil {
+		log.Error("failed to encode diploma", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to render diploma"})
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="diploma_%d.%s"`, courseID, format))
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+func (h *DiplomaHandler) GetFields(c *fiber.Ctx) error {
+	const op = "handlers.diploma_handler.GetFields"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		log.Error("invalid course ID", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	fields, err := h.diplomaService.GetFields(courseID)
+	if err != nil {
+		log.Error("failed to fetch diploma fields", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch diploma fields"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"fields": fields})
+}
+
+func (h *DiplomaHandler) SetFields(c *fiber.Ctx) error {
+	const op = "handlers.diploma_handler.SetFields"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		log.Error("invalid course ID", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req struct {
+		Fields []structures.DiplomaField `json:"fields"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.diplomaService.SetFields(courseID, req.Fields); err != nil {
+		log.Error("failed to save diploma fields", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Diploma fields have been updated"})
+}
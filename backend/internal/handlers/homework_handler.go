@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Homework is often a phone recording, so videos are accepted next to the usual attachment types
+var homeworkTypes = append(slices.Clone(attachmentTypes), "video/mp4", "video/quicktime", "video/webm")
+
+const (
+	maxHomeworkFileSize = 500 * 1024 * 1024
+	maxHomeworkFiles    = 10
+)
+
+type HomeworkHandler struct {
+	homeworkService *services.HomeworkService
+	log             *slog.Logger
+}
+
+func NewHomeworkHandler(homeworkService *services.HomeworkService, log *slog.Logger) *HomeworkHandler {
+	return &HomeworkHandler{
+		homeworkService: homeworkService,
+		log:             log,
+	}
+}
+
+func (h *HomeworkHandler) homeworkError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidAssignment), errors.Is(err, services.ErrInvalidReview):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrSubmissionPending), errors.Is(err, services.ErrAlreadyAccepted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoAccess):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
+	case errors.Is(err, services.ErrFeatureNotIncluded):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Homework is not included in your plan"})
+	case errors.Is(err, postgres.ErrVideoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Lesson is not found"})
+	case errors.Is(err, postgres.ErrAssignmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Assignment is not found"})
+	case errors.Is(err, postgres.ErrSubmissionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Submission is not found"})
+	default:
+		log.Error("homework operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *HomeworkHandler) CreateAssignment(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.CreateAssignment"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.AssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	id, err := h.homeworkService.CreateAssignment(courseID, req)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"assignment_id": id})
+}
+
+func (h *HomeworkHandler) UpdateAssignment(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.UpdateAssignment"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("assignmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignment ID"})
+	}
+
+	var req structures.AssignmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.homeworkService.UpdateAssignment(id, req); err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "assignment updated"})
+}
+
+func (h *HomeworkHandler) DeleteAssignment(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.DeleteAssignment"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("assignmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignment ID"})
+	}
+
+	files, err := h.homeworkService.DeleteAssignment(id)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	for _, f := range files {
+		removeUpload(f, log)
+	}
+
+	return c.JSON(fiber.Map{"message": "assignment deleted"})
+}
+
+func (h *HomeworkHandler) GetCourseAssignmentsForAdmin(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.GetCourseAssignmentsForAdmin"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	assignments, err := h.homeworkService.GetCourseAssignmentsForAdmin(courseID)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"assignments": assignments})
+}
+
+func (h *HomeworkHandler) GetCourseAssignments(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.GetCourseAssignments"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	assignments, err := h.homeworkService.GetCourseAssignments(courseID, userID)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"assignments": assignments})
+}
+
+// Submit uploads a homework submission. Form fields: comment, file[] (PDF, images, ZIP or video).
+func (h *HomeworkHandler) Submit(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.Submit"
+	log := h.log.With("op", op)
+
+	assignmentID, err := strconv.Atoi(c.Params("assignmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignment ID"})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		log.Error("failed to parse multipart form", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid form data"})
+	}
+
+	uploads := form.File["file[]"]
+	if len(uploads) > maxHomeworkFiles {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "too many files"})
+	}
+	for _, file := range uploads {
+		if !contains(homeworkTypes, file.Header.Get("Content-Type")) || file.Size > maxHomeworkFileSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "files must be PDF, JPEG, PNG, ZIP or MP4/MOV/WEBM video up to 500 MB"})
+		}
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	var files []structures.SubmissionFile
+	cleanup := func() {
+		for _, f := range files {
+			removeUpload(f.Path, log)
+		}
+	}
+
+	for _, file := range uploads {
+		path, err := saveUpload(c, file, "homework", log)
+		if err != nil {
+			cleanup()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save file"})
+		}
+		files = append(files, structures.SubmissionFile{Title: file.Filename, Path: path})
+	}
+
+	submission, err := h.homeworkService.Submit(assignmentID, userID, c.FormValue("comment"), files)
+	if err != nil {
+		cleanup()
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"submission": submission})
+}
+
+func (h *HomeworkHandler) GetMySubmissions(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.GetMySubmissions"
+	log := h.log.With("op", op)
+
+	assignmentID, err := strconv.Atoi(c.Params("assignmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid assignment ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	submissions, err := h.homeworkService.GetMySubmissions(assignmentID, userID)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"submissions": submissions})
+}
+
+// GetSubmissions is the review queue, query: course_id, assignment_id, user_id, status
+func (h *HomeworkHandler) GetSubmissions(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.GetSubmissions"
+	log := h.log.With("op", op)
+
+	filter := structures.SubmissionFilter{
+		AssignmentID: c.QueryInt("assignment_id"),
+		CourseID:     c.QueryInt("course_id"),
+		UserID:       c.QueryInt("user_id"),
+		Status:       c.Query("status"),
+	}
+
+	reviewerID, _ := c.Locals("userId").(int)
+
+	submissions, err := h.homeworkService.GetSubmissions(filter, reviewerID)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"submissions": submissions})
+}
+
+func (h *HomeworkHandler) GetSubmission(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.GetSubmission"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("submissionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid submission ID"})
+	}
+
+	reviewerID, _ := c.Locals("userId").(int)
+
+	submission, err := h.homeworkService.GetSubmission(id, reviewerID)
+	if err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"submission": submission})
+}
+
+// Review sets the verdict, body: {"status":"accepted|needs_revision","feedback":"..."}
+func (h *HomeworkHandler) Review(c *fiber.Ctx) error {
+	const op = "handlers.homework_handler.Review"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("submissionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid submission ID"})
+	}
+
+	var req structures.ReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	reviewerID, _ := c.Locals("userId").(int)
+
+	if err := h.homeworkService.Review(id, reviewerID, req); err != nil {
+		return h.homeworkError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "submission reviewed"})
+}
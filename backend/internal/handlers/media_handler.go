@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type MediaHandler struct {
+	mediaService *services.MediaService
+	log          *slog.Logger
+}
+
+func NewMediaHandler(mediaService *services.MediaService, log *slog.Logger) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+		log:          log,
+	}
+}
+
+func parseMediaParams(c *fiber.Ctx) (string, int, error) {
+	kind := c.Params("kind")
+	if kind != services.MediaVideo && kind != services.MediaFile && kind != services.MediaAttachment &&
+		kind != services.MediaHomework && kind != services.MediaReceipt {
+		return "", 0, fmt.Errorf("unknown media kind %q", kind)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid media ID")
+	}
+
+	return kind, id, nil
+}
+
+// ServeMedia streams a course video or attachment to an authenticated user with access to the course
+func (h *MediaHandler) ServeMedia(c *fiber.Ctx) error {
+	const op = "handlers.media_handler.ServeMedia"
+	log := h.log.With("op", op)
+
+	kind, videoID, err := parseMediaParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	path, err := h.mediaService.ResolveMedia(kind, videoID, userID)
+	if err != nil {
+		return h.mediaError(c, log, err)
+	}
+
+	return h.send(c, kind, path)
+}
+
+// ServeSignedMedia streams media by a signed link, used by <video> tags that can't send cookies
+func (h *MediaHandler) ServeSignedMedia(c *fiber.Ctx) error {
+	const op = "handlers.media_handler.ServeSignedMedia"
+	log := h.log.With("op", op)
+
+	kind, videoID, err := parseMediaParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	path, err := h.mediaService.ResolveSigned(kind, videoID, c.Query("uid"), c.Query("exp"), c.Query("sig"))
+	if err != nil {
+		return h.mediaError(c, log, err)
+	}
+
+	return h.send(c, kind, path)
+}
+
+func (h *MediaHandler) GetSignedURL(c *fiber.Ctx) error {
+	const op = "handlers.media_handler.GetSignedURL"
+	log := h.log.With("op", op)
+
+	kind, videoID, err := parseMediaParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	if _, err := h.mediaService.ResolveMedia(kind, videoID, userID); err != nil {
+		return h.mediaError(c, log, err)
+	}
+
+	url, expiresAt := h.mediaService.SignedURL(kind, videoID, userID)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"url": url, "expires_at": expiresAt})
+}
+
+func (h *MediaHandler) send(c *fiber.Ctx, kind, path string) error {
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	if kind != services.MediaVideo {
+		c.Attachment(originalFilename(path))
+	}
+
+	// SendFile answers Range requests with 206 Partial Content, so players can seek
+	return c.SendFile(uploadFilePath(path))
+}
+
+func (h *MediaHandler) mediaError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidSignature):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Link is invalid or expired"})
+	case errors.Is(err, services.ErrNoAccess):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
+	case errors.Is(err, services.ErrMediaNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Media is not found"})
+	default:
+		log.Error("failed to resolve media", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load media"})
+	}
+}
+
+// originalFilename strips the "<unixnano>_" prefix that uploads get on save
+func originalFilename(path string) string {
+	name := filepath.Base(path)
+	if i := strings.Index(name, "_"); i > 0 {
+		if _, err := strconv.ParseInt(name[:i], 10, 64); err == nil {
+			return name[i+1:]
+		}
+	}
+	return name
+}
@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModuleHandler struct {
+	moduleService *services.ModuleService
+	log           *slog.Logger
+}
+
+func NewModuleHandler(moduleService *services.ModuleService, log *slog.Logger) *ModuleHandler {
+	return &ModuleHandler{
+		moduleService: moduleService,
+		log:           log,
+	}
+}
+
+func (h *ModuleHandler) moduleError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrModuleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Module is not found"})
+	case errors.Is(err, postgres.ErrVideoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Lesson is not found"})
+	case errors.Is(err, postgres.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Attachment is not found"})
+	case errors.Is(err, services.ErrModuleNotEmpty):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Move or delete the lessons of the module first"})
+	case errors.Is(err, postgres.ErrInvalidOrder), errors.Is(err, services.ErrInvalidUnlock):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("module operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *ModuleHandler) CreateModule(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.CreateModule"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.ModuleRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+
+	module, err := h.moduleService.CreateModule(courseID, strings.TrimSpace(req.Title))
+	if err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"module": module})
+}
+
+func (h *ModuleHandler) RenameModule(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.RenameModule"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("moduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid module ID"})
+	}
+
+	var req structures.ModuleRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+
+	if err := h.moduleService.RenameModule(id, strings.TrimSpace(req.Title)); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "module updated"})
+}
+
+func (h *ModuleHandler) DeleteModule(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.DeleteModule"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("moduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid module ID"})
+	}
+
+	if err := h.moduleService.DeleteModule(id); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "module deleted"})
+}
+
+func (h *ModuleHandler) ReorderModules(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.ReorderModules"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.OrderRequest
+	if err := c.BodyParser(&req); err != nil || len(req.Ids) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "ids are required"})
+	}
+
+	if err := h.moduleService.ReorderModules(courseID, req.Ids); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "modules reordered"})
+}
+
+// CreateLesson adds a lesson to the end of a module. Form fields: title, content, video (optional),
+// attachment[] with matching attachment_title[] (optional).
+func (h *ModuleHandler) CreateLesson(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.CreateLesson"
+	log := h.log.With("op", op)
+
+	moduleID, err := strconv.Atoi(c.Params("moduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid module ID"})
+	}
+
+	lesson := structures.Video{
+		Title:   strings.TrimSpace(c.FormValue("title")),
+		Content: c.FormValue("content"),
+	}
+	if lesson.Title == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		log.Error("failed to parse multipart form", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid form data"})
+	}
+
+	attachments := form.File["attachment[]"]
+	attachmentTitles := form.Value["attachment_title[]"]
+	for _, a := range attachments {
+		if !contains(attachmentTypes, a.Header.Get("Content-Type")) || a.Size > maxAttachmentSize {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "attachments must be PDF, JPEG, PNG or ZIP up to 100 MB"})
+		}
+	}
+
+	if video, err := c.FormFile("video"); err == nil && video != nil {
+		var ok bool
+		if lesson.Duration, ok = parseVideoDuration(c.FormValue("duration")); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "duration in seconds is required with a video"})
+		}
+		lesson.Path, err = saveUpload(c, video, "videos", log)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save video"})
+		}
+	}
+
+	lessonID, err := h.moduleService.CreateLesson(moduleID, lesson)
+	if err != nil {
+		removeUpload(lesson.Path, log)
+		return h.moduleError(c, log, err)
+	}
+
+	for i, a := range attachments {
+		title := a.Filename
+		if i < len(attachmentTitles) && attachmentTitles[i] != "" {
+			title = attachmentTitles[i]
+		}
+
+		path, err := saveUpload(c, a, "files", log)
+		if err != nil {
+			continue
+		}
+		if _, err := h.moduleService.AddAttachment(lessonID, title, path); err != nil {
+			log.Error("failed to add attachment", sl.Err(err))
+			removeUpload(path, log)
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"lesson_id": lessonID})
+}
+
+func (h *ModuleHandler) UpdateLesson(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.UpdateLesson"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("lessonId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid lesson ID"})
+	}
+
+	var req structures.LessonUpdateRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Title) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is required"})
+	}
+
+	if err := h.moduleService.UpdateLesson(id, strings.TrimSpace(req.Title), req.Content); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "lesson updated"})
+}
+
+// SetUnlock sets the drip schedule of the lesson: {"unlock_after_days": 7, "unlock_at": "2025-09-01T00:00:00Z"}
+func (h *ModuleHandler) SetUnlock(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.SetUnlock"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("lessonId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid lesson ID"})
+	}
+
+	var req structures.LessonUnlockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.moduleService.SetUnlock(id, req); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "lesson unlock updated"})
+}
+
+func (h *ModuleHandler) ReorderLessons(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.ReorderLessons"
+	log := h.log.With("op", op)
+
+	moduleID, err := strconv.Atoi(c.Params("moduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid module ID"})
+	}
+
+	var req structures.OrderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.moduleService.ReorderLessons(moduleID, req.Ids); err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "lessons reordered"})
+}
+
+// AddAttachment uploads one more material to a lesson. Form fields: file, title (optional).
+func (h *ModuleHandler) AddAttachment(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.AddAttachment"
+	log := h.log.With("op", op)
+
+	lessonID, err := strconv.Atoi(c.Params("lessonId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid lesson ID"})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil || file == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file is required"})
+	}
+	if !contains(attachmentTypes, file.Header.Get("Content-Type")) || file.Size > maxAttachmentSize {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "attachments must be PDF, JPEG, PNG or ZIP up to 100 MB"})
+	}
+
+	title := c.FormValue("title")
+	if title == "" {
+		title = file.Filename
+	}
+
+	path, err := saveUpload(c, file, "files", log)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save file"})
+	}
+
+	attachment, err := h.moduleService.AddAttachment(lessonID, title, path)
+	if err != nil {
+		removeUpload(path, log)
+		return h.moduleError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"attachment": attachment})
+}
+
+func (h *ModuleHandler) DeleteAttachment(c *fiber.Ctx) error {
+	const op = "handlers.module_handler.DeleteAttachment"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid attachment ID"})
+	}
+
+	attachment, err := h.moduleService.DeleteAttachment(id)
+	if err != nil {
+		return h.moduleError(c, log, err)
+	}
+
+	removeUpload(attachment.Path, log)
+
+	return c.JSON(fiber.Map{"message": "attachment deleted"})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/payments"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type OrderHandler struct {
+	orderService *services.OrderService
+	log          *slog.Logger
+}
+
+func NewOrderHandler(orderService *services.OrderService, log *slog.Logger) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		log:          log,
+	}
+}
+
+func (h *OrderHandler) orderError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrOrderNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Order is not found"})
+	case errors.Is(err, postgres.ErrPromoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Promo code is not found"})
+	case errors.Is(err, postgres.ErrPlanNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Plan is not found"})
+	case errors.Is(err, postgres.ErrBundleNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Bundle is not found"})
+	case errors.Is(err, postgres.ErrCohortNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cohort is not found"})
+	case errors.Is(err, services.ErrNotForSale), errors.Is(err, services.ErrPlanRequired), errors.Is(err, services.ErrCohortRequired), errors.Is(err, services.ErrPromoExpired),
+		errors.Is(err, services.ErrPromoNotApplicable), errors.Is(err, services.ErrPromoExhausted):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrAlreadyHasAccess), errors.Is(err, services.ErrOrderNotPaid), errors.Is(err, services.ErrCohortFull):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, payments.ErrInvalidSignature):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrMockDisabled):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("order operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// Checkout creates an order, body: {"course_id":1,"plan_id":2,"cohort_id":3,"promo_code":"..."} or {"bundle_id":1,"promo_code":"..."}.
+// The user pays at checkout_url of the returned order.
+func (h *OrderHandler) Checkout(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.Checkout"
+	log := h.log.With("op", op)
+
+	var req structures.CheckoutRequest
+	if err := c.BodyParser(&req); err != nil || (req.CourseID == 0) == (req.BundleID == 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "either course_id or bundle_id is required"})
+	}
+	if req.BundleID != 0 {
+		req.PlanID, req.CohortID = 0, 0
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	order, err := h.orderService.Checkout(userID, req)
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"order": order})
+}
+
+// GetPrice returns the final price of a course, query: plan_id, promo_code
+func (h *OrderHandler) GetPrice(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.GetPrice"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	price, err := h.orderService.Quote(userID, structures.CheckoutRequest{
+		CourseID:  courseID,
+		PlanID:    c.QueryInt("plan_id"),
+		PromoCode: c.Query("promo_code"),
+	})
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"price": price})
+}
+
+// GetBundlePrice returns the final price of a bundle, query: promo_code
+func (h *OrderHandler) GetBundlePrice(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.GetBundlePrice"
+	log := h.log.With("op", op)
+
+	bundleID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid bundle ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	price, err := h.orderService.Quote(userID, structures.CheckoutRequest{
+		BundleID:  bundleID,
+		PromoCode: c.Query("promo_code"),
+	})
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"price": price})
+}
+
+func (h *OrderHandler) GetMyOrders(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.GetMyOrders"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	orders, err := h.orderService.GetMyOrders(userID)
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"orders": orders})
+}
+
+func (h *OrderHandler) GetMyOrder(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.GetMyOrder"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid order ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	order, err := h.orderService.GetMyOrder(id, userID)
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"order": order})
+}
+
+// GetOrders lists orders for admins, query: user_id, course_id, status
+func (h *OrderHandler) GetOrders(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.GetOrders"
+	log := h.log.With("op", op)
+
+	orders, err := h.orderService.GetOrders(structures.OrderFilter{
+		UserID:   c.QueryInt("user_id"),
+		CourseID: c.QueryInt("course_id"),
+		Status:   c.Query("status"),
+	})
+	if err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"orders": orders})
+}
+
+func (h *OrderHandler) Refund(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.Refund"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid order ID"})
+	}
+
+	if err := h.orderService.Refund(id); err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "order refunded"})
+}
+
+// Webhook receives payment notifications from the provider
+func (h *OrderHandler) Webhook(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.Webhook"
+	log := h.log.With("op", op)
+
+	if err := h.orderService.HandleWebhook(c.Body(), func(key string) string { return c.Get(key) }); err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "ok"})
+}
+
+// MockPay completes a payment of the mock provider, query: result=paid|failed (paid by default)
+func (h *OrderHandler) MockPay(c *fiber.Ctx) error {
+	const op = "handlers.order_handler.MockPay"
+	log := h.log.With("op", op)
+
+	result := c.Query("result", payments.EventPaid)
+	if result != payments.EventPaid && result != payments.EventFailed {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "result must be paid or failed"})
+	}
+
+	if err := h.orderService.SimulatePayment(c.Params("paymentId"), result); err != nil {
+		return h.orderError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "payment " + result})
+}
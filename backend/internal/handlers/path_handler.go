@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PathHandler struct {
+	pathService *services.PathService
+	log         *slog.Logger
+}
+
+func NewPathHandler(pathService *services.PathService, log *slog.Logger) *PathHandler {
+	return &PathHandler{
+		pathService: pathService,
+		log:         log,
+	}
+}
+
+func (h *PathHandler) pathError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPath):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, postgres.ErrPathNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Learning path is not found"})
+	default:
+		log.Error("learning path operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// GetPaths lists active paths, admins get inactive paths too
+func (h *PathHandler) GetPaths(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.GetPaths"
+	log := h.log.With("op", op)
+
+	role, _ := c.Locals("role").(string)
+
+	paths, err := h.pathService.GetPaths(role == structures.RoleAdmin)
+	if err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"paths": paths})
+}
+
+func (h *PathHandler) GetPath(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.GetPath"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid path ID"})
+	}
+
+	role, _ := c.Locals("role").(string)
+
+	path, err := h.pathService.GetPath(id, role == structures.RoleAdmin)
+	if err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"path": path})
+}
+
+// GetMyPaths returns the user's position in every active path
+func (h *PathHandler) GetMyPaths(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.GetMyPaths"
+	log := h.log.With("op", op)
+
+	userID, _ := c.Locals("userId").(int)
+
+	paths, err := h.pathService.GetMyPaths(userID)
+	if err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"paths": paths})
+}
+
+// CreatePath body: {"title":"...","description":"...","active":true,"courses":[{"course_id":1},{"course_id":2,"prerequisite_id":1}]}
+func (h *PathHandler) CreatePath(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.CreatePath"
+	log := h.log.With("op", op)
+
+	var req structures.LearningPathRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	path, err := h.pathService.CreatePath(req)
+	if err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"path": path})
+}
+
+func (h *PathHandler) UpdatePath(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.UpdatePath"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid path ID"})
+	}
+
+	var req structures.LearningPathRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	path, err := h.pathService.UpdatePath(id, req)
+	if err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"path": path})
+}
+
+func (h *PathHandler) DeletePath(c *fiber.Ctx) error {
+	const op = "handlers.path_handler.DeletePath"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid path ID"})
+	}
+
+	if err := h.pathService.DeletePath(id); err != nil {
+		return h.pathError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "learning path deleted"})
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PlanHandler struct {
+	planService *services.PlanService
+	log         *slog.Logger
+}
+
+func NewPlanHandler(planService *services.PlanService, log *slog.Logger) *PlanHandler {
+	return &PlanHandler{
+		planService: planService,
+		log:         log,
+	}
+}
+
+func (h *PlanHandler) planError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPlan):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrPlanNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Plan is not found"})
+	case errors.Is(err, postgres.ErrPlanInUse):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("plan operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *PlanHandler) GetPlans(c *fiber.Ctx) error {
+	const op = "handlers.plan_handler.GetPlans"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	plans, err := h.planService.GetPlans(courseID)
+	if err != nil {
+		return h.planError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"plans": plans})
+}
+
+// CreatePlan body: {"title":"...","description":"...","cost":10000,"features":["webinars","homework","diploma"],"position":1,"active":true}
+func (h *PlanHandler) CreatePlan(c *fiber.Ctx) error {
+	const op = "handlers.plan_handler.CreatePlan"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.CoursePlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	plan, err := h.planService.CreatePlan(courseID, req)
+	if err != nil {
+		return h.planError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"plan": plan})
+}
+
+func (h *PlanHandler) UpdatePlan(c *fiber.Ctx) error {
+	const op = "handlers.plan_handler.UpdatePlan"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("planId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid plan ID"})
+	}
+
+	var req structures.CoursePlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	plan, err := h.planService.UpdatePlan(id, req)
+	if err != nil {
+		return h.planError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"plan": plan})
+}
+
+func (h *PlanHandler) DeletePlan(c *fiber.Ctx) error {
+	const op = "handlers.plan_handler.DeletePlan"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("planId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid plan ID"})
+	}
+
+	if err := h.planService.DeletePlan(id); err != nil {
+		return h.planError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "plan deleted"})
+}
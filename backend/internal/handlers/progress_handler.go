@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProgressHandler struct {
+	progressService *services.ProgressService
+	log             *slog.Logger
+}
+
+func NewProgressHandler(progressService *services.ProgressService, log *slog.Logger) *ProgressHandler {
+	return &ProgressHandler{
+		progressService: progressService,
+		log:             log,
+	}
+}
+
+func (h *ProgressHandler) Heartbeat(c *fiber.Ctx) error {
+	const op = "handlers.progress_handler.Heartbeat"
+	log := h.log.With("op", op)
+
+	var hb structures.ProgressHeartbeat
+	if err := c.BodyParser(&hb); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if hb.VideoID == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "video_id is required"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	progress, err := h.progressService.Heartbeat(userID, hb)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNoAccess):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
+		case errors.Is(err, postgres.ErrVideoNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Video is not found"})
+		default:
+			log.Error("failed to save progress", sl.Err(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to save progress"})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"progress": progress})
+}
+
+func (h *ProgressHandler) GetCourseReport(c *fiber.Ctx) error {
+	const op = "handlers.progress_handler.GetCourseReport"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		log.Error("invalid course ID", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	learners, err := h.progressService.GetCourseReport(courseID)
+	if err != nil {
+		log.Error("failed to fetch course report", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch course progress"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"learners": learners})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromoHandler struct {
+	promoService *services.PromoService
+	log          *slog.Logger
+}
+
+func NewPromoHandler(promoService *services.PromoService, log *slog.Logger) *PromoHandler {
+	return &PromoHandler{
+		promoService: promoService,
+		log:          log,
+	}
+}
+
+func (h *PromoHandler) promoError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidPromo):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, postgres.ErrPromoDuplicate):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, postgres.ErrPromoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Promo code is not found"})
+	default:
+		log.Error("promo code operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// CreatePromo body: {"code","kind":"percent|fixed","value","expires_at","max_uses","max_uses_per_user","course_ids":[],"active"}
+func (h *PromoHandler) CreatePromo(c *fiber.Ctx) error {
+	const op = "handlers.promo_handler.CreatePromo"
+	log := h.log.With("op", op)
+
+	promo := structures.PromoCode{Active: true, MaxUsesPerUser: 1}
+	if err := c.BodyParser(&promo); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	id, err := h.promoService.CreatePromo(promo)
+	if err != nil {
+		return h.promoError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"promo_code_id": id})
+}
+
+func (h *PromoHandler) UpdatePromo(c *fiber.Ctx) error {
+	const op = "handlers.promo_handler.UpdatePromo"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid promo code ID"})
+	}
+
+	var promo structures.PromoCode
+	if err := c.BodyParser(&promo); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.promoService.UpdatePromo(id, promo); err != nil {
+		return h.promoError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "promo code updated"})
+}
+
+func (h *PromoHandler) DeletePromo(c *fiber.Ctx) error {
+	const op = "handlers.promo_handler.DeletePromo"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid promo code ID"})
+	}
+
+	if err := h.promoService.DeletePromo(id); err != nil {
+		return h.promoError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "promo code deleted"})
+}
+
+func (h *PromoHandler) GetPromos(c *fiber.Ctx) error {
+	const op = "handlers.promo_handler.GetPromos"
+	log := h.log.With("op", op)
+
+	promos, err := h.promoService.GetPromos()
+	if err != nil {
+		return h.promoError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"promo_codes": promos})
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type QuizHandler struct {
+	quizService *services.QuizService
+	log         *slog.Logger
+}
+
+func NewQuizHandler(quizService *services.QuizService, log *slog.Logger) *QuizHandler {
+	return &QuizHandler{
+		quizService: quizService,
+		log:         log,
+	}
+}
+
+func (h *QuizHandler) quizError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidQuiz):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoAccess):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "User has no access for course"})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrVideoNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Lesson is not found"})
+	case errors.Is(err, postgres.ErrQuizNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Quiz is not found"})
+	default:
+		log.Error("quiz operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *QuizHandler) CreateQuiz(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.CreateQuiz"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var quiz structures.Quiz
+	if err := c.BodyParser(&quiz); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	id, err := h.quizService.CreateQuiz(courseID, quiz)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"quiz_id": id})
+}
+
+func (h *QuizHandler) UpdateQuiz(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.UpdateQuiz"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	var quiz structures.Quiz
+	if err := c.BodyParser(&quiz); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.quizService.UpdateQuiz(id, quiz); err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "quiz updated"})
+}
+
+func (h *QuizHandler) DeleteQuiz(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.DeleteQuiz"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	if err := h.quizService.DeleteQuiz(id); err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "quiz deleted"})
+}
+
+func (h *QuizHandler) GetQuizForAdmin(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetQuizForAdmin"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	quiz, err := h.quizService.GetQuizForAdmin(id)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"quiz": quiz})
+}
+
+func (h *QuizHandler) GetCourseQuizzesForAdmin(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetCourseQuizzesForAdmin"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	quizzes, err := h.quizService.GetCourseQuizzesForAdmin(courseID)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"quizzes": quizzes})
+}
+
+func (h *QuizHandler) GetAllAttempts(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetAllAttempts"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	attempts, err := h.quizService.GetAllAttempts(id)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"attempts": attempts})
+}
+
+func (h *QuizHandler) GetCourseQuizzes(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetCourseQuizzes"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	quizzes, err := h.quizService.GetCourseQuizzes(courseID, userID)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"quizzes": quizzes})
+}
+
+func (h *QuizHandler) GetQuiz(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetQuiz"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	quiz, err := h.quizService.GetQuiz(id, userID)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"quiz": quiz})
+}
+
+// SubmitAttempt scores the answers, body: {"answers":[{"question_id":1,"option_ids":[2]},{"question_id":3,"text":"..."}]}
+func (h *QuizHandler) SubmitAttempt(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.SubmitAttempt"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	var sub structures.QuizSubmission
+	if err := c.BodyParser(&sub); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	attempt, err := h.quizService.Submit(id, userID, sub)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"attempt": attempt})
+}
+
+func (h *QuizHandler) GetAttempts(c *fiber.Ctx) error {
+	const op = "handlers.quiz_handler.GetAttempts"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	attempts, err := h.quizService.GetAttempts(id, userID)
+	if err != nil {
+		return h.quizError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"attempts": attempts})
+}
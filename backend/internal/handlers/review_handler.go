@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+	log           *slog.Logger
+}
+
+func NewReviewHandler(reviewService *services.ReviewService, log *slog.Logger) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: reviewService,
+		log:           log,
+	}
+}
+
+func (h *ReviewHandler) reviewError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidCourseReview):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrNoAccess):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only users with access to the course can review it"})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrReviewNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Review is not found"})
+	default:
+		log.Error("review operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// Submit body: {"rating":5,"text":"..."}. The review is shown after moderation.
+func (h *ReviewHandler) Submit(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.Submit"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.CourseReviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	review, err := h.reviewService.Submit(userID, courseID, req)
+	if err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"review": review})
+}
+
+func (h *ReviewHandler) GetMyReview(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.GetMyReview"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	review, err := h.reviewService.GetMyReview(userID, courseID)
+	if err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"review": review})
+}
+
+func (h *ReviewHandler) DeleteMyReview(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.DeleteMyReview"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	if err := h.reviewService.DeleteMyReview(userID, courseID); err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "review deleted"})
+}
+
+// GetCourseReviews lists approved reviews of the course, query: page, limit (20 by default, up to 100)
+func (h *ReviewHandler) GetCourseReviews(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.GetCourseReviews"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	page, err := h.reviewService.GetCourseReviews(courseID, c.QueryInt("page", 1), c.QueryInt("limit"))
+	if err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(page)
+}
+
+// GetReviews is the admin moderation queue, query: status (pending by default, "all" for every status),
+// course_id, user_id, page, limit
+func (h *ReviewHandler) GetReviews(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.GetReviews"
+	log := h.log.With("op", op)
+
+	status := c.Query("status", structures.CourseReviewPending)
+	if status == "all" {
+		status = ""
+	}
+
+	page, err := h.reviewService.GetReviews(structures.CourseReviewFilter{
+		CourseID: c.QueryInt("course_id"),
+		UserID:   c.QueryInt("user_id"),
+		Status:   status,
+	}, c.QueryInt("page", 1), c.QueryInt("limit"))
+	if err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(page)
+}
+
+// Approve body (optional): {"comment":"..."}
+func (h *ReviewHandler) Approve(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.Approve"
+	log := h.log.With("op", op)
+
+	id, decision, err := parseDecision(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	if err := h.reviewService.Approve(id, adminID, decision.Comment); err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "review approved"})
+}
+
+// Reject body (optional): {"comment":"..."}, the author sees the comment on their review
+func (h *ReviewHandler) Reject(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.Reject"
+	log := h.log.With("op", op)
+
+	id, decision, err := parseDecision(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	adminID, _ := c.Locals("userId").(int)
+
+	if err := h.reviewService.Reject(id, adminID, decision.Comment); err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "review rejected"})
+}
+
+func (h *ReviewHandler) DeleteReview(c *fiber.Ctx) error {
+	const op = "handlers.review_handler.DeleteReview"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid review ID"})
+	}
+
+	if err := h.reviewService.DeleteReview(id); err != nil {
+		return h.reviewError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "review deleted"})
+}
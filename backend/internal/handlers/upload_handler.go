@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Resumable uploads follow the core tus 1.0.0 protocol with the creation and termination extensions (https://tus.io/protocols/resumable-upload)
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,termination"
+	tusChunkType  = "application/offset+octet-stream"
+)
+
+type UploadHandler struct {
+	uploadService *services.UploadService
+	log           *slog.Logger
+}
+
+func NewUploadHandler(uploadService *services.UploadService, log *slog.Logger) *UploadHandler {
+	return &UploadHandler{
+		uploadService: uploadService,
+		log:           log,
+	}
+}
+
+func uploadPartPath(id string) string {
+	return filepath.Join(uploadBaseDir, "tmp", id+".part")
+}
+
+// parseUploadMetadata decodes the tus Upload-Metadata header: "key base64value,key base64value"
+func parseUploadMetadata(header string) map[string]string {
+	meta := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), " ", 2)
+		if parts[0] == "" {
+			continue
+		}
+		value := ""
+		if len(parts) == 2 {
+			if decoded, err := base64.StdEncoding.DecodeString(parts[1]); err == nil {
+				value = string(decoded)
+			}
+		}
+		meta[parts[0]] = value
+	}
+	return meta
+}
+
+func (h *UploadHandler) Options(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set("Tus-Version", tusVersion)
+	c.Set("Tus-Extension", tusExtensions)
+	c.Set("Tus-Max-Size", strconv.FormatInt(services.MaxUploadSize, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// CreateUpload starts a session. Course, title, file name and video duration in seconds come in Upload-Metadata
+// (course_id, title, filename, duration).
+func (h *UploadHandler) CreateUpload(c *fiber.Ctx) error {
+	const op = "handlers.upload_handler.CreateUpload"
+	log := h.log.With("op", op)
+
+	c.Set("Tus-Resumable", tusVersion)
+
+	size, err := strconv.ParseInt(c.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Upload-Length header is required"})
+	}
+
+	meta := parseUploadMetadata(c.Get("Upload-Metadata"))
+	courseID, err := strconv.Atoi(meta["course_id"])
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "course_id metadata is required"})
+	}
+	if meta["title"] == "" || meta["filename"] == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title and filename metadata are required"})
+	}
+	duration, ok := parseVideoDuration(meta["duration"])
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "duration metadata in seconds is required"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	session, err := h.uploadService.CreateSession(structures.UploadSession{
+		CourseID:  courseID,
+		Title:     meta["title"],
+		Filename:  filepath.Base(meta["filename"]),
+		Size:      size,
+		Duration:  duration,
+		CreatedBy: userID,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrUploadTooLarge) {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error("failed to create upload", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if err := ensureDir(filepath.Dir(uploadPartPath(session.Id)), log); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create uploads dir"})
+	}
+
+	c.Location(c.BaseURL() + "/api/v1/admin/upload/" + session.Id)
+	c.Set("Upload-Offset", "0")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"upload": session})
+}
+
+// HeadUpload reports the current offset so the client knows where to resume
+func (h *UploadHandler) HeadUpload(c *fiber.Ctx) error {
+	c.Set("Tus-Resumable", tusVersion)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	session, err := h.uploadService.GetSession(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, postgres.ErrUploadNotFound) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+		return c.SendStatus(fiber.StatusInternalServerError)
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(session.Size, 10))
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// GetUpload returns the session with its progress for the admin UI
+func (h *UploadHandler) GetUpload(c *fiber.Ctx) error {
+	const op = "handlers.upload_handler.GetUpload"
+	log := h.log.With("op", op)
+
+	session, err := h.uploadService.GetSession(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, postgres.ErrUploadNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Upload not found"})
+		}
+		log.Error("failed to get upload", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get upload"})
+	}
+
+	progress := 100
+	if session.Size > 0 {
+		progress = int(session.Offset * 100 / session.Size)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"upload": session, "progress": progress})
+}
+
+// PatchUpload appends a chunk at Upload-Offset. The last chunk turns the file into a course video.
+func (h *UploadHandler) PatchUpload(c *fiber.Ctx) error {
+	const op = "handlers.upload_handler.PatchUpload"
+	log := h.log.With("op", op)
+
+	c.Set("Tus-Resumable", tusVersion)
+
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), tusChunkType) {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{"error": "Content-Type must be " + tusChunkType})
+	}
+
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Upload-Offset header is required"})
+	}
+
+	session, err := h.uploadService.GetSession(c.Params("id"))
+	if err != nil {
+		if errors.Is(err, postgres.ErrUploadNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Upload not found"})
+		}
+		log.Error("failed to get upload", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to get upload"})
+	}
+
+	if session.Status != structures.UploadStatusUploading {
+		c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	if offset != session.Offset {
+		c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Upload-Offset does not match", "offset": session.Offset})
+	}
+
+	chunk := c.Body()
+	if offset+int64(len(chunk)) > session.Size {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"error": "chunk exceeds Upload-Length"})
+	}
+
+	if len(chunk) > 0 {
+		err := h.uploadService.Advance(session.Id, offset, int64(len(chunk)), func() error {
+			return writeChunk(uploadPartPath(session.Id), offset, chunk)
+		})
+		if err != nil {
+			if errors.Is(err, postgres.ErrUploadOffsetMoved) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Upload-Offset does not match"})
+			}
+			if errors.Is(err, postgres.ErrUploadNotUploading) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "upload is not in progress"})
+			}
+			log.Error("failed to advance upload", sl.Err(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to save chunk"})
+		}
+		session.Offset += int64(len(chunk))
+	}
+
+	// Also reached by an empty PATCH when a previous finalization failed, so it can be retried
+	if session.Offset == session.Size {
+		if err := h.finalize(session); err != nil {
+			log.Error("failed to finalize upload", slog.String("id", session.Id), sl.Err(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to attach video to course"})
+		}
+	}
+
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *UploadHandler) finalize(session structures.UploadSession) error {
+	videoDir := filepath.Join(uploadBaseDir, "videos")
+	if err := ensureDir(videoDir, h.log); err != nil {
+		return err
+	}
+
+	videoFilename := fmt.Sprintf("%s_%s", session.Id, session.Filename)
+	finalPath := filepath.Join(videoDir, videoFilename)
+
+	if err := os.Rename(uploadPartPath(session.Id), finalPath); err != nil {
+		// the file may already be in place if attaching it failed on a previous attempt
+		if _, statErr := os.Stat(finalPath); statErr != nil {
+			return err
+		}
+	}
+
+	return h.uploadService.Complete(session, "/uploads/videos/"+videoFilename)
+}
+
+// RemoveParts deletes the partial files of the given uploads and any partial file untouched since staleBefore,
+// which covers uploads whose session went away with their course
+func (h *UploadHandler) RemoveParts(ids []string, staleBefore time.Time) {
+	const op = "handlers.upload_handler.RemoveParts"
+	log := h.log.With("op", op)
+
+	for _, id := range ids {
+		if err := os.Remove(uploadPartPath(id)); err != nil && !os.IsNotExist(err) {
+			log.Warn("failed to remove partial upload", slog.String("id", id), sl.Err(err))
+		}
+	}
+
+	parts, err := filepath.Glob(filepath.Join(uploadBaseDir, "tmp", "*.part"))
+	if err != nil {
+		log.Warn("failed to list partial uploads", sl.Err(err))
+		return
+	}
+	for _, p := range parts {
+		info, err := os.Stat(p)
+		if err != nil || info.ModTime().After(staleBefore) {
+			continue
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Warn("failed to remove partial upload", slog.String("path", p), sl.Err(err))
+		}
+	}
+}
+
+func writeChunk(path string, offset int64, chunk []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = f.Write(chunk)
+	return err
+}
+
+func (h *UploadHandler) DeleteUpload(c *fiber.Ctx) error {
+	const op = "handlers.upload_handler.DeleteUpload"
+	log := h.log.With("op", op)
+
+	c.Set("Tus-Resumable", tusVersion)
+
+	id := c.Params("id")
+	if err := h.uploadService.DeleteSession(id); err != nil {
+		if errors.Is(err, postgres.ErrUploadNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Upload not found"})
+		}
+		log.Error("failed to delete upload", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to delete upload"})
+	}
+
+	if err := os.Remove(uploadPartPath(id)); err != nil && !os.IsNotExist(err) {
+		log.Warn("failed to remove partial upload", slog.String("id", id), sl.Err(err))
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
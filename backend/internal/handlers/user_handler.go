@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"log/slog"
+	"strconv"
 	"time"
 
 	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
 	"github.com/QwaQ-dev/bala/internal/services"
 	"github.com/QwaQ-dev/bala/internal/structures"
 	"github.com/QwaQ-dev/bala/pkg/sl"
@@ -152,3 +155,33 @@ func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(users)
 }
+
+// SetRole assigns a role to a user, body: {"role":"user|curator|admin"}
+func (h *UserHandler) SetRole(c *fiber.Ctx) error {
+	const op = "handlers.user_handler.SetRole"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user ID"})
+	}
+
+	var req structures.RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if err := h.userService.SetRole(id, req.Role); err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, postgres.ErrUserNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User is not found"})
+		default:
+			log.Error("failed to set role", sl.Err(err))
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to set role"})
+		}
+	}
+
+	return c.JSON(fiber.Map{"message": "role updated"})
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type VoucherHandler struct {
+	voucherService *services.VoucherService
+	log            *slog.Logger
+}
+
+func NewVoucherHandler(voucherService *services.VoucherService, log *slog.Logger) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+		log:            log,
+	}
+}
+
+func (h *VoucherHandler) voucherError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidVoucherBatch), errors.Is(err, services.ErrCohortRequired),
+		errors.Is(err, services.ErrInvalidAccess):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrCohortNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Cohort is not found"})
+	case errors.Is(err, services.ErrCohortFull):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, postgres.ErrVoucherBatchNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Voucher batch is not found"})
+	case errors.Is(err, postgres.ErrVoucherNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Voucher code is not found"})
+	case errors.Is(err, postgres.ErrVoucherRedeemed), errors.Is(err, services.ErrAlreadyHasAccess):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrVoucherExpired):
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+	default:
+		log.Error("voucher operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+// CreateBatch body: {"course_id":1,"cohort_id":2,"count":100,"title":"...","expires_at":"2025-12-31T23:59:59Z"}
+func (h *VoucherHandler) CreateBatch(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.CreateBatch"
+	log := h.log.With("op", op)
+
+	var req structures.VoucherBatchRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	batch, err := h.voucherService.CreateBatch(req)
+	if err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"batch": batch})
+}
+
+// GetBatches lists batches without codes, query: course_id
+func (h *VoucherHandler) GetBatches(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.GetBatches"
+	log := h.log.With("op", op)
+
+	batches, err := h.voucherService.GetBatches(c.QueryInt("course_id"))
+	if err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"batches": batches})
+}
+
+func (h *VoucherHandler) GetBatch(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.GetBatch"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid batch ID"})
+	}
+
+	batch, err := h.voucherService.GetBatch(id)
+	if err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"batch": batch})
+}
+
+func (h *VoucherHandler) DeleteBatch(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.DeleteBatch"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid batch ID"})
+	}
+
+	if err := h.voucherService.DeleteBatch(id); err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "voucher batch deleted"})
+}
+
+func (h *VoucherHandler) ExportBatch(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.ExportBatch"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid batch ID"})
+	}
+
+	batch, data, err := h.voucherService.ExportBatch(id)
+	if err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	c.Attachment(fmt.Sprintf("vouchers-%d.csv", batch.Id))
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	return c.Send(data)
+}
+
+// Redeem unlocks a course by a voucher code, body: {"code":"K7QX-M2PA-9RTD"}
+func (h *VoucherHandler) Redeem(c *fiber.Ctx) error {
+	const op = "handlers.voucher_handler.Redeem"
+	log := h.log.With("op", op)
+
+	var req structures.RedeemRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	userID, _ := c.Locals("userId").(int)
+
+	courseID, err := h.voucherService.Redeem(userID, req.Code)
+	if err != nil {
+		return h.voucherError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "access granted", "course_id": courseID})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/services"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebinarHandler struct {
+	webinarService *services.WebinarService
+	log            *slog.Logger
+}
+
+func NewWebinarHandler(webinarService *services.WebinarService, log *slog.Logger) *WebinarHandler {
+	return &WebinarHandler{
+		webinarService: webinarService,
+		log:            log,
+	}
+}
+
+func (h *WebinarHandler) webinarError(c *fiber.Ctx, log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, services.ErrInvalidWebinar):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, services.ErrCourseNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Course is not found"})
+	case errors.Is(err, postgres.ErrWebinarNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Webinar is not found"})
+	default:
+		log.Error("webinar operation failed", sl.Err(err))
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal server error"})
+	}
+}
+
+func (h *WebinarHandler) GetCourseWebinars(c *fiber.Ctx) error {
+	const op = "handlers.webinar_handler.GetCourseWebinars"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	webinars, err := h.webinarService.GetCourseWebinars(courseID)
+	if err != nil {
+		return h.webinarError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"webinars": webinars})
+}
+
+// CreateWebinars schedules a webinar, body: {"title","link","date","duration","repeat":"weekly","count":8}
+func (h *WebinarHandler) CreateWebinars(c *fiber.Ctx) error {
+	const op = "handlers.webinar_handler.CreateWebinars"
+	log := h.log.With("op", op)
+
+	courseID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid course ID"})
+	}
+
+	var req structures.WebinarRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	webinars, err := h.webinarService.CreateWebinars(courseID, req)
+	if err != nil {
+		return h.webinarError(c, log, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"webinars": webinars})
+}
+
+func (h *WebinarHandler) UpdateWebinar(c *fiber.Ctx) error {
+	const op = "handlers.webinar_handler.UpdateWebinar"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("webinarId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webinar ID"})
+	}
+
+	var req structures.WebinarRequest
+	if err := c.BodyParser(&req); err != nil {
+		log.Error("failed to parse request body", sl.Err(err))
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	webinar, err := h.webinarService.UpdateWebinar(id, req)
+	if err != nil {
+		return h.webinarError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"webinar": webinar})
+}
+
+// CancelWebinar cancels one webinar, ?series=true also cancels the later sessions of its series
+func (h *WebinarHandler) CancelWebinar(c *fiber.Ctx) error {
+	const op = "handlers.webinar_handler.CancelWebinar"
+	log := h.log.With("op", op)
+
+	id, err := strconv.Atoi(c.Params("webinarId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid webinar ID"})
+	}
+
+	count, err := h.webinarService.CancelWebinar(id, c.QueryBool("series"))
+	if err != nil {
+		return h.webinarError(c, log, err)
+	}
+
+	return c.JSON(fiber.Map{"message": "webinar cancelled", "cancelled": count})
+}
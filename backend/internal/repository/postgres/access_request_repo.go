@@ -0,0 +1,191 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrAccessRequestNotFound = errors.New("access request not found")
+	ErrAccessRequestPending  = errors.New("there is already a pending request for this course")
+)
+
+type AccessRequestRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewAccessRequestRepo(log *slog.Logger, db *sql.DB) *AccessRequestRepo {
+	return &AccessRequestRepo{log: log, db: db}
+}
+
+const accessRequestSelect = `
+	SELECT r.id, r.user_id, u.username, r.course_id, c.title, r.contact, r.comment,
+		COALESCE(r.receipt_path, ''), r.status, r.admin_comment, COALESCE(r.reviewed_by, 0),
+		r.reviewed_at, r.created_at
+	FROM access_requests r
+	JOIN users u ON u.id = r.user_id
+	JOIN courses c ON c.id = r.course_id`
+
+func scanAccessRequest(row interface{ Scan(...any) error }) (structures.AccessRequest, error) {
+	var a structures.AccessRequest
+	var reviewedAt sql.NullTime
+	err := row.Scan(&a.Id, &a.UserID, &a.Username, &a.CourseID, &a.CourseTitle, &a.Contact, &a.Comment,
+		&a.ReceiptPath, &a.Status, &a.AdminComment, &a.ReviewedBy, &reviewedAt, &a.CreatedAt)
+	if reviewedAt.Valid {
+		a.ReviewedAt = &reviewedAt.Time
+	}
+	return a, err
+}
+
+// InsertRequest fails with ErrAccessRequestPending while the user has an unprocessed request for the course
+func (r *AccessRequestRepo) InsertRequest(a structures.AccessRequest) (int, error) {
+	const op = "postgres.access_request_repo.InsertRequest"
+	log := r.log.With("op", op)
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO access_requests (user_id, course_id, contact, comment, receipt_path)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id
+	`, a.UserID, a.CourseID, a.Contact, a.Comment, a.ReceiptPath).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrAccessRequestPending
+		}
+		log.Error("failed to insert access request", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("access requested", slog.Int("id", id), slog.Int("user_id", a.UserID), slog.Int("course_id", a.CourseID))
+	return id, nil
+}
+
+func (r *AccessRequestRepo) SelectRequestById(id int) (structures.AccessRequest, error) {
+	const op = "postgres.access_request_repo.SelectRequestById"
+
+	a, err := scanAccessRequest(r.db.QueryRow(accessRequestSelect+` WHERE r.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return a, ErrAccessRequestNotFound
+		}
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+// SelectRequests returns requests oldest first, so the admin queue is worked in order
+func (r *AccessRequestRepo) SelectRequests(filter structures.AccessRequestFilter) ([]structures.AccessRequest, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		add("r.user_id = $%d", filter.UserID)
+	}
+	if filter.CourseID != 0 {
+		add("r.course_id = $%d", filter.CourseID)
+	}
+	if filter.Status != "" {
+		add("r.status = $%d", filter.Status)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	return r.selectRequests(accessRequestSelect+where+` ORDER BY r.created_at, r.id`, args...)
+}
+
+// SelectLatest returns the newest request of the user for every course they asked for
+func (r *AccessRequestRepo) SelectLatest(userID int) (map[int]structures.AccessRequest, error) {
+	requests, err := r.selectRequests(accessRequestSelect+`
+		WHERE r.user_id = $1
+		ORDER BY r.course_id, r.created_at DESC, r.id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := make(map[int]structures.AccessRequest, len(requests))
+	for _, a := range requests {
+		if _, ok := latest[a.CourseID]; !ok {
+			latest[a.CourseID] = a
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *AccessRequestRepo) selectRequests(query string, args ...any) ([]structures.AccessRequest, error) {
+	const op = "postgres.access_request_repo.selectRequests"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Error("failed to select access requests", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	requests := []structures.AccessRequest{}
+	for rows.Next() {
+		a, err := scanAccessRequest(rows)
+		if err != nil {
+			log.Error("failed to scan access request", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		requests = append(requests, a)
+	}
+
+	return requests, rows.Err()
+}
+
+// Decide moves a pending request to status. It returns false if the request was already decided.
+func (r *AccessRequestRepo) Decide(id int, status, comment string, reviewerID int) (bool, error) {
+	const op = "postgres.access_request_repo.Decide"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE access_requests
+		SET status = $1, admin_comment = $2, reviewed_by = NULLIF($3, 0), reviewed_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $4 AND status = 'pending'
+	`, status, comment, reviewerID, id)
+	if err != nil {
+		log.Error("failed to decide access request", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected > 0 {
+		log.Info("access request decided", slog.Int("id", id), slog.String("status", status), slog.Int("reviewer_id", reviewerID))
+	}
+	return rowsAffected > 0, nil
+}
+
+// Reopen returns an approved request to the queue when giving access failed
+func (r *AccessRequestRepo) Reopen(id int) error {
+	const op = "postgres.access_request_repo.Reopen"
+
+	_, err := r.db.Exec(`
+		UPDATE access_requests
+		SET status = 'pending', admin_comment = '', reviewed_by = NULL, reviewed_at = NULL
+		WHERE id = $1 AND status = 'approved'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
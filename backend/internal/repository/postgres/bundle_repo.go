@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrBundleNotFound = errors.New("bundle not found")
+	ErrBundleInUse    = errors.New("bundle has orders, deactivate it instead")
+)
+
+type BundleRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewBundleRepo(log *slog.Logger, db *sql.DB) *BundleRepo {
+	return &BundleRepo{log: log, db: db}
+}
+
+// InsertBundle creates the bundle together with its courses
+func (r *BundleRepo) InsertBundle(b structures.Bundle) (int, error) {
+	const op = "postgres.bundle_repo.InsertBundle"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO bundles (title, description, cost, active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, b.Title, b.Description, b.Cost, b.Active).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert bundle", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertBundleCourses(tx, id, b.Courses); err != nil {
+		log.Error("failed to insert bundle courses", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("bundle created", slog.Int("id", id), slog.Int("courses", len(b.Courses)))
+	return id, nil
+}
+
+// UpdateBundle saves the bundle and replaces its courses. Access already bought is not changed.
+func (r *BundleRepo) UpdateBundle(b structures.Bundle) error {
+	const op = "postgres.bundle_repo.UpdateBundle"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE bundles SET title = $1, description = $2, cost = $3, active = $4
+		WHERE id = $5
+	`, b.Title, b.Description, b.Cost, b.Active, b.Id)
+	if err != nil {
+		log.Error("failed to update bundle", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrBundleNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM bundle_courses WHERE bundle_id = $1`, b.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertBundleCourses(tx, b.Id, b.Courses); err != nil {
+		log.Error("failed to insert bundle courses", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func insertBundleCourses(tx *sql.Tx, bundleID int, courses []structures.BundleCourse) error {
+	for i, c := range courses {
+		_, err := tx.Exec(`
+			INSERT INTO bundle_courses (bundle_id, course_id, plan_id, position)
+			VALUES ($1, $2, NULLIF($3, 0), $4)
+		`, bundleID, c.CourseID, c.PlanID, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBundle removes a bundle that was never ordered, ordered ones fail with ErrBundleInUse
+// because refunds of their orders still need the list of courses
+func (r *BundleRepo) DeleteBundle(id int) error {
+	const op = "postgres.bundle_repo.DeleteBundle"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		DELETE FROM bundles b
+		WHERE b.id = $1 AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.bundle_id = b.id)
+	`, id)
+	if err != nil {
+		log.Error("failed to delete bundle", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.SelectBundle(id); err != nil {
+			return err
+		}
+		return ErrBundleInUse
+	}
+
+	log.Info("bundle deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *BundleRepo) SelectBundle(id int) (structures.Bundle, error) {
+	const op = "postgres.bundle_repo.SelectBundle"
+
+	bundles, err := r.selectBundles(`WHERE b.id = $1`, id)
+	if err != nil {
+		return structures.Bundle{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(bundles) == 0 {
+		return structures.Bundle{}, ErrBundleNotFound
+	}
+
+	return bundles[0], nil
+}
+
+// SelectBundles returns bundles newest first, activeOnly leaves the ones on sale
+func (r *BundleRepo) SelectBundles(activeOnly bool) ([]structures.Bundle, error) {
+	return r.selectBundles(`WHERE b.active OR NOT $1`, activeOnly)
+}
+
+func (r *BundleRepo) selectBundles(where string, args ...any) ([]structures.Bundle, error) {
+	const op = "postgres.bundle_repo.selectBundles"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT b.id, b.title, b.description, b.cost, b.active, b.created_at
+		FROM bundles b
+		`+where+`
+		ORDER BY b.created_at DESC, b.id DESC
+	`, args...)
+	if err != nil {
+		log.Error("failed to select bundles", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	bundles := []structures.Bundle{}
+	bundleIdx := make(map[int]int)
+	var ids []int
+	for rows.Next() {
+		var b structures.Bundle
+		if err := rows.Scan(&b.Id, &b.Title, &b.Description, &b.Cost, &b.Active, &b.CreatedAt); err != nil {
+			log.Error("failed to scan bundle", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		b.Courses = []structures.BundleCourse{}
+		bundleIdx[b.Id] = len(bundles)
+		bundles = append(bundles, b)
+		ids = append(ids, b.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(ids) == 0 {
+		return bundles, nil
+	}
+
+	courseRows, err := r.db.Query(`
+		SELECT bc.bundle_id, bc.course_id, c.title, COALESCE(bc.plan_id, 0), COALESCE(p.title, '')
+		FROM bundle_courses bc
+		JOIN courses c ON c.id = bc.course_id
+		LEFT JOIN course_plans p ON p.id = bc.plan_id
+		WHERE bc.bundle_id = ANY($1)
+		ORDER BY bc.bundle_id, bc.position
+	`, intArray(ids))
+	if err != nil {
+		log.Error("failed to select bundle courses", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer courseRows.Close()
+
+	for courseRows.Next() {
+		var bundleID int
+		var c structures.BundleCourse
+		if err := courseRows.Scan(&bundleID, &c.CourseID, &c.CourseTitle, &c.PlanID, &c.PlanTitle); err != nil {
+			log.Error("failed to scan bundle course", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if i, ok := bundleIdx[bundleID]; ok {
+			bundles[i].Courses = append(bundles[i].Courses, c)
+		}
+	}
+
+	return bundles, courseRows.Err()
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+var ErrCertificateNotFound = errors.New("certificate not found")
+
+type CertificateRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewCertificateRepo(log *slog.Logger, db *sql.DB) *CertificateRepo {
+	return &CertificateRepo{log: log, db: db}
+}
+
+const certificateColumns = `id, code, COALESCE(user_id, 0), COALESCE(course_id, 0), holder_name, course_title, issued_at, revoked_at, COALESCE(revoke_reason, '')`
+
+func scanCertificate(row interface{ Scan(...any) error }) (structures.Certificate, error) {
+	var c structures.Certificate
+	var revokedAt sql.NullTime
+
+	err := row.Scan(&c.Id, &c.Code, &c.UserID, &c.CourseID, &c.HolderName, &c.CourseTitle, &c.IssuedAt, &revokedAt, &c.RevokeReason)
+	if err != nil {
+		return c, err
+	}
+
+	if revokedAt.Valid {
+		c.RevokedAt = &revokedAt.Time
+	}
+
+	return c, nil
+}
+
+// InsertCertificate registers a new certificate. If the user already has a certificate
+// for the course, the existing one is returned. A unique violation on code is returned
+// wrapped, so the caller can retry with another code.
+func (r *CertificateRepo) InsertCertificate(c structures.Certificate) (structures.Certificate, error) {
+	const op = "postgres.certificate_repo.InsertCertificate"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO certificates (code, user_id, course_id, holder_name, course_title)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, course_id) DO NOTHING
+		RETURNING ` + certificateColumns
+
+	cert, err := scanCertificate(r.db.QueryRow(query, c.Code, c.UserID, c.CourseID, c.HolderName, c.CourseTitle))
+	if err == nil {
+		log.Info("certificate issued", slog.String("code", cert.Code), slog.Int("user_id", c.UserID), slog.Int("course_id", c.CourseID))
+		return cert, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) || pqErr.Code != "23505" {
+			log.Error("failed to insert certificate", sl.Err(err))
+		}
+		return cert, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return r.SelectByUserAndCourse(c.UserID, c.CourseID)
+}
+
+func (r *CertificateRepo) SelectByUserAndCourse(userID, courseID int) (structures.Certificate, error) {
+	const op = "postgres.certificate_repo.SelectByUserAndCourse"
+	log := r.log.With("op", op)
+
+	query := `SELECT ` + certificateColumns + ` FROM certificates WHERE user_id = $1 AND course_id = $2`
+
+	cert, err := scanCertificate(r.db.QueryRow(query, userID, courseID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cert, ErrCertificateNotFound
+		}
+		log.Error("failed to select certificate", sl.Err(err))
+		return cert, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cert, nil
+}
+
+func (r *CertificateRepo) SelectByCode(code string) (structures.Certificate, error) {
+	const op = "postgres.certificate_repo.SelectByCode"
+	log := r.log.With("op", op)
+
+	query := `SELECT ` + certificateColumns + ` FROM certificates WHERE code = $1`
+
+	cert, err := scanCertificate(r.db.QueryRow(query, code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return cert, ErrCertificateNotFound
+		}
+		log.Error("failed to select certificate", sl.Err(err))
+		return cert, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cert, nil
+}
+
+func (r *CertificateRepo) SelectAll() ([]structures.Certificate, error) {
+	const op = "postgres.certificate_repo.SelectAll"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`SELECT ` + certificateColumns + ` FROM certificates ORDER BY id DESC`)
+	if err != nil {
+		log.Error("failed to execute query", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var certificates []structures.Certificate
+	for rows.Next() {
+		cert, err := scanCertificate(rows)
+		if err != nil {
+			log.Error("failed to scan certificate row", sl.Err(err))
+			continue
+		}
+		certificates = append(certificates, cert)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("rows iteration error", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return certificates, nil
+}
+
+// RevokeCertificate marks a certificate as revoked. Revoking twice keeps the first revocation date.
+func (r *CertificateRepo) RevokeCertificate(code, reason string) error {
+	const op = "postgres.certificate_repo.RevokeCertificate"
+	log := r.log.With("op", op)
+
+	query := `
+		UPDATE certificates
+		SET revoked_at = COALESCE(revoked_at, now() AT TIME ZONE 'utc'),
+			revoke_reason = $1
+		WHERE code = $2
+	`
+
+	result, err := r.db.Exec(query, reason, code)
+	if err != nil {
+		log.Error("failed to revoke certificate", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		log.Warn("no certificate found with code", slog.String("code", code))
+		return ErrCertificateNotFound
+	}
+
+	log.Info("certificate revoked", slog.String("code", code))
+	return nil
+}
@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var ErrCohortNotFound = errors.New("cohort not found")
+
+type CohortRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewCohortRepo(log *slog.Logger, db *sql.DB) *CohortRepo {
+	return &CohortRepo{log: log, db: db}
+}
+
+// InsertCohort creates the cohort together with its curators
+func (r *CohortRepo) InsertCohort(c structures.Cohort) (int, error) {
+	const op = "postgres.cohort_repo.InsertCohort"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO cohorts (course_id, title, starts_at, capacity)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, c.CourseID, c.Title, c.StartsAt, c.Capacity).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert cohort", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertCohortCurators(tx, id, c.Curators); err != nil {
+		log.Error("failed to insert cohort curators", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("cohort created", slog.Int("id", id), slog.Int("course_id", c.CourseID))
+	return id, nil
+}
+
+// UpdateCohort saves the cohort and replaces its curators
+func (r *CohortRepo) UpdateCohort(c structures.Cohort) error {
+	const op = "postgres.cohort_repo.UpdateCohort"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE cohorts SET title = $1, starts_at = $2, capacity = $3
+		WHERE id = $4
+	`, c.Title, c.StartsAt, c.Capacity, c.Id)
+	if err != nil {
+		log.Error("failed to update cohort", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrCohortNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM cohort_curators WHERE cohort_id = $1`, c.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertCohortCurators(tx, c.Id, c.Curators); err != nil {
+		log.Error("failed to insert cohort curators", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func insertCohortCurators(tx *sql.Tx, cohortID int, curators []structures.CohortCurator) error {
+	for _, c := range curators {
+		if _, err := tx.Exec(`INSERT INTO cohort_curators (cohort_id, user_id) VALUES ($1, $2)`, cohortID, c.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteCohort removes the cohort with its webinars, its learners keep access to the course as self-paced
+func (r *CohortRepo) DeleteCohort(id int) error {
+	const op = "postgres.cohort_repo.DeleteCohort"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM cohorts WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete cohort", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrCohortNotFound
+	}
+
+	log.Info("cohort deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *CohortRepo) SelectCohort(id int) (structures.Cohort, error) {
+	const op = "postgres.cohort_repo.SelectCohort"
+
+	cohorts, err := r.selectCohorts(`WHERE co.id = $1`, id)
+	if err != nil {
+		return structures.Cohort{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(cohorts) == 0 {
+		return structures.Cohort{}, ErrCohortNotFound
+	}
+
+	return cohorts[0], nil
+}
+
+// SelectCohorts returns the cohorts of the course by start date
+func (r *CohortRepo) SelectCohorts(courseID int) ([]structures.Cohort, error) {
+	return r.selectCohorts(`WHERE co.course_id = $1`, courseID)
+}
+
+// SelectByCurator returns the cohorts the user curates
+func (r *CohortRepo) SelectByCurator(userID int) ([]structures.Cohort, error) {
+	return r.selectCohorts(`WHERE EXISTS (SELECT 1 FROM cohort_curators cc WHERE cc.cohort_id = co.id AND cc.user_id = $1)`, userID)
+}
+
+func (r *CohortRepo) selectCohorts(where string, args ...any) ([]structures.Cohort, error) {
+	const op = "postgres.cohort_repo.selectCohorts"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT co.id, co.course_id, co.title, co.starts_at, co.capacity, co.created_at,
+			(SELECT COUNT(*) FROM enrollments e WHERE e.cohort_id = co.id AND `+activeEnrollment+`)
+		FROM cohorts co
+		`+where+`
+		ORDER BY co.starts_at, co.id
+	`, args...)
+	if err != nil {
+		log.Error("failed to select cohorts", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	cohorts := []structures.Cohort{}
+	cohortIdx := make(map[int]int)
+	var ids []int
+	for rows.Next() {
+		var c structures.Cohort
+		if err := rows.Scan(&c.Id, &c.CourseID, &c.Title, &c.StartsAt, &c.Capacity, &c.CreatedAt, &c.Enrolled); err != nil {
+			log.Error("failed to scan cohort", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		c.Curators = []structures.CohortCurator{}
+		cohortIdx[c.Id] = len(cohorts)
+		cohorts = append(cohorts, c)
+		ids = append(ids, c.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(ids) == 0 {
+		return cohorts, nil
+	}
+
+	curatorRows, err := r.db.Query(`
+		SELECT cc.cohort_id, u.id, u.username
+		FROM cohort_curators cc
+		JOIN users u ON u.id = cc.user_id
+		WHERE cc.cohort_id = ANY($1)
+		ORDER BY cc.cohort_id, u.username
+	`, intArray(ids))
+	if err != nil {
+		log.Error("failed to select cohort curators", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer curatorRows.Close()
+
+	for curatorRows.Next() {
+		var cohortID int
+		var c structures.CohortCurator
+		if err := curatorRows.Scan(&cohortID, &c.UserID, &c.Username); err != nil {
+			log.Error("failed to scan cohort curator", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if i, ok := cohortIdx[cohortID]; ok {
+			cohorts[i].Curators = append(cohorts[i].Curators, c)
+		}
+	}
+
+	return cohorts, curatorRows.Err()
+}
+
+// CuratesLearner reports whether the curator is assigned to the cohort the learner takes the course in
+func (r *CohortRepo) CuratesLearner(curatorID, learnerID, courseID int) (bool, error) {
+	const op = "postgres.cohort_repo.CuratesLearner"
+
+	var ok bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM enrollments e
+			JOIN cohort_curators cc ON cc.cohort_id = e.cohort_id
+			WHERE e.user_id = $1 AND e.course_id = $2 AND cc.user_id = $3
+		)
+	`, learnerID, courseID, curatorID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ok, nil
+}
@@ -2,28 +2,41 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/QwaQ-dev/bala/internal/structures"
 	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
 )
 
+var ErrVideoNotFound = errors.New("video not found")
+
+// DefaultModuleTitle names the module every course starts with
+const DefaultModuleTitle = "Модуль 1"
+
 type CourseRepo struct {
 	log *slog.Logger
 	db  *sql.DB
 }
 
+// courseRating selects the average rating and the number of approved reviews of courses
+const courseRating = `
+	COALESCE((SELECT ROUND(AVG(r.rating), 1) FROM course_reviews r WHERE r.course_id = courses.id AND r.status = 'approved'), 0),
+	(SELECT COUNT(*) FROM course_reviews r WHERE r.course_id = courses.id AND r.status = 'approved')`
+
 func NewCourseRepo(log *slog.Logger, db *sql.DB) *CourseRepo {
 	return &CourseRepo{log: log, db: db}
 }
 
-// InsertCourse adds a course to the database and returns its ID, including webinars
+// InsertCourse adds a course to the database and returns its ID, including its default module and webinars
 func (r *CourseRepo) InsertCourse(course structures.Course) (int, error) {
 	const op = "postgres.course_repo.InsertCourse"
 	log := r.log.With("op", op)
 
-	log.Info("course", course)
+	log.Info("course", slog.String("title", course.Title))
 
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -32,22 +45,28 @@ func (r *CourseRepo) InsertCourse(course structures.Course) (int, error) {
 	}
 	defer tx.Rollback()
 
-	query := `INSERT INTO courses (title, description, cost, diploma_path, diploma_x, diploma_y, img)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	query := `INSERT INTO courses (title, description, cost, diploma_path, diploma_x, diploma_y, img, status, publish_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	          RETURNING id`
 	var courseID int
-	err = tx.QueryRow(query, course.Title, course.Description, course.Cost, course.DiplomaPath, course.Diploma_x, course.Diploma_y, course.Img).Scan(&courseID)
+	err = tx.QueryRow(query, course.Title, course.Description, course.Cost, course.DiplomaPath, course.Diploma_x, course.Diploma_y, course.Img,
+		course.Status, course.PublishAt).Scan(&courseID)
 	if err != nil {
 		log.Error("failed to insert course", sl.Err(err))
 		return 0, err
 	}
 
+	if _, err := insertWebinars(tx, courseID, course.Webinars); err != nil {
+		log.Error("failed to insert webinar", sl.Err(err))
+		return 0, err
+	}
+
 	_, err = tx.Exec(`
-		INSERT INTO webinars (link, date, course_id)
-		VALUES ($1, $2, $3)
-	`, course.Webinars.Link, course.Webinars.Date, courseID)
+		INSERT INTO course_modules (course_id, title, position)
+		VALUES ($1, $2, 1)
+	`, courseID, DefaultModuleTitle)
 	if err != nil {
-		log.Error("failed to insert webinar", sl.Err(err))
+		log.Error("failed to insert default module", sl.Err(err))
 		return 0, err
 	}
 
@@ -82,114 +101,173 @@ func (r *CourseRepo) DeleteCourse(id int) error {
 	return nil
 }
 
-// SelectCourseById returns a course with its videos and webinars
+// SelectCourseById returns a course with its modules, lessons and webinars.
+// Lessons come in syllabus order: by module position, then by lesson position.
 func (r *CourseRepo) SelectCourseById(courseID int) (structures.Course, error) {
 	const op = "postgres.course_repo.SelectCourseById"
 	log := r.log.With("op", op)
 
 	query := `
-		SELECT 
-            c.id, c.title, c.description, c.cost, c.diploma_path, c.diploma_x, c.diploma_y, c.img,
-            v.id AS video_id, v.file AS video_file, v.path AS video_path, v.title AS video_title,
-            w.id AS webinar_id, w.link AS webinar_link, w.date AS webinar_date
-        FROM courses c
-        LEFT JOIN videos v ON v.course_id = c.id
-        LEFT JOIN webinars w ON w.course_id = c.id
-        WHERE c.id = $1
-    `
-
-	rows, err := r.db.Query(query, courseID)
+		SELECT id, title, description, cost, diploma_path, diploma_x, diploma_y, img, status, publish_at,
+			` + courseRating + `
+		FROM courses
+		WHERE id = $1
+	`
+
+	var course structures.Course
+	var diplomaPath sql.NullString
+	var publishAt sql.NullTime
+	err := r.db.QueryRow(query, courseID).Scan(
+		&course.Id,
+		&course.Title,
+		&course.Description,
+		&course.Cost,
+		&diplomaPath,
+		&course.Diploma_x,
+		&course.Diploma_y,
+		&course.Img,
+		&course.Status,
+		&publishAt,
+		&course.Rating,
+		&course.ReviewCount,
+	)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("no course found", slog.Int("id", courseID))
+			return structures.Course{}, fmt.Errorf("course with id=%d not found", courseID)
+		}
 		log.Error("failed to select course", sl.Err(err))
 		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
 	}
-	defer rows.Close()
-
-	var course structures.Course
-	var videos []structures.Video
-	var webinar structures.Webinar
-	courseInitialized := false
+	course.DiplomaPath = diplomaPath.String
+	if publishAt.Valid {
+		course.PublishAt = &publishAt.Time
+	}
 
-	for rows.Next() {
-		var (
-			videoID     sql.NullInt64
-			videoPath   sql.NullString
-			videoTitle  sql.NullString
-			file        sql.NullString
-			webinarID   sql.NullInt64
-			webinarLink sql.NullString
-			webinarDate sql.NullTime
-			diplomaPath sql.NullString
-			diplomaX    int
-			diplomaY    int
-		)
+	modules, err := r.selectModules(courseID)
+	if err != nil {
+		log.Error("failed to select modules", sl.Err(err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		var cId int
-		var title, description, img string
-		var cost int
+	videos, err := r.selectLessons(courseID)
+	if err != nil {
+		log.Error("failed to select lessons", sl.Err(err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
 
-		if err := rows.Scan(
-			&cId,
-			&title,
-			&description,
-			&cost,
-			&diplomaPath,
-			&diplomaX,
-			&diplomaY,
-			&img,
-			&videoID,
-			&file,
-			&videoPath,
-			&videoTitle,
-			&webinarID,
-			&webinarLink,
-			&webinarDate,
-		); err != nil {
-			log.Error("failed to scan row", sl.Err(err))
-			return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	moduleIdx := make(map[int]int, len(modules))
+	for i, m := range modules {
+		moduleIdx[m.Id] = i
+	}
+	for _, v := range videos {
+		if i, ok := moduleIdx[v.ModuleID]; ok {
+			modules[i].Lessons = append(modules[i].Lessons, v)
 		}
+	}
 
-		if !courseInitialized {
-			course = structures.Course{
-				Id:          cId,
-				Title:       title,
-				Description: description,
-				Cost:        cost,
-				DiplomaPath: diplomaPath.String,
-				Diploma_x:   diplomaX,
-				Diploma_y:   diplomaY,
-				Img:         img,
-			}
-			courseInitialized = true
-		}
+	webinars, err := selectCourseWebinars(r.db, courseID)
+	if err != nil {
+		log.Error("failed to select webinars", sl.Err(err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	course.Modules = modules
+	course.Videos = videos
+	course.Webinars = webinars
+	return course, nil
+}
+
+func (r *CourseRepo) selectModules(courseID int) ([]structures.Module, error) {
+	rows, err := r.db.Query(`
+		SELECT id, course_id, title, position
+		FROM course_modules
+		WHERE course_id = $1
+		ORDER BY position, id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		if videoID.Valid && videoPath.Valid {
-			videos = append(videos, structures.Video{
-				Id:    int(videoID.Int64),
-				Path:  videoPath.String,
-				Title: videoTitle.String,
-				File:  file.String,
-			})
+	var modules []structures.Module
+	for rows.Next() {
+		var m structures.Module
+		if err := rows.Scan(&m.Id, &m.CourseID, &m.Title, &m.Position); err != nil {
+			return nil, err
 		}
+		modules = append(modules, m)
+	}
+
+	return modules, rows.Err()
+}
 
-		if webinarID.Valid {
-			webinar = structures.Webinar{
-				Id:       int(webinarID.Int64),
-				Link:     webinarLink.String,
-				Date:     webinarDate.Time,
-				CourseID: cId,
-			}
+// selectLessons returns all lessons of the course with their attachments in syllabus order
+func (r *CourseRepo) selectLessons(courseID int) ([]structures.Video, error) {
+	rows, err := r.db.Query(`
+		SELECT v.id, v.course_id, v.module_id, v.position, v.path, v.title, v.content, COALESCE(v.file, ''),
+			v.is_preview, v.duration, v.unlock_after_days, v.unlock_at
+		FROM videos v
+		JOIN course_modules m ON m.id = v.module_id
+		WHERE v.course_id = $1
+		ORDER BY m.position, m.id, v.position, v.id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var videos []structures.Video
+	videoIdx := make(map[int]int)
+	for rows.Next() {
+		var v structures.Video
+		var unlockDays sql.NullInt64
+		var unlockAt sql.NullTime
+		if err := rows.Scan(&v.Id, &v.CourseID, &v.ModuleID, &v.Position, &v.Path, &v.Title, &v.Content, &v.File,
+			&v.IsPreview, &v.Duration, &unlockDays, &unlockAt); err != nil {
+			return nil, err
 		}
+		setUnlock(&v, unlockDays, unlockAt)
+		videoIdx[v.Id] = len(videos)
+		videos = append(videos, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if !courseInitialized {
-		log.Warn("no course found", slog.Int("id", courseID))
-		return structures.Course{}, fmt.Errorf("course with id=%d not found", courseID)
+	attRows, err := r.db.Query(`
+		SELECT a.id, a.video_id, a.title, a.path, a.position
+		FROM lesson_attachments a
+		JOIN videos v ON v.id = a.video_id
+		WHERE v.course_id = $1
+		ORDER BY a.video_id, a.position, a.id
+	`, courseID)
+	if err != nil {
+		return nil, err
 	}
+	defer attRows.Close()
 
-	course.Videos = videos
-	course.Webinars = webinar
-	return course, nil
+	for attRows.Next() {
+		var a structures.LessonAttachment
+		if err := attRows.Scan(&a.Id, &a.VideoID, &a.Title, &a.Path, &a.Position); err != nil {
+			return nil, err
+		}
+		if i, ok := videoIdx[a.VideoID]; ok {
+			videos[i].Attachments = append(videos[i].Attachments, a)
+		}
+	}
+
+	return videos, attRows.Err()
+}
+
+func setUnlock(v *structures.Video, days sql.NullInt64, at sql.NullTime) {
+	if days.Valid {
+		d := int(days.Int64)
+		v.UnlockAfterDays = &d
+	}
+	if at.Valid {
+		v.UnlockAt = &at.Time
+	}
 }
 
 // UpdateCourse updates course data
@@ -222,13 +300,60 @@ func (r *CourseRepo) UpdateCourse(c *structures.Course) error {
 	return nil
 }
 
+// UpdateStatus changes the lifecycle status of the course. publishAt is kept for scheduled and published courses.
+func (r *CourseRepo) UpdateStatus(id int, status string, publishAt *time.Time) error {
+	const op = "postgres.course_repo.UpdateStatus"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`UPDATE courses SET status = $1, publish_at = $2 WHERE id = $3`, status, publishAt, id)
+	if err != nil {
+		log.Error("failed to update course status", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("course with id=%d not found", id)
+	}
+
+	log.Info("course status updated", slog.Int("id", id), slog.String("status", status))
+	return nil
+}
+
+// PublishDue publishes scheduled courses whose publish time has come and returns their ids
+func (r *CourseRepo) PublishDue(now time.Time) ([]int, error) {
+	const op = "postgres.course_repo.PublishDue"
+
+	rows, err := r.db.Query(`
+		UPDATE courses SET status = 'published'
+		WHERE status = 'scheduled' AND publish_at <= $1
+		RETURNING id
+	`, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
 // SelectAllCourses returns all courses without videos but with diploma_path
 func (r *CourseRepo) SelectAllCourses() ([]structures.Course, error) {
 	const op = "postgres.course_repo.SelectAllCourses"
 	log := r.log.With("op", op)
 
 	query := `
-		SELECT id, title, description, cost, img, diploma_path
+		SELECT id, title, description, cost, img, diploma_path, status, publish_at,
+			` + courseRating + `
 		FROM courses
 		ORDER BY id DESC
 	`
@@ -245,6 +370,7 @@ func (r *CourseRepo) SelectAllCourses() ([]structures.Course, error) {
 	for rows.Next() {
 		var course structures.Course
 		var diplomaPath sql.NullString
+		var publishAt sql.NullTime
 
 		err := rows.Scan(
 			&course.Id,
@@ -253,6 +379,10 @@ func (r *CourseRepo) SelectAllCourses() ([]structures.Course, error) {
 			&course.Cost,
 			&course.Img,
 			&diplomaPath,
+			&course.Status,
+			&publishAt,
+			&course.Rating,
+			&course.ReviewCount,
 		)
 		if err != nil {
 			log.Error("failed to scan course row", sl.Err(err))
@@ -260,6 +390,9 @@ func (r *CourseRepo) SelectAllCourses() ([]structures.Course, error) {
 		}
 
 		course.DiplomaPath = diplomaPath.String
+		if publishAt.Valid {
+			course.PublishAt = &publishAt.Time
+		}
 		courses = append(courses, course)
 	}
 
@@ -271,94 +404,382 @@ func (r *CourseRepo) SelectAllCourses() ([]structures.Course, error) {
 	return courses, nil
 }
 
-// AddWebinarToCourse adds a webinar to a course
-func (r *CourseRepo) AddWebinarToCourse(courseID int, webinar structures.Webinar) error {
-	const op = "postgres.course_repo.AddWebinarToCourse"
+// AddVideoToCourse appends a lesson to the last module of the course
+func (r *CourseRepo) AddVideoToCourse(courseID int, path string, title, file string, duration int) error {
+	const op = "postgres.course_repo.AddVideoToCourse"
 	log := r.log.With("op", op)
 
-	query := `
-		INSERT INTO webinars (link, date, course_id)
-		VALUES ($1, $2, $3)
-	`
-	_, err := r.db.Exec(query, webinar.Link, webinar.Date, courseID)
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Error("failed to add webinar", sl.Err(err))
+		log.Error("failed to begin tx", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
+	defer tx.Rollback()
 
-	log.Info("webinar added", slog.Int("course_id", courseID), slog.String("title", webinar.Link))
+	if err := insertLesson(tx, courseID, path, title, file, duration); err != nil {
+		log.Error("failed to insert video path", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("video added to course", slog.Int("course_id", courseID), slog.String("path", path))
 	return nil
 }
 
-// UpdateUsersIds adds a course to a user's course_ids array
-func (r *CourseRepo) UpdateUsersIds(userId, courseId int) error {
-	const op = "postgres.course_repo.UpdateUsersIds"
-	log := r.log.With("op", op)
+// insertLesson appends a lesson to the last module of the course within tx
+func insertLesson(tx *sql.Tx, courseID int, path string, title, file string, duration int) error {
+	// courses created before modules existed may still have none
+	_, err := tx.Exec(`
+		INSERT INTO course_modules (course_id, title, position)
+		SELECT $1, $2, 1
+		WHERE NOT EXISTS (SELECT 1 FROM course_modules WHERE course_id = $1)
+	`, courseID, DefaultModuleTitle)
+	if err != nil {
+		return fmt.Errorf("ensure default module: %w", err)
+	}
 
-	query := `
-		UPDATE users
-		SET course_ids = array_append(course_ids, $1)
-		WHERE id = $2
-	`
+	_, err = tx.Exec(`
+		WITH m AS (
+			SELECT id FROM course_modules
+			WHERE course_id = $1
+			ORDER BY position DESC, id DESC
+			LIMIT 1
+		)
+		INSERT INTO videos (course_id, module_id, position, path, title, file, duration)
+		SELECT $1, m.id, COALESCE((SELECT MAX(position) FROM videos WHERE module_id = m.id), 0) + 1, $2, $3, $4, $5
+		FROM m
+	`, courseID, path, title, file, duration)
+	return err
+}
 
-	result, err := r.db.Exec(query, courseId, userId)
+// UpdateVideo saves the title and file paths of a single video
+func (r *CourseRepo) UpdateVideo(v structures.Video) error {
+	const op = "postgres.course_repo.UpdateVideo"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE videos
+		SET title = $1, path = $2, file = $3, is_preview = $4, duration = $5
+		WHERE id = $6
+	`, v.Title, v.Path, v.File, v.IsPreview, v.Duration, v.Id)
 	if err != nil {
-		log.Error("failed to give access", sl.Err(err))
+		log.Error("failed to update video", sl.Err(err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		log.Warn("no user found with ID", slog.Int("id", userId))
-		return fmt.Errorf("user with id=%d not found", userId)
+		return ErrVideoNotFound
 	}
 
-	log.Info("access has been given to user", slog.Int("id", userId))
+	log.Info("video updated", slog.Int("id", v.Id))
 	return nil
 }
 
-// RemoveCourseId removes a course from a user's course_ids array
-func (r *CourseRepo) RemoveCourseId(userId, courseId int) error {
-	const op = "postgres.course_repo.RemoveCourseId"
+// DeleteVideo deletes a video with its attachments and progress and returns the paths of every file it referenced
+func (r *CourseRepo) DeleteVideo(id int) ([]string, error) {
+	const op = "postgres.course_repo.DeleteVideo"
 	log := r.log.With("op", op)
 
-	query := `
-		UPDATE users
-		SET course_ids = array_remove(course_ids, $1)
-		WHERE id = $2
-	`
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
 
-	result, err := r.db.Exec(query, courseId, userId)
+	files, err := selectPaths(tx, `
+		SELECT path FROM lesson_attachments WHERE video_id = $1
+		UNION ALL SELECT f.path FROM submission_files f
+			JOIN submissions s ON s.id = f.submission_id
+			JOIN assignments a ON a.id = s.assignment_id
+			WHERE a.video_id = $1
+	`, id)
 	if err != nil {
-		log.Error("failed to remove access", sl.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
+		log.Error("failed to select attachments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		log.Warn("no user found with ID", slog.Int("id", userId))
-		return fmt.Errorf("user with id=%d not found", userId)
+	var path, file string
+	err = tx.QueryRow(`
+		DELETE FROM videos WHERE id = $1
+		RETURNING path, COALESCE(file, '')
+	`, id).Scan(&path, &file)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrVideoNotFound
+		}
+		log.Error("failed to delete video", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	log.Info("access has been taken from user", slog.Int("id", userId))
-	return nil
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("video deleted", slog.Int("id", id))
+	return append(files, path, file), nil
 }
 
-func (s *CourseRepo) AddVideoToCourse(courseID int, path string, title, file string) error {
-	const op = "postgres.course_repo.AddVideoToCourse"
-	log := s.log.With("op", op)
+// courseMedia selects the uploaded files the course content is made of, learner uploads are not part of it
+const courseMedia = `
+		SELECT img FROM courses WHERE id = $1
+		UNION ALL SELECT diploma_path FROM courses WHERE id = $1
+		UNION ALL SELECT path FROM videos WHERE course_id = $1
+		UNION ALL SELECT file FROM videos WHERE course_id = $1
+		UNION ALL SELECT a.path FROM lesson_attachments a JOIN videos v ON v.id = a.video_id WHERE v.course_id = $1`
+
+// SelectCourseMedia returns the paths of the course image, diploma template, lesson videos, files and attachments
+func (r *CourseRepo) SelectCourseMedia(courseID int) ([]string, error) {
+	const op = "postgres.course_repo.SelectCourseMedia"
+
+	files, err := selectPaths(r.db, courseMedia, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return files, nil
+}
+
+// SelectCourseFiles returns the paths of every uploaded file that belongs to the course
+func (r *CourseRepo) SelectCourseFiles(courseID int) ([]string, error) {
+	const op = "postgres.course_repo.SelectCourseFiles"
+
+	files, err := selectPaths(r.db, courseMedia+`
+		UNION ALL SELECT f.path FROM submission_files f
+			JOIN submissions s ON s.id = f.submission_id
+			JOIN assignments a ON a.id = s.assignment_id
+			WHERE a.course_id = $1
+		UNION ALL SELECT receipt_path FROM access_requests WHERE course_id = $1
+	`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return files, nil
+}
+
+// copiedPath replaces a file path with the path of its copy, files without a copy are dropped
+// so that the copied course never shares a file with the original
+func copiedPath(column string) string {
+	return `COALESCE((SELECT new_path FROM copy_files WHERE old_path = ` + column + `), '')`
+}
+
+// courseCopySteps copy the content of course $1 into course $2. Ids of copied rows that other rows refer to
+// are taken from the sequences up front and kept in copy_ids to link the children, steps that only follow
+// those links take no arguments.
+var courseCopySteps = []struct {
+	name   string
+	scoped bool
+	query  string
+}{
+	{"modules", true, `
+		WITH src AS (
+			SELECT id, nextval('public.course_modules_id_seq') AS new_id, title, position
+			FROM course_modules WHERE course_id = $1
+		), ins AS (
+			INSERT INTO course_modules (id, course_id, title, position)
+			SELECT new_id, $2, title, position FROM src
+		)
+		INSERT INTO copy_ids (kind, old_id, new_id) SELECT 'module', id, new_id FROM src`},
+	{"lessons", true, `
+		WITH src AS (
+			SELECT v.*, nextval('public.videos_id_seq') AS new_id FROM videos v WHERE v.course_id = $1
+		), ins AS (
+			INSERT INTO videos (id, course_id, module_id, position, path, title, content, file,
+				is_preview, duration, unlock_after_days, unlock_at)
+			SELECT src.new_id, $2, m.new_id, src.position, ` + copiedPath("src.path") + `, src.title, src.content,
+				NULLIF(` + copiedPath("src.file") + `, ''), src.is_preview, src.duration, src.unlock_after_days, src.unlock_at
+			FROM src
+			LEFT JOIN copy_ids m ON m.kind = 'module' AND m.old_id = src.module_id
+		)
+		INSERT INTO copy_ids (kind, old_id, new_id) SELECT 'video', id, new_id FROM src`},
+	{"attachments", false, `
+		INSERT INTO lesson_attachments (video_id, title, path, position)
+		SELECT m.new_id, a.title, ` + copiedPath("a.path") + `, a.position
+		FROM lesson_attachments a
+		JOIN copy_ids m ON m.kind = 'video' AND m.old_id = a.video_id`},
+	{"quizzes", true, `
+		WITH src AS (
+			SELECT q.*, nextval('public.quizzes_id_seq') AS new_id FROM quizzes q WHERE q.course_id = $1
+		), ins AS (
+			INSERT INTO quizzes (id, course_id, video_id, title, pass_score)
+			SELECT src.new_id, $2, m.new_id, src.title, src.pass_score
+			FROM src
+			LEFT JOIN copy_ids m ON m.kind = 'video' AND m.old_id = src.video_id
+		)
+		INSERT INTO copy_ids (kind, old_id, new_id) SELECT 'quiz', id, new_id FROM src`},
+	{"quiz questions", false, `
+		WITH src AS (
+			SELECT qq.*, m.new_id AS new_quiz_id, nextval('public.quiz_questions_id_seq') AS new_id
+			FROM quiz_questions qq
+			JOIN copy_ids m ON m.kind = 'quiz' AND m.old_id = qq.quiz_id
+		), ins AS (
+			INSERT INTO quiz_questions (id, quiz_id, kind, text, position, points, accepted_answers)
+			SELECT new_id, new_quiz_id, kind, text, position, points, accepted_answers FROM src
+		)
+		INSERT INTO copy_ids (kind, old_id, new_id) SELECT 'question', id, new_id FROM src`},
+	{"quiz options", false, `
+		INSERT INTO quiz_options (question_id, text, is_correct, position)
+		SELECT m.new_id, o.text, o.is_correct, o.position
+		FROM quiz_options o
+		JOIN copy_ids m ON m.kind = 'question' AND m.old_id = o.question_id`},
+	{"assignments", true, `
+		INSERT INTO assignments (course_id, video_id, title, description)
+		SELECT $2, m.new_id, a.title, a.description
+		FROM assignments a
+		JOIN copy_ids m ON m.kind = 'video' AND m.old_id = a.video_id
+		WHERE a.course_id = $1`},
+	{"plans", true, `
+		INSERT INTO course_plans (course_id, title, description, cost, features, position, active)
+		SELECT $2, title, description, cost, features, position, active
+		FROM course_plans WHERE course_id = $1`},
+	{"diploma fields", true, `
+		INSERT INTO diploma_fields (course_id, kind, text, x, y, font_size, color, align)
+		SELECT $2, kind, text, x, y, font_size, color, align
+		FROM diploma_fields WHERE course_id = $1`},
+	// upcoming course-wide webinars only: past ones would show up as last season's schedule and cohorts
+	// are not copied. Series get new ids so cancelling one in the copy leaves the original alone.
+	{"webinars", true, `
+		INSERT INTO webinars (course_id, title, link, date, duration, status, series_id)
+		SELECT $2, title, link, date, duration, status, substr(md5(series_id || ':' || $2::integer), 1, 16)
+		FROM webinars
+		WHERE course_id = $1 AND cohort_id IS NULL AND status = 'scheduled' AND date > (now() AT TIME ZONE 'utc')`},
+}
+
+// CopyCourse creates a draft copy of the course with its modules, lessons, attachments, quizzes, assignments,
+// plans, diploma template and upcoming course-wide webinars, and returns its ID.
+// files maps the paths returned by SelectCourseMedia to the paths of their copies.
+// Enrollments, cohorts, reviews, orders and learner progress are not copied.
+func (r *CourseRepo) CopyCourse(sourceID int, title string, files map[string]string) (int, error) {
+	const op = "postgres.course_repo.CopyCourse"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		CREATE TEMP TABLE copy_ids (kind text, old_id integer, new_id integer) ON COMMIT DROP;
+		CREATE TEMP TABLE copy_files (old_path text, new_path text) ON COMMIT DROP;
+	`)
+	if err != nil {
+		log.Error("failed to create temp tables", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	oldPaths := make([]string, 0, len(files))
+	newPaths := make([]string, 0, len(files))
+	for o, n := range files {
+		oldPaths = append(oldPaths, o)
+		newPaths = append(newPaths, n)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO copy_files (old_path, new_path)
+		SELECT * FROM unnest($1::text[], $2::text[])
+	`, pq.Array(oldPaths), pq.Array(newPaths))
+	if err != nil {
+		log.Error("failed to store file copies", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var courseID int
+	err = tx.QueryRow(`
+		INSERT INTO courses (title, description, cost, diploma_path, diploma_x, diploma_y, img, status)
+		SELECT $2, description, cost, `+copiedPath("diploma_path")+`, diploma_x, diploma_y, `+copiedPath("img")+`, $3
+		FROM courses
+		WHERE id = $1
+		RETURNING id
+	`, sourceID, title, structures.CourseDraft).Scan(&courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("no course found", slog.Int("id", sourceID))
+			return 0, fmt.Errorf("course with id=%d not found", sourceID)
+		}
+		log.Error("failed to copy course", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, step := range courseCopySteps {
+		var args []any
+		if step.scoped {
+			args = []any{sourceID, courseID}
+		}
+		if _, err := tx.Exec(step.query, args...); err != nil {
+			log.Error("failed to copy "+step.name, sl.Err(err))
+			return 0, fmt.Errorf("%s: copy %s: %w", op, step.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("course copied", slog.Int("source_id", sourceID), slog.Int("id", courseID))
+	return courseID, nil
+}
+
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// selectPaths runs a single-column query and drops NULL and empty paths
+func selectPaths(q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var p sql.NullString
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		if p.String != "" {
+			paths = append(paths, p.String)
+		}
+	}
+
+	return paths, rows.Err()
+}
+
+// SelectVideoById returns a single video together with the course it belongs to
+func (r *CourseRepo) SelectVideoById(videoID int) (structures.Video, error) {
+	const op = "postgres.course_repo.SelectVideoById"
+	log := r.log.With("op", op)
 
 	query := `
-		INSERT INTO videos (course_id, path, title, file)
-		VALUES ($1, $2, $3, $4)
+		SELECT id, COALESCE(course_id, 0), COALESCE(module_id, 0), position, path, title, content, COALESCE(file, ''),
+			is_preview, duration, unlock_after_days, unlock_at
+		FROM videos
+		WHERE id = $1
 	`
 
-	_, err := s.db.Exec(query, courseID, path, title, file)
+	var v structures.Video
+	var unlockDays sql.NullInt64
+	var unlockAt sql.NullTime
+	err := r.db.QueryRow(query, videoID).Scan(&v.Id, &v.CourseID, &v.ModuleID, &v.Position, &v.Path, &v.Title, &v.Content, &v.File,
+		&v.IsPreview, &v.Duration, &unlockDays, &unlockAt)
 	if err != nil {
-		log.Error("failed to insert video path", sl.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Warn("no video found", slog.Int("id", videoID))
+			return v, ErrVideoNotFound
+		}
+		log.Error("failed to select video", sl.Err(err))
+		return v, fmt.Errorf("%s: %w", op, err)
 	}
+	setUnlock(&v, unlockDays, unlockAt)
 
-	log.Info("video added to course", slog.Int("course_id", courseID), slog.String("path", path))
-	return nil
+	return v, nil
 }
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+type DiplomaRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewDiplomaRepo(log *slog.Logger, db *sql.DB) *DiplomaRepo {
+	return &DiplomaRepo{log: log, db: db}
+}
+
+// SelectFields returns the text fields configured for a course diploma template
+func (r *DiplomaRepo) SelectFields(courseID int) ([]structures.DiplomaField, error) {
+	const op = "postgres.diploma_repo.SelectFields"
+	log := r.log.With("op", op)
+
+	query := `
+		SELECT id, course_id, kind, text, x, y, font_size, color, align
+		FROM diploma_fields
+		WHERE course_id = $1
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(query, courseID)
+	if err != nil {
+		log.Error("failed to select diploma fields", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var fields []structures.DiplomaField
+	for rows.Next() {
+		var f structures.DiplomaField
+		if err := rows.Scan(&f.Id, &f.CourseID, &f.Kind, &f.Text, &f.X, &f.Y, &f.FontSize, &f.Color, &f.Align); err != nil {
+			log.Error("failed to scan diploma field", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		fields = append(fields, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("rows iteration error", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fields, nil
+}
+
+// ReplaceFields replaces all diploma fields of a course in one transaction
+func (r *DiplomaRepo) ReplaceFields(courseID int, fields []structures.DiplomaField) error {
+	const op = "postgres.diploma_repo.ReplaceFields"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM diploma_fields WHERE course_id = $1`, courseID); err != nil {
+		log.Error("failed to delete old diploma fields", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		INSERT INTO diploma_fields (course_id, kind, text, x, y, font_size, color, align)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for _, f := range fields {
+		if _, err := tx.Exec(query, courseID, f.Kind, f.Text, f.X, f.Y, f.FontSize, f.Color, f.Align); err != nil {
+			log.Error("failed to insert diploma field", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("diploma fields updated", slog.Int("course_id", courseID), slog.Int("count", len(fields)))
+	return nil
+}
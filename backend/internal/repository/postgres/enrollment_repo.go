@@ -0,0 +1,405 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+// activeEnrollment is the condition for an enrollment aliased as e that still gives access
+const activeEnrollment = `(e.expires_at IS NULL OR e.expires_at > (now() AT TIME ZONE 'utc'))`
+
+// enrollmentWebinars is the condition for an enrollment aliased as e whose plan includes webinars
+const enrollmentWebinars = `(e.plan_id IS NULL OR EXISTS (
+	SELECT 1 FROM course_plans p WHERE p.id = e.plan_id AND '` + structures.FeatureWebinars + `' = ANY(p.features)))`
+
+// enrollmentUpsert takes user_id, course_id, source, granted_by, expires_at, plan_id, cohort_id and order_id.
+// Extending access that is still active keeps granted_at, so the drip schedule of the course doesn't start over.
+// A grant without a cohort keeps the learner in the cohort they are in.
+const enrollmentUpsert = `
+	INSERT INTO enrollments (user_id, course_id, source, granted_by, expires_at, plan_id, cohort_id, order_id)
+	VALUES ($1, $2, $3, NULLIF($4, 0), $5, NULLIF($6, 0), NULLIF($7, 0), NULLIF($8, 0))
+	ON CONFLICT (user_id, course_id) DO UPDATE
+	SET source = EXCLUDED.source,
+		granted_by = EXCLUDED.granted_by,
+		order_id = EXCLUDED.order_id,
+		plan_id = EXCLUDED.plan_id,
+		cohort_id = COALESCE(EXCLUDED.cohort_id, enrollments.cohort_id),
+		granted_at = CASE
+			WHEN enrollments.expires_at IS NULL OR enrollments.expires_at > (now() AT TIME ZONE 'utc') THEN enrollments.granted_at
+			ELSE (now() AT TIME ZONE 'utc')
+		END,
+		expires_at = EXCLUDED.expires_at`
+
+type EnrollmentRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewEnrollmentRepo(log *slog.Logger, db *sql.DB) *EnrollmentRepo {
+	return &EnrollmentRepo{log: log, db: db}
+}
+
+// Upsert grants access to the course. A repeated grant replaces the source, granting admin and expiry of the previous one.
+func (r *EnrollmentRepo) Upsert(e structures.Enrollment) error {
+	const op = "postgres.enrollment_repo.Upsert"
+	log := r.log.With("op", op)
+
+	_, err := r.db.Exec(enrollmentUpsert, e.UserID, e.CourseID, e.Source, e.GrantedBy, e.ExpiresAt, e.PlanID, e.CohortID, e.OrderID)
+	if err != nil {
+		log.Error("failed to give access", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("access has been given to user", slog.Int("user_id", e.UserID), slog.Int("course_id", e.CourseID),
+		slog.Int("cohort_id", e.CohortID), slog.String("source", e.Source))
+	return nil
+}
+
+// SelectPaidGrants returns the access the user's paid orders give to the course, newest order first:
+// orders of the course itself and of bundles that include it, each with its plan of the course
+func (r *EnrollmentRepo) SelectPaidGrants(userID, courseID int) ([]structures.Enrollment, error) {
+	const op = "postgres.enrollment_repo.SelectPaidGrants"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT o.id, COALESCE(o.plan_id, 0)
+		FROM orders o
+		WHERE o.user_id = $1 AND o.course_id = $2 AND o.status = $3
+		UNION ALL
+		SELECT o.id, COALESCE(bc.plan_id, 0)
+		FROM orders o
+		JOIN bundle_courses bc ON bc.bundle_id = o.bundle_id AND bc.course_id = $2
+		WHERE o.user_id = $1 AND o.status = $3
+		ORDER BY 1 DESC
+	`, userID, courseID, structures.OrderPaid)
+	if err != nil {
+		log.Error("failed to select paid orders", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var grants []structures.Enrollment
+	for rows.Next() {
+		e := structures.Enrollment{UserID: userID, CourseID: courseID, Source: structures.EnrollmentPayment}
+		if err := rows.Scan(&e.OrderID, &e.PlanID); err != nil {
+			log.Error("failed to scan paid order", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		grants = append(grants, e)
+	}
+
+	return grants, rows.Err()
+}
+
+// Delete takes access away. Removing an enrollment that doesn't exist is not an error.
+func (r *EnrollmentRepo) Delete(userID, courseID int) error {
+	const op = "postgres.enrollment_repo.Delete"
+	log := r.log.With("op", op)
+
+	if _, err := r.db.Exec(`DELETE FROM enrollments WHERE user_id = $1 AND course_id = $2`, userID, courseID); err != nil {
+		log.Error("failed to remove access", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("access has been taken from user", slog.Int("user_id", userID), slog.Int("course_id", courseID))
+	return nil
+}
+
+// IsActive reports whether the user has an enrollment in the course that hasn't expired
+func (r *EnrollmentRepo) IsActive(userID, courseID int) (bool, error) {
+	const op = "postgres.enrollment_repo.IsActive"
+
+	var active bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM enrollments e
+			WHERE e.user_id = $1 AND e.course_id = $2 AND `+activeEnrollment+`
+		)
+	`, userID, courseID).Scan(&active)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return active, nil
+}
+
+// StartedAt returns when the user's active enrollment in the course started: the start of the cohort
+// for cohort learners, otherwise when access was granted. nil without an active enrollment.
+func (r *EnrollmentRepo) StartedAt(userID, courseID int) (*time.Time, error) {
+	const op = "postgres.enrollment_repo.StartedAt"
+
+	var startedAt time.Time
+	err := r.db.QueryRow(`
+		SELECT COALESCE(co.starts_at, e.granted_at)
+		FROM enrollments e
+		LEFT JOIN cohorts co ON co.id = e.cohort_id
+		WHERE e.user_id = $1 AND e.course_id = $2 AND `+activeEnrollment,
+		userID, courseID).Scan(&startedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &startedAt, nil
+}
+
+// Cohort returns the cohort of the user's active enrollment in the course, 0 for self-paced access or none
+func (r *EnrollmentRepo) Cohort(userID, courseID int) (int, error) {
+	const op = "postgres.enrollment_repo.Cohort"
+
+	var cohortID int
+	err := r.db.QueryRow(`
+		SELECT COALESCE(e.cohort_id, 0) FROM enrollments e
+		WHERE e.user_id = $1 AND e.course_id = $2 AND `+activeEnrollment,
+		userID, courseID).Scan(&cohortID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cohortID, nil
+}
+
+// Features returns the features of the user's active enrollment in the course: those of its plan,
+// or all of them for access without a plan. ok is false without an active enrollment.
+func (r *EnrollmentRepo) Features(userID, courseID int) (features []string, ok bool, err error) {
+	const op = "postgres.enrollment_repo.Features"
+
+	var planID sql.NullInt64
+	err = r.db.QueryRow(`
+		SELECT e.plan_id, COALESCE(p.features, '{}')
+		FROM enrollments e
+		LEFT JOIN course_plans p ON p.id = e.plan_id
+		WHERE e.user_id = $1 AND e.course_id = $2 AND `+activeEnrollment,
+		userID, courseID).Scan(&planID, pq.Array(&features))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !planID.Valid {
+		return structures.AllFeatures, true, nil
+	}
+	return features, true, nil
+}
+
+// SelectActive returns the enrollments of the user that still give access
+func (r *EnrollmentRepo) SelectActive(userID int) ([]structures.Enrollment, error) {
+	return r.selectEnrollments(`WHERE e.user_id = $1 AND `+activeEnrollment, userID)
+}
+
+// Select returns enrollments including expired ones, filtered by user and/or course
+func (r *EnrollmentRepo) Select(filter structures.EnrollmentFilter) ([]structures.Enrollment, error) {
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		add("e.user_id = $%d", filter.UserID)
+	}
+	if filter.CourseID != 0 {
+		add("e.course_id = $%d", filter.CourseID)
+	}
+	if filter.CohortID != 0 {
+		add("e.cohort_id = $%d", filter.CohortID)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	return r.selectEnrollments(where, args...)
+}
+
+func (r *EnrollmentRepo) selectEnrollments(where string, args ...any) ([]structures.Enrollment, error) {
+	const op = "postgres.enrollment_repo.selectEnrollments"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT e.id, e.user_id, u.username, e.course_id, c.title, COALESCE(e.plan_id, 0), COALESCE(p.title, ''),
+			COALESCE(e.cohort_id, 0), COALESCE(co.title, ''),
+			e.source, COALESCE(e.order_id, 0), COALESCE(e.granted_by, 0), e.granted_at, e.expires_at, `+activeEnrollment+`
+		FROM enrollments e
+		JOIN users u ON u.id = e.user_id
+		JOIN courses c ON c.id = e.course_id
+		LEFT JOIN course_plans p ON p.id = e.plan_id
+		LEFT JOIN cohorts co ON co.id = e.cohort_id
+		`+where+`
+		ORDER BY e.granted_at DESC, e.id DESC
+	`, args...)
+	if err != nil {
+		log.Error("failed to select enrollments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	enrollments := []structures.Enrollment{}
+	for rows.Next() {
+		var e structures.Enrollment
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&e.Id, &e.UserID, &e.Username, &e.CourseID, &e.CourseTitle, &e.PlanID, &e.PlanTitle,
+			&e.CohortID, &e.CohortTitle, &e.Source, &e.OrderID, &e.GrantedBy, &e.GrantedAt, &expiresAt, &e.Active); err != nil {
+			log.Error("failed to scan enrollment", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if expiresAt.Valid {
+			e.ExpiresAt = &expiresAt.Time
+		}
+		enrollments = append(enrollments, e)
+	}
+
+	return enrollments, rows.Err()
+}
+
+// Import grants or revokes access for every row without a Status in one transaction and sets the
+// Status of each row. With dryRun the transaction is rolled back, so only the report is produced.
+func (r *EnrollmentRepo) Import(rows []structures.AccessImportRow, action string, grantedBy int, dryRun bool) error {
+	const op = "postgres.enrollment_repo.Import"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	courses := make(map[int]bool)
+	cohorts := make(map[int]pq.Int64Array)
+	for i := range rows {
+		row := &rows[i]
+		if row.Status != "" {
+			continue
+		}
+
+		// a username is matched first, so a name made of digits still works
+		err := tx.QueryRow(`SELECT id FROM users WHERE username = $1`, row.User).Scan(&row.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			if id, convErr := strconv.Atoi(row.User); convErr == nil {
+				err = tx.QueryRow(`SELECT id FROM users WHERE id = $1`, id).Scan(&row.UserID)
+			}
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			row.Status = structures.AccessUnknownUser
+			continue
+		}
+		if err != nil {
+			log.Error("failed to find user", slog.Int("line", row.Line), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		exists, ok := courses[row.CourseID]
+		if !ok {
+			if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM courses WHERE id = $1)`, row.CourseID).Scan(&exists); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			courses[row.CourseID] = exists
+		}
+		if !exists {
+			row.Status = structures.AccessUnknownCourse
+			continue
+		}
+
+		if action == structures.AccessRevoke {
+			result, err := tx.Exec(`DELETE FROM enrollments WHERE user_id = $1 AND course_id = $2`, row.UserID, row.CourseID)
+			if err != nil {
+				log.Error("failed to remove access", slog.Int("line", row.Line), sl.Err(err))
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			if n, _ := result.RowsAffected(); n > 0 {
+				row.Status = structures.AccessRevoked
+			} else {
+				row.Status = structures.AccessNotEnrolled
+			}
+			continue
+		}
+
+		var active bool
+		err = tx.QueryRow(`
+			SELECT EXISTS (
+				SELECT 1 FROM enrollments e
+				WHERE e.user_id = $1 AND e.course_id = $2 AND `+activeEnrollment+`
+			)
+		`, row.UserID, row.CourseID).Scan(&active)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if active {
+			row.Status = structures.AccessAlreadyHad
+			continue
+		}
+
+		// courses with cohorts are only given with a seat in one of them
+		courseCohorts, ok := cohorts[row.CourseID]
+		if !ok {
+			err := tx.QueryRow(`SELECT COALESCE(array_agg(id), '{}') FROM cohorts WHERE course_id = $1`, row.CourseID).Scan(&courseCohorts)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			cohorts[row.CourseID] = courseCohorts
+		}
+		switch {
+		case row.CohortID == 0 && len(courseCohorts) > 0:
+			row.Status = structures.AccessNeedsCohort
+			continue
+		case row.CohortID != 0 && !slices.Contains(courseCohorts, int64(row.CohortID)):
+			row.Status = structures.AccessUnknownCohort
+			continue
+		case row.CohortID != 0:
+			// rows granted earlier in this import take their seats too
+			var free bool
+			err := tx.QueryRow(`
+				SELECT co.capacity = 0 OR co.capacity > (
+					SELECT COUNT(*) FROM enrollments e WHERE e.cohort_id = co.id AND `+activeEnrollment+`
+				)
+				FROM cohorts co WHERE co.id = $1
+			`, row.CohortID).Scan(&free)
+			if err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+			if !free {
+				row.Status = structures.AccessCohortFull
+				continue
+			}
+		}
+
+		_, err = tx.Exec(enrollmentUpsert, row.UserID, row.CourseID, structures.EnrollmentManual, grantedBy, row.ExpiresAt, 0, row.CohortID, 0)
+		if err != nil {
+			log.Error("failed to give access", slog.Int("line", row.Line), sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		row.Status = structures.AccessGranted
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("access imported", slog.String("action", action), slog.Int("rows", len(rows)), slog.Int("granted_by", grantedBy))
+	return nil
+}
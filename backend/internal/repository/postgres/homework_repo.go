@@ -0,0 +1,415 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrAssignmentNotFound = errors.New("assignment not found")
+	ErrSubmissionNotFound = errors.New("submission not found")
+)
+
+type HomeworkRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewHomeworkRepo(log *slog.Logger, db *sql.DB) *HomeworkRepo {
+	return &HomeworkRepo{log: log, db: db}
+}
+
+const assignmentColumns = `id, course_id, video_id, title, description, created_at`
+
+func scanAssignment(row interface{ Scan(...any) error }) (structures.Assignment, error) {
+	var a structures.Assignment
+	err := row.Scan(&a.Id, &a.CourseID, &a.VideoID, &a.Title, &a.Description, &a.CreatedAt)
+	return a, err
+}
+
+const submissionColumns = `s.id, s.assignment_id, s.user_id, u.username, s.comment, s.status, s.feedback,
+	COALESCE(s.reviewer_id, 0), s.reviewed_at, s.created_at`
+
+func scanSubmission(row interface{ Scan(...any) error }) (structures.Submission, error) {
+	var s structures.Submission
+	var reviewedAt sql.NullTime
+	err := row.Scan(&s.Id, &s.AssignmentID, &s.UserID, &s.Username, &s.Comment, &s.Status, &s.Feedback,
+		&s.ReviewerID, &reviewedAt, &s.CreatedAt)
+	if reviewedAt.Valid {
+		s.ReviewedAt = &reviewedAt.Time
+	}
+	s.Files = []structures.SubmissionFile{}
+	return s, err
+}
+
+func (r *HomeworkRepo) InsertAssignment(a structures.Assignment) (int, error) {
+	const op = "postgres.homework_repo.InsertAssignment"
+	log := r.log.With("op", op)
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO assignments (course_id, video_id, title, description)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, a.CourseID, a.VideoID, a.Title, a.Description).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert assignment", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("assignment created", slog.Int("id", id), slog.Int("course_id", a.CourseID))
+	return id, nil
+}
+
+func (r *HomeworkRepo) UpdateAssignment(a structures.Assignment) error {
+	const op = "postgres.homework_repo.UpdateAssignment"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE assignments SET video_id = $1, title = $2, description = $3
+		WHERE id = $4
+	`, a.VideoID, a.Title, a.Description, a.Id)
+	if err != nil {
+		log.Error("failed to update assignment", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrAssignmentNotFound
+	}
+
+	return nil
+}
+
+// DeleteAssignment removes the assignment with all its submissions and returns the paths of the uploaded files
+func (r *HomeworkRepo) DeleteAssignment(id int) ([]string, error) {
+	const op = "postgres.homework_repo.DeleteAssignment"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	paths, err := selectPaths(tx, `
+		SELECT f.path FROM submission_files f
+		JOIN submissions s ON s.id = f.submission_id
+		WHERE s.assignment_id = $1
+	`, id)
+	if err != nil {
+		log.Error("failed to select submission files", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := tx.Exec(`DELETE FROM assignments WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete assignment", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return nil, ErrAssignmentNotFound
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("assignment deleted", slog.Int("id", id))
+	return paths, nil
+}
+
+func (r *HomeworkRepo) SelectAssignmentById(id int) (structures.Assignment, error) {
+	const op = "postgres.homework_repo.SelectAssignmentById"
+	log := r.log.With("op", op)
+
+	a, err := scanAssignment(r.db.QueryRow(`SELECT `+assignmentColumns+` FROM assignments WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return a, ErrAssignmentNotFound
+		}
+		log.Error("failed to select assignment", sl.Err(err))
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+// SelectCourseAssignments returns the assignments of a course in syllabus order
+func (r *HomeworkRepo) SelectCourseAssignments(courseID int) ([]structures.Assignment, error) {
+	const op = "postgres.homework_repo.SelectCourseAssignments"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT a.id, a.course_id, a.video_id, a.title, a.description, a.created_at
+		FROM assignments a
+		JOIN videos v ON v.id = a.video_id
+		LEFT JOIN course_modules m ON m.id = v.module_id
+		WHERE a.course_id = $1
+		ORDER BY m.position, v.position, a.id
+	`, courseID)
+	if err != nil {
+		log.Error("failed to select assignments", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	assignments := []structures.Assignment{}
+	for rows.Next() {
+		a, err := scanAssignment(rows)
+		if err != nil {
+			log.Error("failed to scan assignment", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		assignments = append(assignments, a)
+	}
+
+	return assignments, rows.Err()
+}
+
+// InsertSubmission stores a submission together with its files and returns it with ids filled in
+func (r *HomeworkRepo) InsertSubmission(s structures.Submission) (structures.Submission, error) {
+	const op = "postgres.homework_repo.InsertSubmission"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return s, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO submissions (assignment_id, user_id, comment, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, s.AssignmentID, s.UserID, s.Comment, structures.SubmissionPending).Scan(&s.Id, &s.CreatedAt)
+	if err != nil {
+		log.Error("failed to insert submission", sl.Err(err))
+		return s, fmt.Errorf("%s: %w", op, err)
+	}
+	s.Status = structures.SubmissionPending
+
+	for i := range s.Files {
+		f := &s.Files[i]
+		f.SubmissionID = s.Id
+		err := tx.QueryRow(`
+			INSERT INTO submission_files (submission_id, title, path)
+			VALUES ($1, $2, $3)
+			RETURNING id
+		`, s.Id, f.Title, f.Path).Scan(&f.Id)
+		if err != nil {
+			log.Error("failed to insert submission file", sl.Err(err))
+			return s, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return s, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("submission created", slog.Int("id", s.Id), slog.Int("assignment_id", s.AssignmentID), slog.Int("user_id", s.UserID))
+	return s, nil
+}
+
+func (r *HomeworkRepo) SelectSubmissionById(id int) (structures.Submission, error) {
+	const op = "postgres.homework_repo.SelectSubmissionById"
+	log := r.log.With("op", op)
+
+	s, err := scanSubmission(r.db.QueryRow(`
+		SELECT `+submissionColumns+`
+		FROM submissions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1
+	`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return s, ErrSubmissionNotFound
+		}
+		log.Error("failed to select submission", sl.Err(err))
+		return s, fmt.Errorf("%s: %w", op, err)
+	}
+
+	submissions := []structures.Submission{s}
+	if err := r.attachFiles(submissions); err != nil {
+		log.Error("failed to select submission files", sl.Err(err))
+		return s, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return submissions[0], nil
+}
+
+// SelectSubmissions returns submissions matching the filter, newest first. Zero fields are not filtered on.
+func (r *HomeworkRepo) SelectSubmissions(f structures.SubmissionFilter) ([]structures.Submission, error) {
+	const op = "postgres.homework_repo.SelectSubmissions"
+	log := r.log.With("op", op)
+
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.AssignmentID != 0 {
+		add("s.assignment_id = $%d", f.AssignmentID)
+	}
+	if f.CourseID != 0 {
+		add("a.course_id = $%d", f.CourseID)
+	}
+	if f.UserID != 0 {
+		add("s.user_id = $%d", f.UserID)
+	}
+	if f.Status != "" {
+		add("s.status = $%d", f.Status)
+	}
+	if f.CuratorID != 0 {
+		add("EXISTS (SELECT 1 FROM enrollments e JOIN cohort_curators cc ON cc.cohort_id = e.cohort_id"+
+			" WHERE e.user_id = s.user_id AND e.course_id = a.course_id AND cc.user_id = $%d)", f.CuratorID)
+	}
+
+	query := `
+		SELECT ` + submissionColumns + `
+		FROM submissions s
+		JOIN users u ON u.id = s.user_id
+		JOIN assignments a ON a.id = s.assignment_id`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY s.created_at DESC, s.id DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Error("failed to select submissions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	submissions := []structures.Submission{}
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			log.Error("failed to scan submission", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		submissions = append(submissions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.attachFiles(submissions); err != nil {
+		log.Error("failed to select submission files", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return submissions, nil
+}
+
+func (r *HomeworkRepo) attachFiles(submissions []structures.Submission) error {
+	if len(submissions) == 0 {
+		return nil
+	}
+
+	ids := make([]int, len(submissions))
+	index := make(map[int]int, len(submissions))
+	for i, s := range submissions {
+		ids[i] = s.Id
+		index[s.Id] = i
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, submission_id, title, path
+		FROM submission_files
+		WHERE submission_id = ANY($1)
+		ORDER BY id
+	`, intArray(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var f structures.SubmissionFile
+		if err := rows.Scan(&f.Id, &f.SubmissionID, &f.Title, &f.Path); err != nil {
+			return err
+		}
+		i := index[f.SubmissionID]
+		submissions[i].Files = append(submissions[i].Files, f)
+	}
+
+	return rows.Err()
+}
+
+// SelectLastSubmissions returns the latest submission of the user per assignment of the course
+func (r *HomeworkRepo) SelectLastSubmissions(userID, courseID int) (map[int]structures.Submission, error) {
+	const op = "postgres.homework_repo.SelectLastSubmissions"
+
+	submissions, err := r.SelectSubmissions(structures.SubmissionFilter{CourseID: courseID, UserID: userID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	last := make(map[int]structures.Submission)
+	for _, s := range submissions {
+		// newest first, keep the first one seen
+		if _, ok := last[s.AssignmentID]; !ok {
+			last[s.AssignmentID] = s
+		}
+	}
+
+	return last, nil
+}
+
+func (r *HomeworkRepo) ReviewSubmission(id, reviewerID int, status, feedback string) error {
+	const op = "postgres.homework_repo.ReviewSubmission"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE submissions
+		SET status = $1, feedback = $2, reviewer_id = $3, reviewed_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $4
+	`, status, feedback, reviewerID, id)
+	if err != nil {
+		log.Error("failed to review submission", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrSubmissionNotFound
+	}
+
+	log.Info("submission reviewed", slog.Int("id", id), slog.String("status", status), slog.Int("reviewer_id", reviewerID))
+	return nil
+}
+
+func (r *HomeworkRepo) SelectSubmissionFile(id int) (structures.SubmissionFile, error) {
+	const op = "postgres.homework_repo.SelectSubmissionFile"
+	log := r.log.With("op", op)
+
+	var f structures.SubmissionFile
+	err := r.db.QueryRow(`
+		SELECT id, submission_id, title, path FROM submission_files WHERE id = $1
+	`, id).Scan(&f.Id, &f.SubmissionID, &f.Title, &f.Path)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return f, ErrSubmissionNotFound
+		}
+		log.Error("failed to select submission file", sl.Err(err))
+		return f, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return f, nil
+}
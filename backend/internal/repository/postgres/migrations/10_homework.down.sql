@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS public.submission_files CASCADE;
+DROP SEQUENCE IF EXISTS public.submission_files_id_seq;
+DROP TABLE IF EXISTS public.submissions CASCADE;
+DROP SEQUENCE IF EXISTS public.submissions_id_seq;
+DROP TABLE IF EXISTS public.assignments CASCADE;
+DROP SEQUENCE IF EXISTS public.assignments_id_seq;
@@ -0,0 +1,82 @@
+-- ======================
+-- Домашние задания
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.assignments_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.assignments (
+    id integer NOT NULL DEFAULT nextval('public.assignments_id_seq'::regclass),
+    course_id integer NOT NULL,
+    video_id integer NOT NULL,                  -- урок, к которому относится задание
+    title text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT assignments_pkey PRIMARY KEY (id),
+    CONSTRAINT assignments_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT assignments_video_id_fkey FOREIGN KEY (video_id) REFERENCES public.videos(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.assignments_id_seq OWNED BY public.assignments.id;
+
+CREATE INDEX IF NOT EXISTS idx_assignments_course_id ON public.assignments(course_id);
+
+-- ======================
+-- Сданные работы
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.submissions_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.submissions (
+    id integer NOT NULL DEFAULT nextval('public.submissions_id_seq'::regclass),
+    assignment_id integer NOT NULL,
+    user_id integer NOT NULL,
+    comment text NOT NULL DEFAULT '',
+    status character varying(20) NOT NULL DEFAULT 'pending', -- pending, accepted, needs_revision
+    feedback text NOT NULL DEFAULT '',
+    reviewer_id integer,
+    reviewed_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT submissions_pkey PRIMARY KEY (id),
+    CONSTRAINT submissions_assignment_id_fkey FOREIGN KEY (assignment_id) REFERENCES public.assignments(id) ON DELETE CASCADE,
+    CONSTRAINT submissions_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT submissions_reviewer_id_fkey FOREIGN KEY (reviewer_id) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.submissions_id_seq OWNED BY public.submissions.id;
+
+CREATE INDEX IF NOT EXISTS idx_submissions_assignment_user ON public.submissions(assignment_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_submissions_status ON public.submissions(status);
+
+-- ======================
+-- Файлы работ
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.submission_files_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.submission_files (
+    id integer NOT NULL DEFAULT nextval('public.submission_files_id_seq'::regclass),
+    submission_id integer NOT NULL,
+    title text NOT NULL,
+    path text NOT NULL,
+    CONSTRAINT submission_files_pkey PRIMARY KEY (id),
+    CONSTRAINT submission_files_submission_id_fkey FOREIGN KEY (submission_id) REFERENCES public.submissions(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.submission_files_id_seq OWNED BY public.submission_files.id;
+
+CREATE INDEX IF NOT EXISTS idx_submission_files_submission_id ON public.submission_files(submission_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.orders CASCADE;
+DROP SEQUENCE IF EXISTS public.orders_id_seq;
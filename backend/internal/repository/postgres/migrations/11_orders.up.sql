@@ -0,0 +1,35 @@
+-- ======================
+-- Заказы
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.orders (
+    id integer NOT NULL DEFAULT nextval('public.orders_id_seq'::regclass),
+    user_id integer NOT NULL,
+    course_id integer,                           -- NULL, если курс удалён; заказ остаётся в истории
+    amount integer NOT NULL,
+    currency character varying(3) NOT NULL,
+    status character varying(20) NOT NULL DEFAULT 'pending', -- pending, paid, failed, refunded
+    provider character varying(50) NOT NULL,
+    payment_id text,                             -- идентификатор платежа у провайдера
+    checkout_url text NOT NULL DEFAULT '',
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    updated_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    paid_at timestamp without time zone,
+    refunded_at timestamp without time zone,
+    CONSTRAINT orders_pkey PRIMARY KEY (id),
+    CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT orders_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_provider_payment ON public.orders(provider, payment_id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON public.orders(user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON public.orders(status);
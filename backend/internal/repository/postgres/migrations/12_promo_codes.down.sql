@@ -0,0 +1,7 @@
+ALTER TABLE public.orders
+    DROP CONSTRAINT IF EXISTS orders_promo_code_id_fkey,
+    DROP COLUMN IF EXISTS promo_code_id,
+    DROP COLUMN IF EXISTS discount;
+
+DROP TABLE IF EXISTS public.promo_codes CASCADE;
+DROP SEQUENCE IF EXISTS public.promo_codes_id_seq;
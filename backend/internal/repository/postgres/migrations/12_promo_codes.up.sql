@@ -0,0 +1,37 @@
+-- ======================
+-- Промокоды
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.promo_codes_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.promo_codes (
+    id integer NOT NULL DEFAULT nextval('public.promo_codes_id_seq'::regclass),
+    code character varying(64) NOT NULL,         -- хранится в верхнем регистре
+    kind character varying(20) NOT NULL,         -- percent, fixed
+    value integer NOT NULL,                      -- процент или сумма скидки
+    expires_at timestamp without time zone,
+    max_uses integer NOT NULL DEFAULT 0,         -- 0 - без ограничений
+    max_uses_per_user integer NOT NULL DEFAULT 1, -- 0 - без ограничений
+    course_ids integer[] NOT NULL DEFAULT '{}',  -- пусто - действует на все курсы
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT promo_codes_pkey PRIMARY KEY (id),
+    CONSTRAINT promo_codes_code_key UNIQUE (code)
+);
+
+ALTER SEQUENCE public.promo_codes_id_seq OWNED BY public.promo_codes.id;
+
+-- ======================
+-- Скидка в заказах
+-- ======================
+ALTER TABLE public.orders
+    ADD COLUMN IF NOT EXISTS promo_code_id integer,
+    ADD COLUMN IF NOT EXISTS discount integer NOT NULL DEFAULT 0,
+    ADD CONSTRAINT orders_promo_code_id_fkey FOREIGN KEY (promo_code_id) REFERENCES public.promo_codes(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_promo_code_id ON public.orders(promo_code_id);
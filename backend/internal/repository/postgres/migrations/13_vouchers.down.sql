@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.vouchers CASCADE;
+DROP SEQUENCE IF EXISTS public.vouchers_id_seq;
+DROP TABLE IF EXISTS public.voucher_batches CASCADE;
+DROP SEQUENCE IF EXISTS public.voucher_batches_id_seq;
@@ -0,0 +1,51 @@
+-- ======================
+-- Партии ваучеров
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.voucher_batches_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.voucher_batches (
+    id integer NOT NULL DEFAULT nextval('public.voucher_batches_id_seq'::regclass),
+    course_id integer NOT NULL,
+    title text NOT NULL DEFAULT '',              -- например, название клиники-партнёра
+    expires_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT voucher_batches_pkey PRIMARY KEY (id),
+    CONSTRAINT voucher_batches_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.voucher_batches_id_seq OWNED BY public.voucher_batches.id;
+
+CREATE INDEX IF NOT EXISTS idx_voucher_batches_course_id ON public.voucher_batches(course_id);
+
+-- ======================
+-- Ваучеры (одноразовые)
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.vouchers_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.vouchers (
+    id integer NOT NULL DEFAULT nextval('public.vouchers_id_seq'::regclass),
+    batch_id integer NOT NULL,
+    code character varying(32) NOT NULL,
+    redeemed_by integer,
+    redeemed_at timestamp without time zone,
+    CONSTRAINT vouchers_pkey PRIMARY KEY (id),
+    CONSTRAINT vouchers_code_key UNIQUE (code),
+    CONSTRAINT vouchers_batch_id_fkey FOREIGN KEY (batch_id) REFERENCES public.voucher_batches(id) ON DELETE CASCADE,
+    CONSTRAINT vouchers_redeemed_by_fkey FOREIGN KEY (redeemed_by) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.vouchers_id_seq OWNED BY public.vouchers.id;
+
+CREATE INDEX IF NOT EXISTS idx_vouchers_batch_id ON public.vouchers(batch_id);
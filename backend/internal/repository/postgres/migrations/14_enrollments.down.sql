@@ -0,0 +1,11 @@
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS course_ids integer[] DEFAULT '{}';
+
+UPDATE public.users u
+SET course_ids = ARRAY(
+    SELECT e.course_id FROM public.enrollments e
+    WHERE e.user_id = u.id AND (e.expires_at IS NULL OR e.expires_at > (now() AT TIME ZONE 'utc'))
+    ORDER BY e.course_id
+);
+
+DROP TABLE IF EXISTS public.enrollments CASCADE;
+DROP SEQUENCE IF EXISTS public.enrollments_id_seq;
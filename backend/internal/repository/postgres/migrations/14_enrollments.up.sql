@@ -0,0 +1,55 @@
+-- ======================
+-- Доступ пользователей к курсам
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.enrollments_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.enrollments (
+    id integer NOT NULL DEFAULT nextval('public.enrollments_id_seq'::regclass),
+    user_id integer NOT NULL,
+    course_id integer NOT NULL,
+    source character varying(20) NOT NULL DEFAULT 'manual', -- manual, payment, voucher
+    granted_by integer,                                     -- админ, выдавший доступ вручную
+    granted_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    expires_at timestamp without time zone,                 -- NULL - бессрочно
+    CONSTRAINT enrollments_pkey PRIMARY KEY (id),
+    CONSTRAINT enrollments_user_course_key UNIQUE (user_id, course_id),
+    CONSTRAINT enrollments_source_check CHECK (source IN ('manual', 'payment', 'voucher')),
+    CONSTRAINT enrollments_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT enrollments_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT enrollments_granted_by_fkey FOREIGN KEY (granted_by) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.enrollments_id_seq OWNED BY public.enrollments.id;
+
+CREATE INDEX IF NOT EXISTS idx_enrollments_course_id ON public.enrollments(course_id);
+
+-- ======================
+-- Перенос users.course_ids. Источник и дата берутся из оплаченного заказа или ваучера, если они есть.
+-- Ссылки на удалённые курсы отбрасываются.
+-- ======================
+INSERT INTO public.enrollments (user_id, course_id, source, granted_at)
+SELECT DISTINCT ON (u.id, c.id)
+    u.id,
+    c.id,
+    CASE
+        WHEN o.id IS NOT NULL THEN 'payment'
+        WHEN v.id IS NOT NULL THEN 'voucher'
+        ELSE 'manual'
+    END,
+    COALESCE(o.paid_at, v.redeemed_at, now() AT TIME ZONE 'utc')
+FROM public.users u
+CROSS JOIN LATERAL unnest(u.course_ids) AS ids(course_id)
+JOIN public.courses c ON c.id = ids.course_id
+LEFT JOIN public.orders o ON o.user_id = u.id AND o.course_id = c.id AND o.status = 'paid'
+LEFT JOIN public.vouchers v ON v.redeemed_by = u.id
+    AND v.batch_id IN (SELECT b.id FROM public.voucher_batches b WHERE b.course_id = c.id)
+ORDER BY u.id, c.id, o.paid_at DESC NULLS LAST, v.redeemed_at DESC NULLS LAST
+ON CONFLICT (user_id, course_id) DO NOTHING;
+
+ALTER TABLE public.users DROP COLUMN IF EXISTS course_ids;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.access_requests CASCADE;
+DROP SEQUENCE IF EXISTS public.access_requests_id_seq;
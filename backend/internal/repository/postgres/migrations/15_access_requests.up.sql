@@ -0,0 +1,36 @@
+-- ======================
+-- Заявки на доступ к курсу
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.access_requests_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.access_requests (
+    id integer NOT NULL DEFAULT nextval('public.access_requests_id_seq'::regclass),
+    user_id integer NOT NULL,
+    course_id integer NOT NULL,
+    contact character varying(255) NOT NULL,               -- телефон или WhatsApp для связи
+    comment text NOT NULL DEFAULT '',
+    receipt_path text,                                     -- чек об оплате
+    status character varying(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    admin_comment text NOT NULL DEFAULT '',
+    reviewed_by integer,
+    reviewed_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT access_requests_pkey PRIMARY KEY (id),
+    CONSTRAINT access_requests_status_check CHECK (status IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT access_requests_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT access_requests_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT access_requests_reviewed_by_fkey FOREIGN KEY (reviewed_by) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.access_requests_id_seq OWNED BY public.access_requests.id;
+
+-- не больше одной необработанной заявки на курс от пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS idx_access_requests_pending
+    ON public.access_requests(user_id, course_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_access_requests_status ON public.access_requests(status);
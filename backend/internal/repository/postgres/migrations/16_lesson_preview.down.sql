@@ -0,0 +1,2 @@
+ALTER TABLE public.videos DROP COLUMN IF EXISTS duration;
+ALTER TABLE public.videos DROP COLUMN IF EXISTS is_preview;
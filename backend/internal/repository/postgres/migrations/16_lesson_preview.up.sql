@@ -0,0 +1,5 @@
+-- ======================
+-- Бесплатные уроки и длительность
+-- ======================
+ALTER TABLE public.videos ADD COLUMN IF NOT EXISTS is_preview boolean NOT NULL DEFAULT false;
+ALTER TABLE public.videos ADD COLUMN IF NOT EXISTS duration integer NOT NULL DEFAULT 0; -- секунды
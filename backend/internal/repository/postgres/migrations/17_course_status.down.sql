@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.idx_courses_scheduled;
+ALTER TABLE public.courses DROP CONSTRAINT IF EXISTS courses_status_check;
+ALTER TABLE public.courses DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE public.courses DROP COLUMN IF EXISTS status;
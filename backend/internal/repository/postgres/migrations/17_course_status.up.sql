@@ -0,0 +1,13 @@
+-- ======================
+-- Статус курса и отложенная публикация
+-- ======================
+-- существующие курсы остаются опубликованными, новые создаются черновиками
+ALTER TABLE public.courses ADD COLUMN IF NOT EXISTS status character varying(20) NOT NULL DEFAULT 'published'; -- draft, scheduled, published, archived
+ALTER TABLE public.courses ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE public.courses ADD COLUMN IF NOT EXISTS publish_at timestamp without time zone;
+
+ALTER TABLE public.courses DROP CONSTRAINT IF EXISTS courses_status_check;
+ALTER TABLE public.courses ADD CONSTRAINT courses_status_check
+    CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_courses_scheduled ON public.courses(publish_at) WHERE status = 'scheduled';
@@ -0,0 +1,3 @@
+ALTER TABLE public.videos DROP CONSTRAINT IF EXISTS videos_unlock_after_days_check;
+ALTER TABLE public.videos DROP COLUMN IF EXISTS unlock_at;
+ALTER TABLE public.videos DROP COLUMN IF EXISTS unlock_after_days;
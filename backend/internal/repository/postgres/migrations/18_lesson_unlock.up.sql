@@ -0,0 +1,9 @@
+-- ======================
+-- Постепенное открытие уроков
+-- ======================
+-- урок открывается через unlock_after_days дней после выдачи доступа и/или не раньше unlock_at
+ALTER TABLE public.videos ADD COLUMN IF NOT EXISTS unlock_after_days integer;
+ALTER TABLE public.videos ADD COLUMN IF NOT EXISTS unlock_at timestamp without time zone;
+
+ALTER TABLE public.videos DROP CONSTRAINT IF EXISTS videos_unlock_after_days_check;
+ALTER TABLE public.videos ADD CONSTRAINT videos_unlock_after_days_check CHECK (unlock_after_days >= 0);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.course_reviews CASCADE;
+DROP SEQUENCE IF EXISTS public.course_reviews_id_seq;
@@ -0,0 +1,36 @@
+-- ======================
+-- Отзывы и оценки курсов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.course_reviews_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.course_reviews (
+    id integer NOT NULL DEFAULT nextval('public.course_reviews_id_seq'::regclass),
+    user_id integer NOT NULL,
+    course_id integer NOT NULL,
+    rating smallint NOT NULL,                                -- от 1 до 5
+    text text NOT NULL DEFAULT '',
+    status character varying(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected
+    admin_comment text NOT NULL DEFAULT '',
+    moderated_by integer,
+    moderated_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    updated_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT course_reviews_pkey PRIMARY KEY (id),
+    CONSTRAINT course_reviews_user_course_key UNIQUE (user_id, course_id),
+    CONSTRAINT course_reviews_rating_check CHECK (rating BETWEEN 1 AND 5),
+    CONSTRAINT course_reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT course_reviews_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT course_reviews_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT course_reviews_moderated_by_fkey FOREIGN KEY (moderated_by) REFERENCES public.users(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.course_reviews_id_seq OWNED BY public.course_reviews.id;
+
+CREATE INDEX IF NOT EXISTS idx_course_reviews_course_status ON public.course_reviews(course_id, status);
+CREATE INDEX IF NOT EXISTS idx_course_reviews_status ON public.course_reviews(status);
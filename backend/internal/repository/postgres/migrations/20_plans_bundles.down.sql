@@ -0,0 +1,14 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_bundle_id_fkey;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_plan_id_fkey;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS bundle_id;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS plan_id;
+
+DROP TABLE IF EXISTS public.bundle_courses CASCADE;
+DROP TABLE IF EXISTS public.bundles CASCADE;
+DROP SEQUENCE IF EXISTS public.bundles_id_seq;
+
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_plan_id_fkey;
+ALTER TABLE public.enrollments DROP COLUMN IF EXISTS plan_id;
+
+DROP TABLE IF EXISTS public.course_plans CASCADE;
+DROP SEQUENCE IF EXISTS public.course_plans_id_seq;
@@ -0,0 +1,82 @@
+-- ======================
+-- Тарифы курсов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.course_plans_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.course_plans (
+    id integer NOT NULL DEFAULT nextval('public.course_plans_id_seq'::regclass),
+    course_id integer NOT NULL,
+    title character varying(255) NOT NULL,
+    description text NOT NULL DEFAULT '',
+    cost integer NOT NULL,
+    features text[] NOT NULL DEFAULT '{}',       -- webinars, homework, diploma; видео и материалы есть в любом тарифе
+    position integer NOT NULL DEFAULT 0,
+    active boolean NOT NULL DEFAULT true,        -- неактивный тариф не продаётся, но остаётся у купивших
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT course_plans_pkey PRIMARY KEY (id),
+    CONSTRAINT course_plans_cost_check CHECK (cost >= 0),
+    CONSTRAINT course_plans_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.course_plans_id_seq OWNED BY public.course_plans.id;
+
+CREATE INDEX IF NOT EXISTS idx_course_plans_course_id ON public.course_plans(course_id);
+
+-- доступ без тарифа (выданный вручную, по ваучеру и т.д.) открывает все возможности курса
+ALTER TABLE public.enrollments ADD COLUMN IF NOT EXISTS plan_id integer;
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_plan_id_fkey;
+ALTER TABLE public.enrollments ADD CONSTRAINT enrollments_plan_id_fkey
+    FOREIGN KEY (plan_id) REFERENCES public.course_plans(id) ON DELETE SET NULL;
+
+-- ======================
+-- Пакеты курсов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.bundles_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.bundles (
+    id integer NOT NULL DEFAULT nextval('public.bundles_id_seq'::regclass),
+    title character varying(255) NOT NULL,
+    description text NOT NULL DEFAULT '',
+    cost integer NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT bundles_pkey PRIMARY KEY (id),
+    CONSTRAINT bundles_cost_check CHECK (cost >= 0)
+);
+
+ALTER SEQUENCE public.bundles_id_seq OWNED BY public.bundles.id;
+
+CREATE TABLE IF NOT EXISTS public.bundle_courses (
+    bundle_id integer NOT NULL,
+    course_id integer NOT NULL,
+    plan_id integer,                             -- тариф курса в пакете, NULL - полный доступ
+    position integer NOT NULL DEFAULT 0,
+    CONSTRAINT bundle_courses_pkey PRIMARY KEY (bundle_id, course_id),
+    CONSTRAINT bundle_courses_bundle_id_fkey FOREIGN KEY (bundle_id) REFERENCES public.bundles(id) ON DELETE CASCADE,
+    CONSTRAINT bundle_courses_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT bundle_courses_plan_id_fkey FOREIGN KEY (plan_id) REFERENCES public.course_plans(id) ON DELETE SET NULL
+);
+
+-- ======================
+-- Заказы тарифов и пакетов
+-- ======================
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS plan_id integer;
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS bundle_id integer;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_plan_id_fkey;
+ALTER TABLE public.orders ADD CONSTRAINT orders_plan_id_fkey
+    FOREIGN KEY (plan_id) REFERENCES public.course_plans(id) ON DELETE SET NULL;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_bundle_id_fkey;
+ALTER TABLE public.orders ADD CONSTRAINT orders_bundle_id_fkey
+    FOREIGN KEY (bundle_id) REFERENCES public.bundles(id) ON DELETE SET NULL;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS public.learning_path_courses CASCADE;
+DROP TABLE IF EXISTS public.learning_paths CASCADE;
+DROP SEQUENCE IF EXISTS public.learning_paths_id_seq;
@@ -0,0 +1,34 @@
+-- ======================
+-- Траектории обучения
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.learning_paths_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.learning_paths (
+    id integer NOT NULL DEFAULT nextval('public.learning_paths_id_seq'::regclass),
+    title character varying(255) NOT NULL,
+    description text NOT NULL DEFAULT '',
+    active boolean NOT NULL DEFAULT true,        -- условия неактивной траектории не действуют
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT learning_paths_pkey PRIMARY KEY (id)
+);
+
+ALTER SEQUENCE public.learning_paths_id_seq OWNED BY public.learning_paths.id;
+
+CREATE TABLE IF NOT EXISTS public.learning_path_courses (
+    path_id integer NOT NULL,
+    course_id integer NOT NULL,
+    prerequisite_id integer,                     -- курс этой же траектории, который нужно пройти до открытия уроков
+    position integer NOT NULL DEFAULT 0,
+    CONSTRAINT learning_path_courses_pkey PRIMARY KEY (path_id, course_id),
+    CONSTRAINT learning_path_courses_path_id_fkey FOREIGN KEY (path_id) REFERENCES public.learning_paths(id) ON DELETE CASCADE,
+    CONSTRAINT learning_path_courses_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT learning_path_courses_prerequisite_id_fkey FOREIGN KEY (prerequisite_id) REFERENCES public.courses(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_learning_path_courses_course_id ON public.learning_path_courses(course_id);
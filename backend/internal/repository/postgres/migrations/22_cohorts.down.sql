@@ -0,0 +1,13 @@
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_cohort_id_fkey;
+ALTER TABLE public.orders DROP COLUMN IF EXISTS cohort_id;
+
+ALTER TABLE public.webinars DROP CONSTRAINT IF EXISTS webinars_cohort_id_fkey;
+ALTER TABLE public.webinars DROP COLUMN IF EXISTS cohort_id;
+
+DROP INDEX IF EXISTS public.idx_enrollments_cohort_id;
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_cohort_id_fkey;
+ALTER TABLE public.enrollments DROP COLUMN IF EXISTS cohort_id;
+
+DROP TABLE IF EXISTS public.cohort_curators CASCADE;
+DROP TABLE IF EXISTS public.cohorts CASCADE;
+DROP SEQUENCE IF EXISTS public.cohorts_id_seq;
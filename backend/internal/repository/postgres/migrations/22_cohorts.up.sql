@@ -0,0 +1,57 @@
+-- ======================
+-- Потоки курсов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.cohorts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.cohorts (
+    id integer NOT NULL DEFAULT nextval('public.cohorts_id_seq'::regclass),
+    course_id integer NOT NULL,
+    title character varying(255) NOT NULL,
+    starts_at timestamp without time zone NOT NULL,  -- от него считается открытие уроков по расписанию
+    capacity integer NOT NULL DEFAULT 0,             -- 0 - без ограничения мест
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT cohorts_pkey PRIMARY KEY (id),
+    CONSTRAINT cohorts_capacity_check CHECK (capacity >= 0),
+    CONSTRAINT cohorts_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.cohorts_id_seq OWNED BY public.cohorts.id;
+
+CREATE INDEX IF NOT EXISTS idx_cohorts_course_id ON public.cohorts(course_id);
+
+CREATE TABLE IF NOT EXISTS public.cohort_curators (
+    cohort_id integer NOT NULL,
+    user_id integer NOT NULL,
+    CONSTRAINT cohort_curators_pkey PRIMARY KEY (cohort_id, user_id),
+    CONSTRAINT cohort_curators_cohort_id_fkey FOREIGN KEY (cohort_id) REFERENCES public.cohorts(id) ON DELETE CASCADE,
+    CONSTRAINT cohort_curators_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_cohort_curators_user_id ON public.cohort_curators(user_id);
+
+-- ======================
+-- Привязка доступа, вебинаров и заказов к потоку
+-- ======================
+ALTER TABLE public.enrollments ADD COLUMN IF NOT EXISTS cohort_id integer;
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_cohort_id_fkey;
+ALTER TABLE public.enrollments ADD CONSTRAINT enrollments_cohort_id_fkey
+    FOREIGN KEY (cohort_id) REFERENCES public.cohorts(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_enrollments_cohort_id ON public.enrollments(cohort_id);
+
+-- вебинар без потока виден всем ученикам курса
+ALTER TABLE public.webinars ADD COLUMN IF NOT EXISTS cohort_id integer;
+ALTER TABLE public.webinars DROP CONSTRAINT IF EXISTS webinars_cohort_id_fkey;
+ALTER TABLE public.webinars ADD CONSTRAINT webinars_cohort_id_fkey
+    FOREIGN KEY (cohort_id) REFERENCES public.cohorts(id) ON DELETE CASCADE;
+
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS cohort_id integer;
+ALTER TABLE public.orders DROP CONSTRAINT IF EXISTS orders_cohort_id_fkey;
+ALTER TABLE public.orders ADD CONSTRAINT orders_cohort_id_fkey
+    FOREIGN KEY (cohort_id) REFERENCES public.cohorts(id) ON DELETE SET NULL;
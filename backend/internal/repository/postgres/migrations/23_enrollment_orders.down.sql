@@ -0,0 +1,2 @@
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_order_id_fkey;
+ALTER TABLE public.enrollments DROP COLUMN IF EXISTS order_id;
@@ -0,0 +1,20 @@
+-- ======================
+-- Заказ, которым куплен доступ
+-- ======================
+-- возврат заказа отзывает доступ, только если он выдан этим заказом
+ALTER TABLE public.enrollments ADD COLUMN IF NOT EXISTS order_id integer;
+ALTER TABLE public.enrollments DROP CONSTRAINT IF EXISTS enrollments_order_id_fkey;
+ALTER TABLE public.enrollments ADD CONSTRAINT enrollments_order_id_fkey
+    FOREIGN KEY (order_id) REFERENCES public.orders(id) ON DELETE SET NULL;
+
+-- Перенос: оплаченный доступ привязывается к последнему оплаченному заказу курса или пакета с ним
+UPDATE public.enrollments e
+SET order_id = (
+    SELECT o.id FROM public.orders o
+    WHERE o.user_id = e.user_id AND o.status = 'paid'
+        AND (o.course_id = e.course_id
+            OR o.bundle_id IN (SELECT bc.bundle_id FROM public.bundle_courses bc WHERE bc.course_id = e.course_id))
+    ORDER BY o.id DESC
+    LIMIT 1
+)
+WHERE e.source = 'payment' AND e.order_id IS NULL;
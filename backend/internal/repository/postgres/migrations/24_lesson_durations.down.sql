@@ -0,0 +1 @@
+ALTER TABLE public.upload_sessions DROP COLUMN IF EXISTS duration;
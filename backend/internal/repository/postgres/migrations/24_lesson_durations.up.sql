@@ -0,0 +1,5 @@
+-- ======================
+-- Длительность видео при загрузке
+-- ======================
+-- урок засчитывается только по длительности, заданной админом; плееру не доверяем
+ALTER TABLE public.upload_sessions ADD COLUMN IF NOT EXISTS duration integer NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE public.orders DROP COLUMN IF EXISTS cohort_ids;
+ALTER TABLE public.voucher_batches DROP CONSTRAINT IF EXISTS voucher_batches_cohort_id_fkey;
+ALTER TABLE public.voucher_batches DROP COLUMN IF EXISTS cohort_id;
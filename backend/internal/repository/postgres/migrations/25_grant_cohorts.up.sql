@@ -0,0 +1,11 @@
+-- ======================
+-- Поток при выдаче доступа
+-- ======================
+-- в курс с потоками доступ выдаётся только с местом в потоке
+ALTER TABLE public.voucher_batches ADD COLUMN IF NOT EXISTS cohort_id integer;
+ALTER TABLE public.voucher_batches DROP CONSTRAINT IF EXISTS voucher_batches_cohort_id_fkey;
+ALTER TABLE public.voucher_batches ADD CONSTRAINT voucher_batches_cohort_id_fkey
+    FOREIGN KEY (cohort_id) REFERENCES public.cohorts(id) ON DELETE SET NULL;
+
+-- потоки курсов пакета, выбранные при оформлении заказа
+ALTER TABLE public.orders ADD COLUMN IF NOT EXISTS cohort_ids integer[];
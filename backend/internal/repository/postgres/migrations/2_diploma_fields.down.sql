@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.diploma_fields CASCADE;
+DROP SEQUENCE IF EXISTS public.diploma_fields_id_seq;
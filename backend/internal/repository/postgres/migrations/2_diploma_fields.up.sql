@@ -0,0 +1,28 @@
+-- ======================
+-- Таблица полей диплома
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.diploma_fields_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.diploma_fields (
+    id integer NOT NULL DEFAULT nextval('public.diploma_fields_id_seq'::regclass),
+    course_id integer NOT NULL,
+    kind character varying(50) NOT NULL, -- name, course_title, issue_date, certificate_number, text
+    text text NOT NULL DEFAULT '',
+    x integer NOT NULL,
+    y integer NOT NULL,
+    font_size real NOT NULL DEFAULT 32,
+    color character varying(7) NOT NULL DEFAULT '#000000',
+    align character varying(10) NOT NULL DEFAULT 'left',
+    CONSTRAINT diploma_fields_pkey PRIMARY KEY (id),
+    CONSTRAINT diploma_fields_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.diploma_fields_id_seq OWNED BY public.diploma_fields.id;
+
+CREATE INDEX IF NOT EXISTS idx_diploma_fields_course_id ON public.diploma_fields(course_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.certificates CASCADE;
+DROP SEQUENCE IF EXISTS public.certificates_id_seq;
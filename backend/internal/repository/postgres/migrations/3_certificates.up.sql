@@ -0,0 +1,30 @@
+-- ======================
+-- Реестр выданных сертификатов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.certificates_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+-- holder_name и course_title сохраняются на момент выдачи, чтобы проверка не зависела от последующих изменений
+CREATE TABLE IF NOT EXISTS public.certificates (
+    id integer NOT NULL DEFAULT nextval('public.certificates_id_seq'::regclass),
+    code character varying(32) NOT NULL,
+    user_id integer,
+    course_id integer,
+    holder_name text NOT NULL,
+    course_title text NOT NULL,
+    issued_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    revoked_at timestamp without time zone,
+    revoke_reason text,
+    CONSTRAINT certificates_pkey PRIMARY KEY (id),
+    CONSTRAINT certificates_code_key UNIQUE (code),
+    CONSTRAINT certificates_user_course_key UNIQUE (user_id, course_id),
+    CONSTRAINT certificates_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE SET NULL,
+    CONSTRAINT certificates_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE SET NULL
+);
+
+ALTER SEQUENCE public.certificates_id_seq OWNED BY public.certificates.id;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS public.video_progress CASCADE;
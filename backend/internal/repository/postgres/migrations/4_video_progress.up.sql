@@ -0,0 +1,17 @@
+-- ======================
+-- Прогресс просмотра видео
+-- ======================
+CREATE TABLE IF NOT EXISTS public.video_progress (
+    user_id integer NOT NULL,
+    video_id integer NOT NULL,
+    last_position integer NOT NULL DEFAULT 0,   -- секунда, на которой пользователь остановился
+    watched_seconds integer NOT NULL DEFAULT 0, -- сколько секунд реально просмотрено
+    duration integer NOT NULL DEFAULT 0,        -- длительность видео по данным плеера
+    completed boolean NOT NULL DEFAULT false,
+    updated_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT video_progress_pkey PRIMARY KEY (user_id, video_id),
+    CONSTRAINT video_progress_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE,
+    CONSTRAINT video_progress_video_id_fkey FOREIGN KEY (video_id) REFERENCES public.videos(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_video_progress_video_id ON public.video_progress(video_id);
@@ -0,0 +1,18 @@
+-- ======================
+-- Сессии возобновляемой загрузки (tus)
+-- ======================
+CREATE TABLE IF NOT EXISTS public.upload_sessions (
+    id character varying(64) NOT NULL,
+    course_id integer NOT NULL,
+    title text NOT NULL,
+    filename text NOT NULL,
+    size bigint NOT NULL,
+    upload_offset bigint NOT NULL DEFAULT 0,
+    status character varying(20) NOT NULL DEFAULT 'uploading', -- uploading, completed
+    video_path text,
+    created_by integer,
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    updated_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT upload_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT upload_sessions_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS public.lesson_attachments CASCADE;
+DROP SEQUENCE IF EXISTS public.lesson_attachments_id_seq;
+
+ALTER TABLE public.videos ALTER COLUMN path DROP DEFAULT;
+ALTER TABLE public.videos
+    DROP CONSTRAINT IF EXISTS videos_module_id_fkey,
+    DROP COLUMN IF EXISTS module_id,
+    DROP COLUMN IF EXISTS position,
+    DROP COLUMN IF EXISTS content;
+
+DROP TABLE IF EXISTS public.course_modules CASCADE;
+DROP SEQUENCE IF EXISTS public.course_modules_id_seq;
@@ -0,0 +1,74 @@
+-- ======================
+-- Модули курса
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.course_modules_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.course_modules (
+    id integer NOT NULL DEFAULT nextval('public.course_modules_id_seq'::regclass),
+    course_id integer NOT NULL,
+    title text NOT NULL,
+    position integer NOT NULL DEFAULT 0,
+    CONSTRAINT course_modules_pkey PRIMARY KEY (id),
+    CONSTRAINT course_modules_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.course_modules_id_seq OWNED BY public.course_modules.id;
+
+CREATE INDEX IF NOT EXISTS idx_course_modules_course_id ON public.course_modules(course_id);
+
+-- ======================
+-- Уроки: видео становятся уроками модуля
+-- ======================
+ALTER TABLE public.videos
+    ADD COLUMN IF NOT EXISTS module_id integer,
+    ADD COLUMN IF NOT EXISTS position integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS content text NOT NULL DEFAULT '', -- текст урока
+    ADD CONSTRAINT videos_module_id_fkey FOREIGN KEY (module_id) REFERENCES public.course_modules(id);
+
+-- урок может быть только текстовым, тогда path пустой
+ALTER TABLE public.videos ALTER COLUMN path SET DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_videos_module_id ON public.videos(module_id);
+
+-- каждый существующий курс получает один модуль со всеми видео в порядке добавления
+INSERT INTO public.course_modules (course_id, title, position)
+SELECT c.id, 'Модуль 1', 1
+FROM public.courses c;
+
+UPDATE public.videos v
+SET module_id = m.id,
+    position = o.position
+FROM public.course_modules m,
+     (SELECT id, row_number() OVER (PARTITION BY course_id ORDER BY id) AS position FROM public.videos) o
+WHERE m.course_id = v.course_id AND o.id = v.id;
+
+-- ======================
+-- Материалы урока
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.lesson_attachments_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.lesson_attachments (
+    id integer NOT NULL DEFAULT nextval('public.lesson_attachments_id_seq'::regclass),
+    video_id integer NOT NULL,
+    title text NOT NULL,
+    path text NOT NULL,
+    position integer NOT NULL DEFAULT 0,
+    CONSTRAINT lesson_attachments_pkey PRIMARY KEY (id),
+    CONSTRAINT lesson_attachments_video_id_fkey FOREIGN KEY (video_id) REFERENCES public.videos(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.lesson_attachments_id_seq OWNED BY public.lesson_attachments.id;
+
+CREATE INDEX IF NOT EXISTS idx_lesson_attachments_video_id ON public.lesson_attachments(video_id);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS public.idx_webinars_series_id;
+DROP INDEX IF EXISTS public.idx_webinars_date;
+
+ALTER TABLE public.webinars
+    DROP COLUMN IF EXISTS series_id,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS duration,
+    DROP COLUMN IF EXISTS title;
@@ -0,0 +1,11 @@
+-- ======================
+-- Расписание вебинаров
+-- ======================
+ALTER TABLE public.webinars
+    ADD COLUMN IF NOT EXISTS title text NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS duration integer NOT NULL DEFAULT 60,                     -- минуты
+    ADD COLUMN IF NOT EXISTS status character varying(20) NOT NULL DEFAULT 'scheduled', -- scheduled, cancelled
+    ADD COLUMN IF NOT EXISTS series_id character varying(32);                           -- общий для повторяющихся вебинаров
+
+CREATE INDEX IF NOT EXISTS idx_webinars_date ON public.webinars(date);
+CREATE INDEX IF NOT EXISTS idx_webinars_series_id ON public.webinars(series_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.idx_users_calendar_token;
+ALTER TABLE public.users DROP COLUMN IF EXISTS calendar_token;
+
+DROP TABLE IF EXISTS public.webinar_reminders_sent CASCADE;
@@ -0,0 +1,19 @@
+-- ======================
+-- Отправленные напоминания о вебинарах
+-- ======================
+CREATE TABLE IF NOT EXISTS public.webinar_reminders_sent (
+    webinar_id integer NOT NULL,
+    user_id integer NOT NULL,
+    offset_minutes integer NOT NULL, -- за сколько минут до начала
+    sent_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT webinar_reminders_sent_pkey PRIMARY KEY (webinar_id, user_id, offset_minutes),
+    CONSTRAINT webinar_reminders_sent_webinar_id_fkey FOREIGN KEY (webinar_id) REFERENCES public.webinars(id) ON DELETE CASCADE,
+    CONSTRAINT webinar_reminders_sent_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+-- ======================
+-- Секретная ссылка на календарь пользователя
+-- ======================
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS calendar_token character varying(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_calendar_token ON public.users(calendar_token);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS public.quiz_attempts CASCADE;
+DROP SEQUENCE IF EXISTS public.quiz_attempts_id_seq;
+DROP TABLE IF EXISTS public.quiz_options CASCADE;
+DROP SEQUENCE IF EXISTS public.quiz_options_id_seq;
+DROP TABLE IF EXISTS public.quiz_questions CASCADE;
+DROP SEQUENCE IF EXISTS public.quiz_questions_id_seq;
+DROP TABLE IF EXISTS public.quizzes CASCADE;
+DROP SEQUENCE IF EXISTS public.quizzes_id_seq;
@@ -0,0 +1,108 @@
+-- ======================
+-- Тесты
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.quizzes_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.quizzes (
+    id integer NOT NULL DEFAULT nextval('public.quizzes_id_seq'::regclass),
+    course_id integer NOT NULL,
+    video_id integer,                           -- урок, к которому привязан тест; NULL - тест всего курса
+    title text NOT NULL,
+    pass_score integer NOT NULL DEFAULT 0,      -- минимальный процент; 0 - тест не обязателен
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT quizzes_pkey PRIMARY KEY (id),
+    CONSTRAINT quizzes_course_id_fkey FOREIGN KEY (course_id) REFERENCES public.courses(id) ON DELETE CASCADE,
+    CONSTRAINT quizzes_video_id_fkey FOREIGN KEY (video_id) REFERENCES public.videos(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.quizzes_id_seq OWNED BY public.quizzes.id;
+
+CREATE INDEX IF NOT EXISTS idx_quizzes_course_id ON public.quizzes(course_id);
+
+-- ======================
+-- Вопросы тестов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.quiz_questions_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.quiz_questions (
+    id integer NOT NULL DEFAULT nextval('public.quiz_questions_id_seq'::regclass),
+    quiz_id integer NOT NULL,
+    kind character varying(20) NOT NULL,         -- single, multiple, text
+    text text NOT NULL,
+    position integer NOT NULL DEFAULT 0,
+    points integer NOT NULL DEFAULT 1,
+    accepted_answers text[] NOT NULL DEFAULT '{}', -- верные ответы для вопросов со свободным ответом
+    CONSTRAINT quiz_questions_pkey PRIMARY KEY (id),
+    CONSTRAINT quiz_questions_quiz_id_fkey FOREIGN KEY (quiz_id) REFERENCES public.quizzes(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.quiz_questions_id_seq OWNED BY public.quiz_questions.id;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_questions_quiz_id ON public.quiz_questions(quiz_id);
+
+-- ======================
+-- Варианты ответов
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.quiz_options_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.quiz_options (
+    id integer NOT NULL DEFAULT nextval('public.quiz_options_id_seq'::regclass),
+    question_id integer NOT NULL,
+    text text NOT NULL,
+    is_correct boolean NOT NULL DEFAULT false,
+    position integer NOT NULL DEFAULT 0,
+    CONSTRAINT quiz_options_pkey PRIMARY KEY (id),
+    CONSTRAINT quiz_options_question_id_fkey FOREIGN KEY (question_id) REFERENCES public.quiz_questions(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.quiz_options_id_seq OWNED BY public.quiz_options.id;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_options_question_id ON public.quiz_options(question_id);
+
+-- ======================
+-- Попытки прохождения
+-- ======================
+CREATE SEQUENCE IF NOT EXISTS public.quiz_attempts_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+CREATE TABLE IF NOT EXISTS public.quiz_attempts (
+    id integer NOT NULL DEFAULT nextval('public.quiz_attempts_id_seq'::regclass),
+    quiz_id integer NOT NULL,
+    user_id integer NOT NULL,
+    score integer NOT NULL,
+    max_score integer NOT NULL,
+    percent integer NOT NULL,
+    passed boolean NOT NULL,
+    answers jsonb NOT NULL DEFAULT '[]',
+    created_at timestamp without time zone NOT NULL DEFAULT (now() AT TIME ZONE 'utc'),
+    CONSTRAINT quiz_attempts_pkey PRIMARY KEY (id),
+    CONSTRAINT quiz_attempts_quiz_id_fkey FOREIGN KEY (quiz_id) REFERENCES public.quizzes(id) ON DELETE CASCADE,
+    CONSTRAINT quiz_attempts_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE
+);
+
+ALTER SEQUENCE public.quiz_attempts_id_seq OWNED BY public.quiz_attempts.id;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_attempts_quiz_user ON public.quiz_attempts(quiz_id, user_id);
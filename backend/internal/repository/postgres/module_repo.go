@@ -0,0 +1,366 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrModuleNotFound     = errors.New("module not found")
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrInvalidOrder       = errors.New("order must list every item exactly once")
+)
+
+// ModuleRepo stores course modules, their lessons (videos table) and lesson attachments
+type ModuleRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewModuleRepo(log *slog.Logger, db *sql.DB) *ModuleRepo {
+	return &ModuleRepo{log: log, db: db}
+}
+
+// InsertModule appends a module to the end of the course
+func (r *ModuleRepo) InsertModule(courseID int, title string) (structures.Module, error) {
+	const op = "postgres.module_repo.InsertModule"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO course_modules (course_id, title, position)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position), 0) + 1 FROM course_modules WHERE course_id = $1))
+		RETURNING id, course_id, title, position
+	`
+
+	var m structures.Module
+	err := r.db.QueryRow(query, courseID, title).Scan(&m.Id, &m.CourseID, &m.Title, &m.Position)
+	if err != nil {
+		log.Error("failed to insert module", sl.Err(err))
+		return m, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("module created", slog.Int("id", m.Id), slog.Int("course_id", courseID))
+	return m, nil
+}
+
+func (r *ModuleRepo) SelectModuleById(id int) (structures.Module, error) {
+	const op = "postgres.module_repo.SelectModuleById"
+	log := r.log.With("op", op)
+
+	var m structures.Module
+	err := r.db.QueryRow(`
+		SELECT id, course_id, title, position
+		FROM course_modules
+		WHERE id = $1
+	`, id).Scan(&m.Id, &m.CourseID, &m.Title, &m.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return m, ErrModuleNotFound
+		}
+		log.Error("failed to select module", sl.Err(err))
+		return m, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return m, nil
+}
+
+func (r *ModuleRepo) UpdateModuleTitle(id int, title string) error {
+	const op = "postgres.module_repo.UpdateModuleTitle"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`UPDATE course_modules SET title = $1 WHERE id = $2`, title, id)
+	if err != nil {
+		log.Error("failed to update module", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrModuleNotFound
+	}
+
+	log.Info("module renamed", slog.Int("id", id))
+	return nil
+}
+
+func (r *ModuleRepo) CountLessons(moduleID int) (int, error) {
+	const op = "postgres.module_repo.CountLessons"
+
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM videos WHERE module_id = $1`, moduleID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+func (r *ModuleRepo) DeleteModule(id int) error {
+	const op = "postgres.module_repo.DeleteModule"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM course_modules WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete module", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrModuleNotFound
+	}
+
+	log.Info("module deleted", slog.Int("id", id))
+	return nil
+}
+
+// ReorderModules sets module positions of the course to the order of ids. Every module of the course has to be listed.
+func (r *ModuleRepo) ReorderModules(courseID int, ids []int) error {
+	const op = "postgres.module_repo.ReorderModules"
+	log := r.log.With("op", op)
+
+	if hasDuplicates(ids) {
+		return ErrInvalidOrder
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var total int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM course_modules WHERE course_id = $1`, courseID).Scan(&total); err != nil {
+		log.Error("failed to count modules", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if total != len(ids) {
+		return ErrInvalidOrder
+	}
+
+	for i, id := range ids {
+		result, err := tx.Exec(`UPDATE course_modules SET position = $1 WHERE id = $2 AND course_id = $3`, i+1, id, courseID)
+		if err != nil {
+			log.Error("failed to update module position", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return ErrInvalidOrder
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("modules reordered", slog.Int("course_id", courseID))
+	return nil
+}
+
+// hasDuplicates reports whether an id is listed more than once, a count check alone lets [a, a] through
+func hasDuplicates(ids []int) bool {
+	seen := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
+}
+
+// InsertLesson appends a lesson to the end of the module and returns its ID
+func (r *ModuleRepo) InsertLesson(moduleID int, lesson structures.Video) (int, error) {
+	const op = "postgres.module_repo.InsertLesson"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO videos (course_id, module_id, position, path, title, content, file, duration)
+		SELECT m.course_id, m.id, COALESCE((SELECT MAX(position) FROM videos WHERE module_id = m.id), 0) + 1, $2, $3, $4, $5, $6
+		FROM course_modules m
+		WHERE m.id = $1
+		RETURNING id
+	`
+
+	var id int
+	err := r.db.QueryRow(query, moduleID, lesson.Path, lesson.Title, lesson.Content, lesson.File, lesson.Duration).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrModuleNotFound
+		}
+		log.Error("failed to insert lesson", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("lesson created", slog.Int("id", id), slog.Int("module_id", moduleID))
+	return id, nil
+}
+
+func (r *ModuleRepo) UpdateLesson(id int, title, content string) error {
+	const op = "postgres.module_repo.UpdateLesson"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`UPDATE videos SET title = $1, content = $2 WHERE id = $3`, title, content, id)
+	if err != nil {
+		log.Error("failed to update lesson", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrVideoNotFound
+	}
+
+	log.Info("lesson updated", slog.Int("id", id))
+	return nil
+}
+
+// UpdateUnlock sets the drip schedule of the lesson, nil values remove the rule
+func (r *ModuleRepo) UpdateUnlock(id int, afterDays *int, at *time.Time) error {
+	const op = "postgres.module_repo.UpdateUnlock"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`UPDATE videos SET unlock_after_days = $1, unlock_at = $2 WHERE id = $3`, afterDays, at, id)
+	if err != nil {
+		log.Error("failed to update lesson unlock", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrVideoNotFound
+	}
+
+	log.Info("lesson unlock updated", slog.Int("id", id))
+	return nil
+}
+
+// ReorderLessons puts the listed lessons into the module in the given order.
+// Lessons may come from other modules of the same course, which moves them.
+func (r *ModuleRepo) ReorderLessons(moduleID int, ids []int) error {
+	const op = "postgres.module_repo.ReorderLessons"
+	log := r.log.With("op", op)
+
+	if hasDuplicates(ids) {
+		return ErrInvalidOrder
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var courseID int
+	err = tx.QueryRow(`SELECT course_id FROM course_modules WHERE id = $1`, moduleID).Scan(&courseID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrModuleNotFound
+		}
+		log.Error("failed to select module", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// lessons left out of the list would end up mixed with the reordered ones
+	var missing int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM videos WHERE module_id = $1 AND NOT (id = ANY($2))`, moduleID, intArray(ids)).Scan(&missing)
+	if err != nil {
+		log.Error("failed to check lessons", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if missing > 0 {
+		return ErrInvalidOrder
+	}
+
+	for i, id := range ids {
+		result, err := tx.Exec(`
+			UPDATE videos SET module_id = $1, position = $2
+			WHERE id = $3 AND course_id = $4
+		`, moduleID, i+1, id, courseID)
+		if err != nil {
+			log.Error("failed to update lesson position", sl.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return ErrInvalidOrder
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("lessons reordered", slog.Int("module_id", moduleID))
+	return nil
+}
+
+// InsertAttachment appends an attachment to the lesson
+func (r *ModuleRepo) InsertAttachment(videoID int, title, path string) (structures.LessonAttachment, error) {
+	const op = "postgres.module_repo.InsertAttachment"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO lesson_attachments (video_id, title, path, position)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position), 0) + 1 FROM lesson_attachments WHERE video_id = $1))
+		RETURNING id, video_id, title, path, position
+	`
+
+	var a structures.LessonAttachment
+	err := r.db.QueryRow(query, videoID, title, path).Scan(&a.Id, &a.VideoID, &a.Title, &a.Path, &a.Position)
+	if err != nil {
+		log.Error("failed to insert attachment", sl.Err(err))
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("attachment added", slog.Int("id", a.Id), slog.Int("video_id", videoID))
+	return a, nil
+}
+
+func (r *ModuleRepo) SelectAttachmentById(id int) (structures.LessonAttachment, error) {
+	const op = "postgres.module_repo.SelectAttachmentById"
+	log := r.log.With("op", op)
+
+	var a structures.LessonAttachment
+	err := r.db.QueryRow(`
+		SELECT id, video_id, title, path, position
+		FROM lesson_attachments
+		WHERE id = $1
+	`, id).Scan(&a.Id, &a.VideoID, &a.Title, &a.Path, &a.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return a, ErrAttachmentNotFound
+		}
+		log.Error("failed to select attachment", sl.Err(err))
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return a, nil
+}
+
+func (r *ModuleRepo) DeleteAttachment(id int) error {
+	const op = "postgres.module_repo.DeleteAttachment"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM lesson_attachments WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete attachment", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	log.Info("attachment deleted", slog.Int("id", id))
+	return nil
+}
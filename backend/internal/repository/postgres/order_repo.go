@@ -0,0 +1,265 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+type OrderRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewOrderRepo(log *slog.Logger, db *sql.DB) *OrderRepo {
+	return &OrderRepo{log: log, db: db}
+}
+
+const orderSelect = `
+	SELECT o.id, o.user_id, u.username, COALESCE(o.course_id, 0), COALESCE(c.title, ''),
+		COALESCE(o.plan_id, 0), COALESCE(pl.title, ''), COALESCE(o.bundle_id, 0), COALESCE(b.title, ''),
+		COALESCE(o.cohort_id, 0), COALESCE(co.title, ''), COALESCE(o.cohort_ids, '{}'),
+		o.amount, o.discount, COALESCE(o.promo_code_id, 0), COALESCE(p.code, ''),
+		o.currency, o.status, o.provider, COALESCE(o.payment_id, ''), o.checkout_url,
+		o.created_at, o.updated_at, o.paid_at, o.refunded_at
+	FROM orders o
+	JOIN users u ON u.id = o.user_id
+	LEFT JOIN courses c ON c.id = o.course_id
+	LEFT JOIN course_plans pl ON pl.id = o.plan_id
+	LEFT JOIN bundles b ON b.id = o.bundle_id
+	LEFT JOIN cohorts co ON co.id = o.cohort_id
+	LEFT JOIN promo_codes p ON p.id = o.promo_code_id`
+
+func scanOrder(row interface{ Scan(...any) error }) (structures.Order, error) {
+	var o structures.Order
+	var paidAt, refundedAt sql.NullTime
+	var cohortIDs pq.Int64Array
+	err := row.Scan(&o.Id, &o.UserID, &o.Username, &o.CourseID, &o.CourseTitle,
+		&o.PlanID, &o.PlanTitle, &o.BundleID, &o.BundleTitle, &o.CohortID, &o.CohortTitle, &cohortIDs,
+		&o.Amount, &o.Discount, &o.PromoCodeID, &o.PromoCode,
+		&o.Currency, &o.Status, &o.Provider, &o.PaymentID, &o.CheckoutURL,
+		&o.CreatedAt, &o.UpdatedAt, &paidAt, &refundedAt)
+	if paidAt.Valid {
+		o.PaidAt = &paidAt.Time
+	}
+	if refundedAt.Valid {
+		o.RefundedAt = &refundedAt.Time
+	}
+	for _, id := range cohortIDs {
+		o.CohortIDs = append(o.CohortIDs, int(id))
+	}
+	return o, err
+}
+
+// InsertOrder creates a pending order. An order with a promo code reserves a use of it: the code is locked
+// and its limits are checked again, so concurrent checkouts can't take more uses than it allows.
+func (r *OrderRepo) InsertOrder(o structures.Order) (int, error) {
+	const op = "postgres.order_repo.InsertOrder"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	if o.PromoCodeID != 0 {
+		var full bool
+		err := tx.QueryRow(`
+			WITH p AS (
+				SELECT id, max_uses, max_uses_per_user FROM promo_codes WHERE id = $1 FOR UPDATE
+			)
+			SELECT (p.max_uses > 0 AND COUNT(o.id) >= p.max_uses)
+				OR (p.max_uses_per_user > 0 AND COUNT(o.id) FILTER (WHERE o.user_id = $2) >= p.max_uses_per_user)
+			FROM p
+			LEFT JOIN orders o ON o.promo_code_id = p.id AND `+promoUse+`
+			GROUP BY p.id, p.max_uses, p.max_uses_per_user
+		`, o.PromoCodeID, o.UserID).Scan(&full)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrPromoNotFound
+		}
+		if err != nil {
+			log.Error("failed to reserve promo code", sl.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if full {
+			return 0, ErrPromoLimitReached
+		}
+	}
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO orders (user_id, course_id, plan_id, bundle_id, cohort_id, cohort_ids, amount, discount, promo_code_id, currency, status, provider)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, 0), NULLIF($6::integer[], '{}'), $7, $8, NULLIF($9, 0), $10, $11, $12)
+		RETURNING id
+	`, o.UserID, o.CourseID, o.PlanID, o.BundleID, o.CohortID, intArray(o.CohortIDs), o.Amount, o.Discount, o.PromoCodeID, o.Currency, structures.OrderPending, o.Provider).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert order", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("order created", slog.Int("id", id), slog.Int("user_id", o.UserID), slog.Int("course_id", o.CourseID),
+		slog.Int("plan_id", o.PlanID), slog.Int("bundle_id", o.BundleID))
+	return id, nil
+}
+
+// SetPayment links the order to the payment created at the provider
+func (r *OrderRepo) SetPayment(id int, paymentID, checkoutURL string) error {
+	const op = "postgres.order_repo.SetPayment"
+	log := r.log.With("op", op)
+
+	_, err := r.db.Exec(`
+		UPDATE orders SET payment_id = $1, checkout_url = $2, updated_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $3
+	`, paymentID, checkoutURL, id)
+	if err != nil {
+		log.Error("failed to set payment", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateStatus moves the order to status only if it is currently in one of from.
+// It reports false when the order is in another status, so repeated webhooks are no-ops.
+func (r *OrderRepo) UpdateStatus(id int, status string, from []string) (bool, error) {
+	const op = "postgres.order_repo.UpdateStatus"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE orders SET
+			status = $1,
+			updated_at = (now() AT TIME ZONE 'utc'),
+			paid_at = CASE WHEN $1 = 'paid' THEN (now() AT TIME ZONE 'utc') ELSE paid_at END,
+			refunded_at = CASE WHEN $1 = 'refunded' THEN (now() AT TIME ZONE 'utc') ELSE refunded_at END
+		WHERE id = $2 AND status = ANY($3)
+	`, status, id, pq.Array(from))
+	if err != nil {
+		log.Error("failed to update order status", sl.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected > 0 {
+		log.Info("order status changed", slog.Int("id", id), slog.String("status", status))
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *OrderRepo) SelectOrderById(id int) (structures.Order, error) {
+	const op = "postgres.order_repo.SelectOrderById"
+	log := r.log.With("op", op)
+
+	o, err := scanOrder(r.db.QueryRow(orderSelect+` WHERE o.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return o, ErrOrderNotFound
+		}
+		log.Error("failed to select order", sl.Err(err))
+		return o, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return o, nil
+}
+
+func (r *OrderRepo) SelectOrderByPayment(provider, paymentID string) (structures.Order, error) {
+	const op = "postgres.order_repo.SelectOrderByPayment"
+	log := r.log.With("op", op)
+
+	o, err := scanOrder(r.db.QueryRow(orderSelect+` WHERE o.provider = $1 AND o.payment_id = $2`, provider, paymentID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return o, ErrOrderNotFound
+		}
+		log.Error("failed to select order", sl.Err(err))
+		return o, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return o, nil
+}
+
+// SelectPendingOrder returns the unpaid order of the user for the same course, plan, cohort or bundle, so checkout can be resumed
+func (r *OrderRepo) SelectPendingOrder(userID int, item structures.CheckoutRequest) (structures.Order, error) {
+	const op = "postgres.order_repo.SelectPendingOrder"
+	log := r.log.With("op", op)
+
+	o, err := scanOrder(r.db.QueryRow(orderSelect+`
+		WHERE o.user_id = $1
+			AND COALESCE(o.course_id, 0) = $2 AND COALESCE(o.plan_id, 0) = $3 AND COALESCE(o.bundle_id, 0) = $4
+			AND COALESCE(o.cohort_id, 0) = $5 AND COALESCE(o.cohort_ids, '{}') = $6::integer[]
+			AND o.status = $7 AND o.payment_id IS NOT NULL
+		ORDER BY o.id DESC
+		LIMIT 1
+	`, userID, item.CourseID, item.PlanID, item.BundleID, item.CohortID, intArray(item.CohortIDs), structures.OrderPending))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return o, ErrOrderNotFound
+		}
+		log.Error("failed to select pending order", sl.Err(err))
+		return o, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return o, nil
+}
+
+// SelectOrders returns orders matching the filter, newest first
+func (r *OrderRepo) SelectOrders(f structures.OrderFilter) ([]structures.Order, error) {
+	const op = "postgres.order_repo.SelectOrders"
+	log := r.log.With("op", op)
+
+	var where []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.UserID != 0 {
+		add("o.user_id = $%d", f.UserID)
+	}
+	if f.CourseID != 0 {
+		add("o.course_id = $%d", f.CourseID)
+	}
+	if f.Status != "" {
+		add("o.status = $%d", f.Status)
+	}
+
+	query := orderSelect
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY o.created_at DESC, o.id DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Error("failed to select orders", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	orders := []structures.Order{}
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			log.Error("failed to scan order", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		orders = append(orders, o)
+	}
+
+	return orders, rows.Err()
+}
@@ -0,0 +1,245 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var ErrPathNotFound = errors.New("learning path not found")
+
+type PathRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewPathRepo(log *slog.Logger, db *sql.DB) *PathRepo {
+	return &PathRepo{log: log, db: db}
+}
+
+// InsertPath creates the path together with its courses
+func (r *PathRepo) InsertPath(p structures.LearningPath) (int, error) {
+	const op = "postgres.path_repo.InsertPath"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO learning_paths (title, description, active)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, p.Title, p.Description, p.Active).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert learning path", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertPathCourses(tx, id, p.Courses); err != nil {
+		log.Error("failed to insert path courses", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("learning path created", slog.Int("id", id), slog.Int("courses", len(p.Courses)))
+	return id, nil
+}
+
+// UpdatePath saves the path and replaces its courses
+func (r *PathRepo) UpdatePath(p structures.LearningPath) error {
+	const op = "postgres.path_repo.UpdatePath"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE learning_paths SET title = $1, description = $2, active = $3
+		WHERE id = $4
+	`, p.Title, p.Description, p.Active, p.Id)
+	if err != nil {
+		log.Error("failed to update learning path", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPathNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM learning_path_courses WHERE path_id = $1`, p.Id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := insertPathCourses(tx, p.Id, p.Courses); err != nil {
+		log.Error("failed to insert path courses", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func insertPathCourses(tx *sql.Tx, pathID int, courses []structures.PathCourse) error {
+	for i, c := range courses {
+		_, err := tx.Exec(`
+			INSERT INTO learning_path_courses (path_id, course_id, prerequisite_id, position)
+			VALUES ($1, $2, NULLIF($3, 0), $4)
+		`, pathID, c.CourseID, c.PrerequisiteID, i)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PathRepo) DeletePath(id int) error {
+	const op = "postgres.path_repo.DeletePath"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM learning_paths WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete learning path", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPathNotFound
+	}
+
+	log.Info("learning path deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *PathRepo) SelectPath(id int) (structures.LearningPath, error) {
+	const op = "postgres.path_repo.SelectPath"
+
+	paths, err := r.selectPaths(`WHERE p.id = $1`, id)
+	if err != nil {
+		return structures.LearningPath{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(paths) == 0 {
+		return structures.LearningPath{}, ErrPathNotFound
+	}
+
+	return paths[0], nil
+}
+
+// SelectPaths returns paths oldest first, activeOnly leaves the ones shown to users
+func (r *PathRepo) SelectPaths(activeOnly bool) ([]structures.LearningPath, error) {
+	return r.selectPaths(`WHERE p.active OR NOT $1`, activeOnly)
+}
+
+func (r *PathRepo) selectPaths(where string, args ...any) ([]structures.LearningPath, error) {
+	const op = "postgres.path_repo.selectPaths"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT p.id, p.title, p.description, p.active, p.created_at
+		FROM learning_paths p
+		`+where+`
+		ORDER BY p.created_at, p.id
+	`, args...)
+	if err != nil {
+		log.Error("failed to select learning paths", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	paths := []structures.LearningPath{}
+	pathIdx := make(map[int]int)
+	var ids []int
+	for rows.Next() {
+		var p structures.LearningPath
+		if err := rows.Scan(&p.Id, &p.Title, &p.Description, &p.Active, &p.CreatedAt); err != nil {
+			log.Error("failed to scan learning path", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		p.Courses = []structures.PathCourse{}
+		pathIdx[p.Id] = len(paths)
+		paths = append(paths, p)
+		ids = append(ids, p.Id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(ids) == 0 {
+		return paths, nil
+	}
+
+	courseRows, err := r.db.Query(`
+		SELECT pc.path_id, pc.course_id, c.title, COALESCE(pc.prerequisite_id, 0), COALESCE(pr.title, '')
+		FROM learning_path_courses pc
+		JOIN courses c ON c.id = pc.course_id
+		LEFT JOIN courses pr ON pr.id = pc.prerequisite_id
+		WHERE pc.path_id = ANY($1)
+		ORDER BY pc.path_id, pc.position
+	`, intArray(ids))
+	if err != nil {
+		log.Error("failed to select path courses", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer courseRows.Close()
+
+	for courseRows.Next() {
+		var pathID int
+		var c structures.PathCourse
+		if err := courseRows.Scan(&pathID, &c.CourseID, &c.CourseTitle, &c.PrerequisiteID, &c.PrerequisiteTitle); err != nil {
+			log.Error("failed to scan path course", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if i, ok := pathIdx[pathID]; ok {
+			paths[i].Courses = append(paths[i].Courses, c)
+		}
+	}
+
+	return paths, courseRows.Err()
+}
+
+// SelectPrerequisites returns the courses that have to be completed before the course opens,
+// collected over all active paths
+func (r *PathRepo) SelectPrerequisites(courseID int) ([]int, error) {
+	const op = "postgres.path_repo.SelectPrerequisites"
+
+	rows, err := r.db.Query(`
+		SELECT DISTINCT pc.prerequisite_id
+		FROM learning_path_courses pc
+		JOIN learning_paths p ON p.id = pc.path_id
+		WHERE pc.course_id = $1 AND pc.prerequisite_id IS NOT NULL AND p.active
+	`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
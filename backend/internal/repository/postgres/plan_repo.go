@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPlanNotFound = errors.New("plan not found")
+	ErrPlanInUse    = errors.New("plan is held by users, deactivate it instead")
+)
+
+type PlanRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewPlanRepo(log *slog.Logger, db *sql.DB) *PlanRepo {
+	return &PlanRepo{log: log, db: db}
+}
+
+const planSelect = `
+	SELECT p.id, p.course_id, p.title, p.description, p.cost, p.features, p.position, p.active, p.created_at
+	FROM course_plans p`
+
+func scanPlan(row interface{ Scan(...any) error }) (structures.CoursePlan, error) {
+	var p structures.CoursePlan
+	err := row.Scan(&p.Id, &p.CourseID, &p.Title, &p.Description, &p.Cost, pq.Array(&p.Features), &p.Position, &p.Active, &p.CreatedAt)
+	if p.Features == nil {
+		p.Features = []string{}
+	}
+	return p, err
+}
+
+func (r *PlanRepo) InsertPlan(p structures.CoursePlan) (int, error) {
+	const op = "postgres.plan_repo.InsertPlan"
+	log := r.log.With("op", op)
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO course_plans (course_id, title, description, cost, features, position, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, p.CourseID, p.Title, p.Description, p.Cost, pq.Array(p.Features), p.Position, p.Active).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert plan", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("plan created", slog.Int("id", id), slog.Int("course_id", p.CourseID))
+	return id, nil
+}
+
+func (r *PlanRepo) UpdatePlan(p structures.CoursePlan) error {
+	const op = "postgres.plan_repo.UpdatePlan"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE course_plans
+		SET title = $1, description = $2, cost = $3, features = $4, position = $5, active = $6
+		WHERE id = $7
+	`, p.Title, p.Description, p.Cost, pq.Array(p.Features), p.Position, p.Active, p.Id)
+	if err != nil {
+		log.Error("failed to update plan", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPlanNotFound
+	}
+
+	return nil
+}
+
+// DeletePlan removes a plan nobody holds. Plans with enrollments or orders fail with ErrPlanInUse,
+// so that access bought on a plan never silently turns into full access.
+func (r *PlanRepo) DeletePlan(id int) error {
+	const op = "postgres.plan_repo.DeletePlan"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		DELETE FROM course_plans p
+		WHERE p.id = $1
+			AND NOT EXISTS (SELECT 1 FROM enrollments e WHERE e.plan_id = p.id)
+			AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.plan_id = p.id)
+	`, id)
+	if err != nil {
+		log.Error("failed to delete plan", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		if _, err := r.SelectPlan(id); err != nil {
+			return err
+		}
+		return ErrPlanInUse
+	}
+
+	log.Info("plan deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *PlanRepo) SelectPlan(id int) (structures.CoursePlan, error) {
+	const op = "postgres.plan_repo.SelectPlan"
+
+	p, err := scanPlan(r.db.QueryRow(planSelect+` WHERE p.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, ErrPlanNotFound
+		}
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// SelectPlans returns the plans of the course in display order, activeOnly leaves the ones on sale
+func (r *PlanRepo) SelectPlans(courseID int, activeOnly bool) ([]structures.CoursePlan, error) {
+	const op = "postgres.plan_repo.SelectPlans"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(planSelect+`
+		WHERE p.course_id = $1 AND (p.active OR NOT $2)
+		ORDER BY p.position, p.id
+	`, courseID, activeOnly)
+	if err != nil {
+		log.Error("failed to select plans", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	plans := []structures.CoursePlan{}
+	for rows.Next() {
+		p, err := scanPlan(rows)
+		if err != nil {
+			log.Error("failed to scan plan", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		plans = append(plans, p)
+	}
+
+	return plans, rows.Err()
+}
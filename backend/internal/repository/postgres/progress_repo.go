@@ -0,0 +1,190 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+type ProgressRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewProgressRepo(log *slog.Logger, db *sql.DB) *ProgressRepo {
+	return &ProgressRepo{log: log, db: db}
+}
+
+// SelectVideoProgress returns the user's progress on a video, or a zero value if the video was never played
+func (r *ProgressRepo) SelectVideoProgress(userID, videoID int) (structures.VideoProgress, error) {
+	const op = "postgres.progress_repo.SelectVideoProgress"
+	log := r.log.With("op", op)
+
+	query := `
+		SELECT video_id, last_position, watched_seconds, duration, completed, updated_at
+		FROM video_progress
+		WHERE user_id = $1 AND video_id = $2
+	`
+
+	var p structures.VideoProgress
+	var updatedAt sql.NullTime
+	err := r.db.QueryRow(query, userID, videoID).Scan(&p.VideoID, &p.LastPosition, &p.WatchedSeconds, &p.Duration, &p.Completed, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return structures.VideoProgress{VideoID: videoID}, nil
+		}
+		log.Error("failed to select video progress", sl.Err(err))
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if updatedAt.Valid {
+		p.UpdatedAt = &updatedAt.Time
+	}
+
+	return p, nil
+}
+
+func (r *ProgressRepo) UpsertVideoProgress(userID int, p structures.VideoProgress) error {
+	const op = "postgres.progress_repo.UpsertVideoProgress"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO video_progress (user_id, video_id, last_position, watched_seconds, duration, completed, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now() AT TIME ZONE 'utc')
+		ON CONFLICT (user_id, video_id) DO UPDATE
+		SET last_position = EXCLUDED.last_position,
+			watched_seconds = EXCLUDED.watched_seconds,
+			duration = EXCLUDED.duration,
+			completed = video_progress.completed OR EXCLUDED.completed,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, userID, p.VideoID, p.LastPosition, p.WatchedSeconds, p.Duration, p.Completed)
+	if err != nil {
+		log.Error("failed to upsert video progress", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SelectLessonProgress returns one row per video of the given courses with the user's progress on it
+func (r *ProgressRepo) SelectLessonProgress(userID int, courseIDs []int) ([]structures.LessonProgress, error) {
+	const op = "postgres.progress_repo.SelectLessonProgress"
+	log := r.log.With("op", op)
+
+	query := `
+		SELECT v.course_id, v.id, v.title,
+			COALESCE(p.last_position, 0), COALESCE(p.watched_seconds, 0), COALESCE(p.duration, 0),
+			COALESCE(p.completed, false), p.updated_at
+		FROM videos v
+		JOIN course_modules m ON m.id = v.module_id
+		LEFT JOIN video_progress p ON p.video_id = v.id AND p.user_id = $1
+		WHERE v.course_id = ANY($2)
+		ORDER BY v.course_id, m.position, m.id, v.position, v.id
+	`
+
+	rows, err := r.db.Query(query, userID, intArray(courseIDs))
+	if err != nil {
+		log.Error("failed to select lesson progress", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var lessons []structures.LessonProgress
+	for rows.Next() {
+		var l structures.LessonProgress
+		var updatedAt sql.NullTime
+
+		if err := rows.Scan(&l.CourseID, &l.VideoID, &l.Title, &l.LastPosition, &l.WatchedSeconds, &l.Duration, &l.Completed, &updatedAt); err != nil {
+			log.Error("failed to scan lesson progress", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if updatedAt.Valid {
+			l.UpdatedAt = &updatedAt.Time
+		}
+		lessons = append(lessons, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("rows iteration error", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return lessons, nil
+}
+
+// SelectCourseLearners returns learners of a course (users with access or with any progress) and how far each one got
+func (r *ProgressRepo) SelectCourseLearners(courseID int) ([]structures.LearnerProgress, error) {
+	const op = "postgres.progress_repo.SelectCourseLearners"
+	log := r.log.With("op", op)
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM videos WHERE course_id = $1`, courseID).Scan(&total); err != nil {
+		log.Error("failed to count course videos", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := `
+		SELECT u.id, u.username,
+			COUNT(p.video_id) FILTER (WHERE p.completed),
+			COALESCE(SUM(p.watched_seconds), 0),
+			MAX(p.updated_at)
+		FROM users u
+		LEFT JOIN video_progress p
+			ON p.user_id = u.id AND p.video_id IN (SELECT id FROM videos WHERE course_id = $1)
+		WHERE EXISTS (SELECT 1 FROM enrollments e WHERE e.user_id = u.id AND e.course_id = $1)
+			OR p.video_id IS NOT NULL
+		GROUP BY u.id, u.username
+		ORDER BY u.username
+	`
+
+	rows, err := r.db.Query(query, courseID)
+	if err != nil {
+		log.Error("failed to select course learners", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var learners []structures.LearnerProgress
+	for rows.Next() {
+		var l structures.LearnerProgress
+		var lastActivity sql.NullTime
+
+		if err := rows.Scan(&l.UserID, &l.Username, &l.CompletedVideos, &l.WatchedSeconds, &lastActivity); err != nil {
+			log.Error("failed to scan learner progress", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if lastActivity.Valid {
+			l.LastActivity = &lastActivity.Time
+		}
+		l.TotalVideos = total
+		if total > 0 {
+			l.Percent = l.CompletedVideos * 100 / total
+		}
+		learners = append(learners, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("rows iteration error", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return learners, nil
+}
+
+// intArray converts ids into a value usable with "= ANY($n)"
+func intArray(ids []int) pq.Int64Array {
+	arr := make(pq.Int64Array, len(ids))
+	for i, id := range ids {
+		arr[i] = int64(id)
+	}
+	return arr
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPromoNotFound     = errors.New("promo code not found")
+	ErrPromoDuplicate    = errors.New("promo code already exists")
+	ErrPromoLimitReached = errors.New("promo code usage limit reached")
+)
+
+type PromoRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewPromoRepo(log *slog.Logger, db *sql.DB) *PromoRepo {
+	return &PromoRepo{log: log, db: db}
+}
+
+// promoUse is the condition for an order aliased as o to use up its promo code: paid orders, and pending ones
+// that hold the use until they are paid or fail, so a code can't be paid for more times than it allows
+const promoUse = `o.status IN ('paid', 'pending')`
+
+const promoSelect = `
+	SELECT p.id, p.code, p.kind, p.value, p.expires_at, p.max_uses, p.max_uses_per_user, p.course_ids, p.active, p.created_at,
+		(SELECT COUNT(*) FROM orders o WHERE o.promo_code_id = p.id AND ` + promoUse + `)
+	FROM promo_codes p`
+
+func scanPromo(row interface{ Scan(...any) error }) (structures.PromoCode, error) {
+	var p structures.PromoCode
+	var expiresAt sql.NullTime
+	var courseIDs pq.Int64Array
+	err := row.Scan(&p.Id, &p.Code, &p.Kind, &p.Value, &expiresAt, &p.MaxUses, &p.MaxUsesPerUser, &courseIDs, &p.Active, &p.CreatedAt, &p.Uses)
+	if expiresAt.Valid {
+		p.ExpiresAt = &expiresAt.Time
+	}
+	p.CourseIDs = make([]int, len(courseIDs))
+	for i, id := range courseIDs {
+		p.CourseIDs[i] = int(id)
+	}
+	return p, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (r *PromoRepo) InsertPromo(p structures.PromoCode) (int, error) {
+	const op = "postgres.promo_repo.InsertPromo"
+	log := r.log.With("op", op)
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO promo_codes (code, kind, value, expires_at, max_uses, max_uses_per_user, course_ids, active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, p.Code, p.Kind, p.Value, p.ExpiresAt, p.MaxUses, p.MaxUsesPerUser, intArray(p.CourseIDs), p.Active).Scan(&id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, ErrPromoDuplicate
+		}
+		log.Error("failed to insert promo code", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("promo code created", slog.Int("id", id), slog.String("code", p.Code))
+	return id, nil
+}
+
+func (r *PromoRepo) UpdatePromo(p structures.PromoCode) error {
+	const op = "postgres.promo_repo.UpdatePromo"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE promo_codes
+		SET code = $1, kind = $2, value = $3, expires_at = $4, max_uses = $5, max_uses_per_user = $6, course_ids = $7, active = $8
+		WHERE id = $9
+	`, p.Code, p.Kind, p.Value, p.ExpiresAt, p.MaxUses, p.MaxUsesPerUser, intArray(p.CourseIDs), p.Active, p.Id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrPromoDuplicate
+		}
+		log.Error("failed to update promo code", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPromoNotFound
+	}
+
+	return nil
+}
+
+func (r *PromoRepo) DeletePromo(id int) error {
+	const op = "postgres.promo_repo.DeletePromo"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM promo_codes WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete promo code", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPromoNotFound
+	}
+
+	log.Info("promo code deleted", slog.Int("id", id))
+	return nil
+}
+
+func (r *PromoRepo) SelectPromos() ([]structures.PromoCode, error) {
+	const op = "postgres.promo_repo.SelectPromos"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(promoSelect + ` ORDER BY p.created_at DESC, p.id DESC`)
+	if err != nil {
+		log.Error("failed to select promo codes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	promos := []structures.PromoCode{}
+	for rows.Next() {
+		p, err := scanPromo(rows)
+		if err != nil {
+			log.Error("failed to scan promo code", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		promos = append(promos, p)
+	}
+
+	return promos, rows.Err()
+}
+
+func (r *PromoRepo) SelectPromoById(id int) (structures.PromoCode, error) {
+	const op = "postgres.promo_repo.SelectPromoById"
+	log := r.log.With("op", op)
+
+	p, err := scanPromo(r.db.QueryRow(promoSelect+` WHERE p.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, ErrPromoNotFound
+		}
+		log.Error("failed to select promo code", sl.Err(err))
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+func (r *PromoRepo) SelectPromoByCode(code string) (structures.PromoCode, error) {
+	const op = "postgres.promo_repo.SelectPromoByCode"
+	log := r.log.With("op", op)
+
+	p, err := scanPromo(r.db.QueryRow(promoSelect+` WHERE p.code = $1`, code))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return p, ErrPromoNotFound
+		}
+		log.Error("failed to select promo code", sl.Err(err))
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// CountUses returns how many orders use the code, in total and by the user.
+// The pending order being resumed, excludeOrderID, already holds its use and is not counted.
+func (r *PromoRepo) CountUses(promoID, userID, excludeOrderID int) (total, byUser int, err error) {
+	const op = "postgres.promo_repo.CountUses"
+
+	err = r.db.QueryRow(`
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE o.user_id = $2)
+		FROM orders o
+		WHERE o.promo_code_id = $1 AND o.id <> $3 AND `+promoUse+`
+	`, promoID, userID, excludeOrderID).Scan(&total, &byUser)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return total, byUser, nil
+}
@@ -0,0 +1,371 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+	"github.com/lib/pq"
+)
+
+var ErrQuizNotFound = errors.New("quiz not found")
+
+type QuizRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewQuizRepo(log *slog.Logger, db *sql.DB) *QuizRepo {
+	return &QuizRepo{log: log, db: db}
+}
+
+// InsertQuiz creates a quiz with its questions and options and returns its ID
+func (r *QuizRepo) InsertQuiz(q structures.Quiz) (int, error) {
+	const op = "postgres.quiz_repo.InsertQuiz"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO quizzes (course_id, video_id, title, pass_score)
+		VALUES ($1, NULLIF($2, 0), $3, $4)
+		RETURNING id
+	`, q.CourseID, q.VideoID, q.Title, q.PassScore).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert quiz", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertQuestions(tx, id, q.Questions); err != nil {
+		log.Error("failed to insert questions", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("quiz created", slog.Int("id", id), slog.Int("course_id", q.CourseID))
+	return id, nil
+}
+
+// ReplaceQuiz updates the quiz settings and replaces all of its questions
+func (r *QuizRepo) ReplaceQuiz(q structures.Quiz) error {
+	const op = "postgres.quiz_repo.ReplaceQuiz"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE quizzes SET video_id = NULLIF($1, 0), title = $2, pass_score = $3
+		WHERE id = $4
+	`, q.VideoID, q.Title, q.PassScore, q.Id)
+	if err != nil {
+		log.Error("failed to update quiz", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrQuizNotFound
+	}
+
+	if _, err := tx.Exec(`DELETE FROM quiz_questions WHERE quiz_id = $1`, q.Id); err != nil {
+		log.Error("failed to delete questions", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertQuestions(tx, q.Id, q.Questions); err != nil {
+		log.Error("failed to insert questions", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("quiz updated", slog.Int("id", q.Id))
+	return nil
+}
+
+func insertQuestions(tx *sql.Tx, quizID int, questions []structures.QuizQuestion) error {
+	for i, qq := range questions {
+		var questionID int
+		err := tx.QueryRow(`
+			INSERT INTO quiz_questions (quiz_id, kind, text, position, points, accepted_answers)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, quizID, qq.Kind, qq.Text, i+1, qq.Points, pq.StringArray(qq.AcceptedAnswers)).Scan(&questionID)
+		if err != nil {
+			return err
+		}
+
+		for j, o := range qq.Options {
+			_, err := tx.Exec(`
+				INSERT INTO quiz_options (question_id, text, is_correct, position)
+				VALUES ($1, $2, $3, $4)
+			`, questionID, o.Text, o.IsCorrect, j+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (r *QuizRepo) DeleteQuiz(id int) error {
+	const op = "postgres.quiz_repo.DeleteQuiz"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM quizzes WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete quiz", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrQuizNotFound
+	}
+
+	log.Info("quiz deleted", slog.Int("id", id))
+	return nil
+}
+
+// SelectQuizById returns the quiz with its questions and options, including the correct answers
+func (r *QuizRepo) SelectQuizById(id int) (structures.Quiz, error) {
+	const op = "postgres.quiz_repo.SelectQuizById"
+	log := r.log.With("op", op)
+
+	var q structures.Quiz
+	err := r.db.QueryRow(`
+		SELECT id, course_id, COALESCE(video_id, 0), title, pass_score
+		FROM quizzes
+		WHERE id = $1
+	`, id).Scan(&q.Id, &q.CourseID, &q.VideoID, &q.Title, &q.PassScore)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return q, ErrQuizNotFound
+		}
+		log.Error("failed to select quiz", sl.Err(err))
+		return q, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, kind, text, position, points, accepted_answers
+		FROM quiz_questions
+		WHERE quiz_id = $1
+		ORDER BY position, id
+	`, id)
+	if err != nil {
+		log.Error("failed to select questions", sl.Err(err))
+		return q, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	questionIdx := make(map[int]int)
+	for rows.Next() {
+		var qq structures.QuizQuestion
+		var accepted pq.StringArray
+		if err := rows.Scan(&qq.Id, &qq.Kind, &qq.Text, &qq.Position, &qq.Points, &accepted); err != nil {
+			log.Error("failed to scan question", sl.Err(err))
+			return q, fmt.Errorf("%s: %w", op, err)
+		}
+		qq.AcceptedAnswers = accepted
+		questionIdx[qq.Id] = len(q.Questions)
+		q.Questions = append(q.Questions, qq)
+	}
+	if err := rows.Err(); err != nil {
+		return q, fmt.Errorf("%s: %w", op, err)
+	}
+
+	optRows, err := r.db.Query(`
+		SELECT o.id, o.question_id, o.text, o.is_correct
+		FROM quiz_options o
+		JOIN quiz_questions qq ON qq.id = o.question_id
+		WHERE qq.quiz_id = $1
+		ORDER BY o.question_id, o.position, o.id
+	`, id)
+	if err != nil {
+		log.Error("failed to select options", sl.Err(err))
+		return q, fmt.Errorf("%s: %w", op, err)
+	}
+	defer optRows.Close()
+
+	for optRows.Next() {
+		var o structures.QuizOption
+		var questionID int
+		if err := optRows.Scan(&o.Id, &questionID, &o.Text, &o.IsCorrect); err != nil {
+			log.Error("failed to scan option", sl.Err(err))
+			return q, fmt.Errorf("%s: %w", op, err)
+		}
+		if i, ok := questionIdx[questionID]; ok {
+			q.Questions[i].Options = append(q.Questions[i].Options, o)
+		}
+	}
+
+	return q, optRows.Err()
+}
+
+// SelectCourseQuizzes returns the quizzes of a course without questions
+func (r *QuizRepo) SelectCourseQuizzes(courseID int) ([]structures.Quiz, error) {
+	const op = "postgres.quiz_repo.SelectCourseQuizzes"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT id, course_id, COALESCE(video_id, 0), title, pass_score
+		FROM quizzes
+		WHERE course_id = $1
+		ORDER BY id
+	`, courseID)
+	if err != nil {
+		log.Error("failed to select quizzes", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	quizzes := []structures.Quiz{}
+	for rows.Next() {
+		var q structures.Quiz
+		if err := rows.Scan(&q.Id, &q.CourseID, &q.VideoID, &q.Title, &q.PassScore); err != nil {
+			log.Error("failed to scan quiz", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		quizzes = append(quizzes, q)
+	}
+
+	return quizzes, rows.Err()
+}
+
+func (r *QuizRepo) InsertAttempt(a structures.QuizAttempt) (structures.QuizAttempt, error) {
+	const op = "postgres.quiz_repo.InsertAttempt"
+	log := r.log.With("op", op)
+
+	answers, err := json.Marshal(a.Answers)
+	if err != nil {
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = r.db.QueryRow(`
+		INSERT INTO quiz_attempts (quiz_id, user_id, score, max_score, percent, passed, answers)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, a.QuizID, a.UserID, a.Score, a.MaxScore, a.Percent, a.Passed, answers).Scan(&a.Id, &a.CreatedAt)
+	if err != nil {
+		log.Error("failed to insert attempt", sl.Err(err))
+		return a, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("quiz attempt saved", slog.Int("quiz_id", a.QuizID), slog.Int("user_id", a.UserID), slog.Int("percent", a.Percent))
+	return a, nil
+}
+
+// SelectAttempts returns the attempt history of a quiz, newest first. userID 0 returns attempts of every user.
+func (r *QuizRepo) SelectAttempts(quizID, userID int) ([]structures.QuizAttempt, error) {
+	const op = "postgres.quiz_repo.SelectAttempts"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT a.id, a.quiz_id, a.user_id, u.username, a.score, a.max_score, a.percent, a.passed, a.answers, a.created_at
+		FROM quiz_attempts a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.quiz_id = $1 AND ($2 = 0 OR a.user_id = $2)
+		ORDER BY a.created_at DESC, a.id DESC
+	`, quizID, userID)
+	if err != nil {
+		log.Error("failed to select attempts", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	attempts := []structures.QuizAttempt{}
+	for rows.Next() {
+		var a structures.QuizAttempt
+		var answers []byte
+		if err := rows.Scan(&a.Id, &a.QuizID, &a.UserID, &a.Username, &a.Score, &a.MaxScore, &a.Percent, &a.Passed, &answers, &a.CreatedAt); err != nil {
+			log.Error("failed to scan attempt", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := json.Unmarshal(answers, &a.Answers); err != nil {
+			log.Warn("failed to decode attempt answers", slog.Int("id", a.Id), sl.Err(err))
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// SelectBestAttempts returns the user's best attempt per quiz of the course
+func (r *QuizRepo) SelectBestAttempts(userID, courseID int) (map[int]structures.QuizAttempt, error) {
+	const op = "postgres.quiz_repo.SelectBestAttempts"
+
+	rows, err := r.db.Query(`
+		SELECT DISTINCT ON (a.quiz_id) a.id, a.quiz_id, a.user_id, a.score, a.max_score, a.percent, a.passed, a.created_at
+		FROM quiz_attempts a
+		JOIN quizzes q ON q.id = a.quiz_id
+		WHERE a.user_id = $1 AND q.course_id = $2
+		ORDER BY a.quiz_id, a.percent DESC, a.created_at
+	`, userID, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	best := make(map[int]structures.QuizAttempt)
+	for rows.Next() {
+		var a structures.QuizAttempt
+		if err := rows.Scan(&a.Id, &a.QuizID, &a.UserID, &a.Score, &a.MaxScore, &a.Percent, &a.Passed, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		best[a.QuizID] = a
+	}
+
+	return best, rows.Err()
+}
+
+// SelectRequirements counts mandatory quizzes (pass_score > 0) per course and how many of them the user passed
+func (r *QuizRepo) SelectRequirements(userID int, courseIDs []int) (map[int]structures.QuizRequirement, error) {
+	const op = "postgres.quiz_repo.SelectRequirements"
+
+	rows, err := r.db.Query(`
+		SELECT q.course_id,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE EXISTS (
+				SELECT 1 FROM quiz_attempts a WHERE a.quiz_id = q.id AND a.user_id = $1 AND a.passed
+			))
+		FROM quizzes q
+		WHERE q.course_id = ANY($2) AND q.pass_score > 0
+		GROUP BY q.course_id
+	`, userID, intArray(courseIDs))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	reqs := make(map[int]structures.QuizRequirement)
+	for rows.Next() {
+		var courseID int
+		var req structures.QuizRequirement
+		if err := rows.Scan(&courseID, &req.Required, &req.Passed); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		reqs[courseID] = req
+	}
+
+	return reqs, rows.Err()
+}
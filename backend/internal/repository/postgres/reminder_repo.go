@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+type ReminderRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewReminderRepo(log *slog.Logger, db *sql.DB) *ReminderRepo {
+	return &ReminderRepo{log: log, db: db}
+}
+
+// SelectDue returns reminders for scheduled webinars starting within offsetMinutes from now
+// that weren't sent yet to users with access to the course
+func (r *ReminderRepo) SelectDue(now time.Time, offsetMinutes int) ([]structures.WebinarReminder, error) {
+	const op = "postgres.reminder_repo.SelectDue"
+	log := r.log.With("op", op)
+
+	query := `
+		SELECT w.id, w.title, w.link, w.date, c.title, u.id, u.username
+		FROM webinars w
+		JOIN courses c ON c.id = w.course_id
+		JOIN enrollments e ON e.course_id = w.course_id
+		JOIN users u ON u.id = e.user_id
+		WHERE w.status = 'scheduled'
+			AND ` + activeEnrollment + `
+			AND ` + enrollmentWebinars + `
+			AND ` + cohortWebinar + `
+			AND w.date > $1
+			AND w.date <= $1 + make_interval(mins => $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM webinar_reminders_sent s
+				WHERE s.webinar_id = w.id AND s.user_id = u.id AND s.offset_minutes = $2
+			)
+		ORDER BY w.date, u.id
+	`
+
+	rows, err := r.db.Query(query, now.UTC(), offsetMinutes)
+	if err != nil {
+		log.Error("failed to select due reminders", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var reminders []structures.WebinarReminder
+	for rows.Next() {
+		var rm structures.WebinarReminder
+		if err := rows.Scan(&rm.WebinarID, &rm.Title, &rm.Link, &rm.Date, &rm.CourseTitle, &rm.UserID, &rm.Username); err != nil {
+			log.Error("failed to scan reminder", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		reminders = append(reminders, rm)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("rows iteration error", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return reminders, nil
+}
+
+// MarkSent records the reminder for every given offset, so a later check doesn't repeat it
+func (r *ReminderRepo) MarkSent(webinarID, userID int, offsetsMinutes []int) error {
+	const op = "postgres.reminder_repo.MarkSent"
+
+	_, err := r.db.Exec(`
+		INSERT INTO webinar_reminders_sent (webinar_id, user_id, offset_minutes)
+		SELECT $1, $2, unnest($3::integer[])
+		ON CONFLICT DO NOTHING
+	`, webinarID, userID, intArray(offsetsMinutes))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var ErrReviewNotFound = errors.New("review not found")
+
+type ReviewRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewReviewRepo(log *slog.Logger, db *sql.DB) *ReviewRepo {
+	return &ReviewRepo{log: log, db: db}
+}
+
+const reviewSelect = `
+	SELECT r.id, r.user_id, u.username, r.course_id, c.title, r.rating, r.text, r.status,
+		r.admin_comment, COALESCE(r.moderated_by, 0), r.moderated_at, r.created_at, r.updated_at
+	FROM course_reviews r
+	JOIN users u ON u.id = r.user_id
+	JOIN courses c ON c.id = r.course_id`
+
+func scanReview(row interface{ Scan(...any) error }) (structures.CourseReview, error) {
+	var rv structures.CourseReview
+	var moderatedAt sql.NullTime
+	err := row.Scan(&rv.Id, &rv.UserID, &rv.Username, &rv.CourseID, &rv.CourseTitle, &rv.Rating, &rv.Text, &rv.Status,
+		&rv.AdminComment, &rv.ModeratedBy, &moderatedAt, &rv.CreatedAt, &rv.UpdatedAt)
+	if moderatedAt.Valid {
+		rv.ModeratedAt = &moderatedAt.Time
+	}
+	return rv, err
+}
+
+// Upsert saves the user's review of the course. An edited review goes back to the moderation queue.
+func (r *ReviewRepo) Upsert(rv structures.CourseReview) (int, error) {
+	const op = "postgres.review_repo.Upsert"
+	log := r.log.With("op", op)
+
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO course_reviews (user_id, course_id, rating, text)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, course_id) DO UPDATE
+		SET rating = EXCLUDED.rating,
+			text = EXCLUDED.text,
+			status = 'pending',
+			admin_comment = '',
+			moderated_by = NULL,
+			moderated_at = NULL,
+			updated_at = (now() AT TIME ZONE 'utc')
+		RETURNING id
+	`, rv.UserID, rv.CourseID, rv.Rating, rv.Text).Scan(&id)
+	if err != nil {
+		log.Error("failed to save review", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("review saved", slog.Int("id", id), slog.Int("user_id", rv.UserID), slog.Int("course_id", rv.CourseID))
+	return id, nil
+}
+
+func (r *ReviewRepo) SelectReviewById(id int) (structures.CourseReview, error) {
+	const op = "postgres.review_repo.SelectReviewById"
+
+	rv, err := scanReview(r.db.QueryRow(reviewSelect+` WHERE r.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rv, ErrReviewNotFound
+		}
+		return rv, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rv, nil
+}
+
+// SelectByUser returns the user's review of the course
+func (r *ReviewRepo) SelectByUser(userID, courseID int) (structures.CourseReview, error) {
+	const op = "postgres.review_repo.SelectByUser"
+
+	rv, err := scanReview(r.db.QueryRow(reviewSelect+` WHERE r.user_id = $1 AND r.course_id = $2`, userID, courseID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return rv, ErrReviewNotFound
+		}
+		return rv, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rv, nil
+}
+
+// SelectReviews returns a page of reviews and the number of all reviews matching the filter.
+// oldestFirst orders the moderation queue, public lists show the newest reviews first.
+func (r *ReviewRepo) SelectReviews(filter structures.CourseReviewFilter, oldestFirst bool) ([]structures.CourseReview, int, error) {
+	const op = "postgres.review_repo.SelectReviews"
+	log := r.log.With("op", op)
+
+	var conds []string
+	var args []any
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.CourseID != 0 {
+		add("r.course_id = $%d", filter.CourseID)
+	}
+	if filter.UserID != 0 {
+		add("r.user_id = $%d", filter.UserID)
+	}
+	if filter.Status != "" {
+		add("r.status = $%d", filter.Status)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM course_reviews r`+where, args...).Scan(&total); err != nil {
+		log.Error("failed to count reviews", sl.Err(err))
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	order := ` ORDER BY r.updated_at DESC, r.id DESC`
+	if oldestFirst {
+		order = ` ORDER BY r.updated_at, r.id`
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query := reviewSelect + where + order + fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		log.Error("failed to select reviews", sl.Err(err))
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	reviews := []structures.CourseReview{}
+	for rows.Next() {
+		rv, err := scanReview(rows)
+		if err != nil {
+			log.Error("failed to scan review", sl.Err(err))
+			return nil, 0, fmt.Errorf("%s: %w", op, err)
+		}
+		reviews = append(reviews, rv)
+	}
+
+	return reviews, total, rows.Err()
+}
+
+// Moderate sets the status of the review. Approving a rejected review or the other way round is allowed.
+func (r *ReviewRepo) Moderate(id int, status, comment string, moderatorID int) error {
+	const op = "postgres.review_repo.Moderate"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE course_reviews
+		SET status = $1, admin_comment = $2, moderated_by = NULLIF($3, 0), moderated_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $4
+	`, status, comment, moderatorID, id)
+	if err != nil {
+		log.Error("failed to moderate review", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrReviewNotFound
+	}
+
+	log.Info("review moderated", slog.Int("id", id), slog.String("status", status), slog.Int("moderator_id", moderatorID))
+	return nil
+}
+
+// Delete removes the review. userID limits the deletion to the author's own review, 0 deletes any.
+func (r *ReviewRepo) Delete(id, userID int) error {
+	const op = "postgres.review_repo.Delete"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM course_reviews WHERE id = $1 AND ($2 = 0 OR user_id = $2)`, id, userID)
+	if err != nil {
+		log.Error("failed to delete review", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrReviewNotFound
+	}
+
+	log.Info("review deleted", slog.Int("id", id))
+	return nil
+}
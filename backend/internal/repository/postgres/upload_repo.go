@@ -0,0 +1,215 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrUploadNotFound     = errors.New("upload not found")
+	ErrUploadOffsetMoved  = errors.New("upload offset does not match")
+	ErrUploadNotUploading = errors.New("upload is already completed")
+)
+
+type UploadRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewUploadRepo(log *slog.Logger, db *sql.DB) *UploadRepo {
+	return &UploadRepo{log: log, db: db}
+}
+
+func (r *UploadRepo) InsertSession(u structures.UploadSession) error {
+	const op = "postgres.upload_repo.InsertSession"
+	log := r.log.With("op", op)
+
+	query := `
+		INSERT INTO upload_sessions (id, course_id, title, filename, size, duration, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.Exec(query, u.Id, u.CourseID, u.Title, u.Filename, u.Size, u.Duration, u.CreatedBy)
+	if err != nil {
+		log.Error("failed to insert upload session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("upload session created", slog.String("id", u.Id), slog.Int("course_id", u.CourseID), slog.Int64("size", u.Size))
+	return nil
+}
+
+func (r *UploadRepo) SelectSession(id string) (structures.UploadSession, error) {
+	const op = "postgres.upload_repo.SelectSession"
+	log := r.log.With("op", op)
+
+	query := `
+		SELECT id, course_id, title, filename, size, duration, upload_offset, status,
+			COALESCE(video_path, ''), COALESCE(created_by, 0), created_at, updated_at
+		FROM upload_sessions
+		WHERE id = $1
+	`
+
+	var u structures.UploadSession
+	err := r.db.QueryRow(query, id).Scan(
+		&u.Id, &u.CourseID, &u.Title, &u.Filename, &u.Size, &u.Duration, &u.Offset, &u.Status,
+		&u.VideoPath, &u.CreatedBy, &u.CreatedAt, &u.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return u, ErrUploadNotFound
+		}
+		log.Error("failed to select upload session", sl.Err(err))
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// AdvanceOffset writes a chunk of n bytes at offset from and moves the offset past it. The session row stays locked
+// while write runs, so two requests for the same offset can't both write into the file.
+func (r *UploadRepo) AdvanceOffset(id string, from, n int64, write func() error) error {
+	const op = "postgres.upload_repo.AdvanceOffset"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var offset int64
+	var status string
+	err = tx.QueryRow(`SELECT upload_offset, status FROM upload_sessions WHERE id = $1 FOR UPDATE`, id).Scan(&offset, &status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUploadNotFound
+		}
+		log.Error("failed to lock upload session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if status != structures.UploadStatusUploading {
+		return ErrUploadNotUploading
+	}
+	if offset != from {
+		log.Warn("upload offset moved concurrently", slog.String("id", id), slog.Int64("from", from))
+		return ErrUploadOffsetMoved
+	}
+
+	if err := write(); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE upload_sessions
+		SET upload_offset = $1, updated_at = now() AT TIME ZONE 'utc'
+		WHERE id = $2
+	`, from+n, id)
+	if err != nil {
+		log.Error("failed to advance upload offset", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CompleteSession attaches the assembled video to the course of the session and marks the session completed
+// in one transaction, so a retried finalization never adds the lesson twice
+func (r *UploadRepo) CompleteSession(id, videoPath string) error {
+	const op = "postgres.upload_repo.CompleteSession"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var courseID, duration int
+	var title string
+	err = tx.QueryRow(`
+		SELECT course_id, title, duration FROM upload_sessions
+		WHERE id = $1 AND status = 'uploading'
+		FOR UPDATE
+	`, id).Scan(&courseID, &title, &duration)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUploadNotUploading
+		}
+		log.Error("failed to lock upload session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertLesson(tx, courseID, videoPath, title, "", duration); err != nil {
+		log.Error("failed to attach uploaded video", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE upload_sessions
+		SET status = 'completed', video_path = $1, updated_at = now() AT TIME ZONE 'utc'
+		WHERE id = $2
+	`, videoPath, id)
+	if err != nil {
+		log.Error("failed to complete upload session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("upload session completed", slog.String("id", id), slog.Int("course_id", courseID), slog.String("path", videoPath))
+	return nil
+}
+
+// DeleteStaleSessions deletes unfinished sessions that received no chunk since before and returns their ids
+func (r *UploadRepo) DeleteStaleSessions(before time.Time) ([]string, error) {
+	const op = "postgres.upload_repo.DeleteStaleSessions"
+	log := r.log.With("op", op)
+
+	ids, err := selectPaths(r.db, `
+		DELETE FROM upload_sessions
+		WHERE status = 'uploading' AND updated_at < $1
+		RETURNING id
+	`, before.UTC())
+	if err != nil {
+		log.Error("failed to delete stale upload sessions", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+func (r *UploadRepo) DeleteSession(id string) error {
+	const op = "postgres.upload_repo.DeleteSession"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM upload_sessions WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete upload session", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUploadNotFound
+	}
+
+	log.Info("upload session deleted", slog.String("id", id))
+	return nil
+}
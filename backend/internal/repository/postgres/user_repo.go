@@ -10,6 +10,11 @@ import (
 	"github.com/QwaQ-dev/bala/pkg/sl"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
+// userCourseIDs selects the courses a user currently has access to, for the users table without an alias
+const userCourseIDs = `ARRAY(SELECT e.course_id FROM enrollments e WHERE e.user_id = users.id AND ` + activeEnrollment + ` ORDER BY e.course_id)`
+
 type UserRepo struct {
 	log *slog.Logger
 	db  *sql.DB
@@ -43,7 +48,7 @@ func (r *UserRepo) GetUserByUsername(username string) (*structures.User, error)
 	const op = "postgres.user_repo.GetUserByUsername"
 	log := r.log.With("op", op)
 
-	query := "SELECT id, username, password, course_ids, role FROM users WHERE username=$1"
+	query := "SELECT id, username, password, " + userCourseIDs + ", role FROM users WHERE username=$1"
 
 	user := new(structures.User)
 
@@ -64,7 +69,7 @@ func (r *UserRepo) SelectAllUsers() ([]structures.User, error) {
 	log := r.log.With("op", op)
 
 	query := `
-		SELECT id, username, password, course_ids, role 
+		SELECT id, username, password, ` + userCourseIDs + `, role
 		FROM users
 		ORDER BY id DESC
 	`
@@ -110,7 +115,7 @@ func (r *UserRepo) GetUserById(id int) (*structures.User, error) {
 
 	user := new(structures.User)
 
-	query := "SELECT username, password, course_ids, role FROM users WHERE id=$1"
+	query := "SELECT username, password, " + userCourseIDs + ", role FROM users WHERE id=$1"
 
 	err := r.db.QueryRow(query, id).Scan(&user.Username, &user.Password, &user.CourseIDs, &user.Role)
 	if err != nil {
@@ -147,3 +152,65 @@ func (r *UserRepo) DeleteUser(id int) error {
 
 	return nil
 }
+
+func (r *UserRepo) UpdateRole(id int, role string) error {
+	const op = "postgres.user_repo.UpdateRole"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec("UPDATE users SET role = $1 WHERE id = $2", role, id)
+	if err != nil {
+		log.Error("Error with updating user role", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	log.Info("User role updated", slog.Int("id", id), slog.String("role", role))
+	return nil
+}
+
+func (r *UserRepo) GetCalendarToken(userID int) (string, error) {
+	const op = "postgres.user_repo.GetCalendarToken"
+	log := r.log.With("op", op)
+
+	var token sql.NullString
+	err := r.db.QueryRow("SELECT calendar_token FROM users WHERE id = $1", userID).Scan(&token)
+	if err != nil {
+		log.Error("Error with selecting calendar token", sl.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token.String, nil
+}
+
+func (r *UserRepo) SetCalendarToken(userID int, token string) error {
+	const op = "postgres.user_repo.SetCalendarToken"
+	log := r.log.With("op", op)
+
+	_, err := r.db.Exec("UPDATE users SET calendar_token = $1 WHERE id = $2", token, userID)
+	if err != nil {
+		log.Error("Error with updating calendar token", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetUserIdByCalendarToken returns 0 when no user has the token
+func (r *UserRepo) GetUserIdByCalendarToken(token string) (int, error) {
+	const op = "postgres.user_repo.GetUserIdByCalendarToken"
+
+	var id int
+	err := r.db.QueryRow("SELECT id FROM users WHERE calendar_token = $1", token).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
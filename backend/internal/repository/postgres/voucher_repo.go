@@ -0,0 +1,260 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var (
+	ErrVoucherNotFound      = errors.New("voucher not found")
+	ErrVoucherBatchNotFound = errors.New("voucher batch not found")
+	ErrVoucherRedeemed      = errors.New("voucher is already redeemed")
+)
+
+type VoucherRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewVoucherRepo(log *slog.Logger, db *sql.DB) *VoucherRepo {
+	return &VoucherRepo{log: log, db: db}
+}
+
+const voucherBatchSelect = `
+	SELECT b.id, b.course_id, c.title, b.title, COALESCE(b.cohort_id, 0), COALESCE(co.title, ''), b.expires_at, b.created_at,
+		(SELECT COUNT(*) FROM vouchers v WHERE v.batch_id = b.id),
+		(SELECT COUNT(*) FROM vouchers v WHERE v.batch_id = b.id AND v.redeemed_at IS NOT NULL)
+	FROM voucher_batches b
+	JOIN courses c ON c.id = b.course_id
+	LEFT JOIN cohorts co ON co.id = b.cohort_id`
+
+func scanVoucherBatch(row interface{ Scan(...any) error }) (structures.VoucherBatch, error) {
+	var b structures.VoucherBatch
+	var expiresAt sql.NullTime
+	err := row.Scan(&b.Id, &b.CourseID, &b.CourseTitle, &b.Title, &b.CohortID, &b.CohortTitle, &expiresAt, &b.CreatedAt, &b.Total, &b.Redeemed)
+	if expiresAt.Valid {
+		b.ExpiresAt = &expiresAt.Time
+	}
+	return b, err
+}
+
+// InsertBatch creates the batch and its codes in one transaction. generate is called until
+// count unique codes are stored, codes that collide with existing ones are skipped.
+func (r *VoucherRepo) InsertBatch(b structures.VoucherBatch, count int, generate func() (string, error)) (int, error) {
+	const op = "postgres.voucher_repo.InsertBatch"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO voucher_batches (course_id, cohort_id, title, expires_at)
+		VALUES ($1, NULLIF($2, 0), $3, $4)
+		RETURNING id
+	`, b.CourseID, b.CohortID, b.Title, b.ExpiresAt).Scan(&id)
+	if err != nil {
+		log.Error("failed to insert voucher batch", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO vouchers (batch_id, code) VALUES ($1, $2)
+		ON CONFLICT (code) DO NOTHING
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for inserted, attempts := 0, 0; inserted < count; attempts++ {
+		if attempts > count*2 {
+			return 0, fmt.Errorf("%s: too many code collisions", op)
+		}
+
+		code, err := generate()
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+
+		result, err := stmt.Exec(id, code)
+		if err != nil {
+			log.Error("failed to insert voucher", sl.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			inserted++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("voucher batch created", slog.Int("id", id), slog.Int("course_id", b.CourseID), slog.Int("count", count))
+	return id, nil
+}
+
+func (r *VoucherRepo) SelectBatches(courseID int) ([]structures.VoucherBatch, error) {
+	const op = "postgres.voucher_repo.SelectBatches"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(voucherBatchSelect+`
+		WHERE $1 = 0 OR b.course_id = $1
+		ORDER BY b.created_at DESC, b.id DESC
+	`, courseID)
+	if err != nil {
+		log.Error("failed to select voucher batches", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	batches := []structures.VoucherBatch{}
+	for rows.Next() {
+		b, err := scanVoucherBatch(rows)
+		if err != nil {
+			log.Error("failed to scan voucher batch", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		batches = append(batches, b)
+	}
+
+	return batches, rows.Err()
+}
+
+// SelectBatch returns the batch with all of its codes
+func (r *VoucherRepo) SelectBatch(id int) (structures.VoucherBatch, error) {
+	const op = "postgres.voucher_repo.SelectBatch"
+	log := r.log.With("op", op)
+
+	b, err := scanVoucherBatch(r.db.QueryRow(voucherBatchSelect+` WHERE b.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return b, ErrVoucherBatchNotFound
+		}
+		log.Error("failed to select voucher batch", sl.Err(err))
+		return b, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT v.id, v.batch_id, v.code, COALESCE(v.redeemed_by, 0), COALESCE(u.username, ''), v.redeemed_at
+		FROM vouchers v
+		LEFT JOIN users u ON u.id = v.redeemed_by
+		WHERE v.batch_id = $1
+		ORDER BY v.id
+	`, id)
+	if err != nil {
+		log.Error("failed to select vouchers", sl.Err(err))
+		return b, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	b.Vouchers = []structures.Voucher{}
+	for rows.Next() {
+		var v structures.Voucher
+		var redeemedAt sql.NullTime
+		if err := rows.Scan(&v.Id, &v.BatchID, &v.Code, &v.RedeemedBy, &v.Username, &redeemedAt); err != nil {
+			log.Error("failed to scan voucher", sl.Err(err))
+			return b, fmt.Errorf("%s: %w", op, err)
+		}
+		if redeemedAt.Valid {
+			v.RedeemedAt = &redeemedAt.Time
+		}
+		b.Vouchers = append(b.Vouchers, v)
+	}
+
+	return b, rows.Err()
+}
+
+// DeleteBatch removes the batch, its unused codes stop working. Access already granted is kept.
+func (r *VoucherRepo) DeleteBatch(id int) error {
+	const op = "postgres.voucher_repo.DeleteBatch"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`DELETE FROM voucher_batches WHERE id = $1`, id)
+	if err != nil {
+		log.Error("failed to delete voucher batch", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrVoucherBatchNotFound
+	}
+
+	log.Info("voucher batch deleted", slog.Int("id", id))
+	return nil
+}
+
+// SelectByCode returns the voucher with the course, cohort and expiry of its batch
+func (r *VoucherRepo) SelectByCode(code string) (structures.Voucher, error) {
+	const op = "postgres.voucher_repo.SelectByCode"
+	log := r.log.With("op", op)
+
+	var v structures.Voucher
+	var redeemedAt, expiresAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT v.id, v.batch_id, v.code, COALESCE(v.redeemed_by, 0), v.redeemed_at, b.course_id, COALESCE(b.cohort_id, 0), b.expires_at
+		FROM vouchers v
+		JOIN voucher_batches b ON b.id = v.batch_id
+		WHERE v.code = $1
+	`, code).Scan(&v.Id, &v.BatchID, &v.Code, &v.RedeemedBy, &redeemedAt, &v.CourseID, &v.CohortID, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return v, ErrVoucherNotFound
+		}
+		log.Error("failed to select voucher", sl.Err(err))
+		return v, fmt.Errorf("%s: %w", op, err)
+	}
+	if redeemedAt.Valid {
+		v.RedeemedAt = &redeemedAt.Time
+	}
+	if expiresAt.Valid {
+		v.ExpiresAt = &expiresAt.Time
+	}
+
+	return v, nil
+}
+
+// MarkRedeemed claims the voucher for the user. It fails with ErrVoucherRedeemed if someone claimed it first.
+func (r *VoucherRepo) MarkRedeemed(id, userID int) error {
+	const op = "postgres.voucher_repo.MarkRedeemed"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE vouchers SET redeemed_by = $1, redeemed_at = (now() AT TIME ZONE 'utc')
+		WHERE id = $2 AND redeemed_at IS NULL
+	`, userID, id)
+	if err != nil {
+		log.Error("failed to redeem voucher", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrVoucherRedeemed
+	}
+
+	return nil
+}
+
+// Release returns a claimed voucher when granting access failed
+func (r *VoucherRepo) Release(id int) error {
+	const op = "postgres.voucher_repo.Release"
+
+	if _, err := r.db.Exec(`UPDATE vouchers SET redeemed_by = NULL, redeemed_at = NULL WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
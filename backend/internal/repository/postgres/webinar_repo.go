@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/sl"
+)
+
+var ErrWebinarNotFound = errors.New("webinar not found")
+
+type WebinarRepo struct {
+	log *slog.Logger
+	db  *sql.DB
+}
+
+func NewWebinarRepo(log *slog.Logger, db *sql.DB) *WebinarRepo {
+	return &WebinarRepo{log: log, db: db}
+}
+
+const webinarColumns = `id, course_id, title, link, date, duration, status, COALESCE(series_id, ''), COALESCE(cohort_id, 0)`
+
+// cohortWebinar is the condition for a webinar aliased as w to be on the schedule of the enrollment aliased as e
+const cohortWebinar = `(w.cohort_id IS NULL OR w.cohort_id = e.cohort_id)`
+
+func scanWebinar(row interface{ Scan(...any) error }) (structures.Webinar, error) {
+	var w structures.Webinar
+	err := row.Scan(&w.Id, &w.CourseID, &w.Title, &w.Link, &w.Date, &w.Duration, &w.Status, &w.SeriesID, &w.CohortID)
+	return w, err
+}
+
+// insertWebinars is shared with CourseRepo.InsertCourse so the first webinar is created in the same transaction
+func insertWebinars(tx *sql.Tx, courseID int, webinars []structures.Webinar) ([]int, error) {
+	ids := make([]int, 0, len(webinars))
+	for _, w := range webinars {
+		var id int
+		err := tx.QueryRow(`
+			INSERT INTO webinars (course_id, title, link, date, duration, status, series_id, cohort_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, 0))
+			RETURNING id
+		`, courseID, w.Title, w.Link, w.Date, w.Duration, structures.WebinarScheduled, w.SeriesID, w.CohortID).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// selectCourseWebinars returns past and upcoming webinars of the course sorted by date
+func selectCourseWebinars(q querier, courseID int) ([]structures.Webinar, error) {
+	rows, err := q.Query(`
+		SELECT `+webinarColumns+`
+		FROM webinars
+		WHERE course_id = $1
+		ORDER BY date, id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webinars := []structures.Webinar{}
+	for rows.Next() {
+		w, err := scanWebinar(rows)
+		if err != nil {
+			return nil, err
+		}
+		webinars = append(webinars, w)
+	}
+
+	return webinars, rows.Err()
+}
+
+// InsertWebinars adds one webinar or a whole series to the course
+func (r *WebinarRepo) InsertWebinars(courseID int, webinars []structures.Webinar) ([]int, error) {
+	const op = "postgres.webinar_repo.InsertWebinars"
+	log := r.log.With("op", op)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("failed to begin tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer tx.Rollback()
+
+	ids, err := insertWebinars(tx, courseID, webinars)
+	if err != nil {
+		log.Error("failed to insert webinars", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("failed to commit tx", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("webinars added", slog.Int("course_id", courseID), slog.Int("count", len(ids)))
+	return ids, nil
+}
+
+func (r *WebinarRepo) SelectByCourse(courseID int) ([]structures.Webinar, error) {
+	const op = "postgres.webinar_repo.SelectByCourse"
+	log := r.log.With("op", op)
+
+	webinars, err := selectCourseWebinars(r.db, courseID)
+	if err != nil {
+		log.Error("failed to select webinars", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return webinars, nil
+}
+
+func (r *WebinarRepo) SelectById(id int) (structures.Webinar, error) {
+	const op = "postgres.webinar_repo.SelectById"
+	log := r.log.With("op", op)
+
+	w, err := scanWebinar(r.db.QueryRow(`SELECT `+webinarColumns+` FROM webinars WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return w, ErrWebinarNotFound
+		}
+		log.Error("failed to select webinar", sl.Err(err))
+		return w, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w, nil
+}
+
+func (r *WebinarRepo) UpdateWebinar(w structures.Webinar) error {
+	const op = "postgres.webinar_repo.UpdateWebinar"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE webinars
+		SET title = $1, link = $2, date = $3, duration = $4
+		WHERE id = $5
+	`, w.Title, w.Link, w.Date, w.Duration, w.Id)
+	if err != nil {
+		log.Error("failed to update webinar", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWebinarNotFound
+	}
+
+	log.Info("webinar updated", slog.Int("id", w.Id))
+	return nil
+}
+
+// CancelWebinar cancels a single webinar
+func (r *WebinarRepo) CancelWebinar(id int) error {
+	const op = "postgres.webinar_repo.CancelWebinar"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`UPDATE webinars SET status = $1 WHERE id = $2`, structures.WebinarCancelled, id)
+	if err != nil {
+		log.Error("failed to cancel webinar", sl.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWebinarNotFound
+	}
+
+	log.Info("webinar cancelled", slog.Int("id", id))
+	return nil
+}
+
+// CancelSeries cancels every occurrence of the series starting at from, past sessions keep their status
+func (r *WebinarRepo) CancelSeries(seriesID string, from time.Time) (int, error) {
+	const op = "postgres.webinar_repo.CancelSeries"
+	log := r.log.With("op", op)
+
+	result, err := r.db.Exec(`
+		UPDATE webinars SET status = $1
+		WHERE series_id = $2 AND date >= $3
+	`, structures.WebinarCancelled, seriesID, from)
+	if err != nil {
+		log.Error("failed to cancel series", sl.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	log.Info("webinar series cancelled", slog.String("series_id", seriesID), slog.Int64("count", rowsAffected))
+	return int(rowsAffected), nil
+}
+
+// SelectForUser returns webinars of every course the user has access to with webinars in their plan, for the calendar feed.
+// Webinars of a cohort are returned to its members only.
+func (r *WebinarRepo) SelectForUser(userID int) ([]structures.CalendarWebinar, error) {
+	const op = "postgres.webinar_repo.SelectForUser"
+	log := r.log.With("op", op)
+
+	rows, err := r.db.Query(`
+		SELECT w.id, w.course_id, w.title, w.link, w.date, w.duration, w.status, COALESCE(w.series_id, ''), c.title
+		FROM webinars w
+		JOIN courses c ON c.id = w.course_id
+		JOIN enrollments e ON e.course_id = w.course_id
+		WHERE e.user_id = $1 AND `+activeEnrollment+` AND `+enrollmentWebinars+` AND `+cohortWebinar+`
+		ORDER BY w.date, w.id
+	`, userID)
+	if err != nil {
+		log.Error("failed to select user webinars", sl.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var webinars []structures.CalendarWebinar
+	for rows.Next() {
+		var w structures.CalendarWebinar
+		if err := rows.Scan(&w.Id, &w.CourseID, &w.Title, &w.Link, &w.Date, &w.Duration, &w.Status, &w.SeriesID, &w.CourseTitle); err != nil {
+			log.Error("failed to scan webinar", sl.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		webinars = append(webinars, w)
+	}
+
+	return webinars, rows.Err()
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/QwaQ-dev/bala/internal/config"
 	"github.com/QwaQ-dev/bala/internal/handlers"
+	"github.com/QwaQ-dev/bala/internal/structures"
 	"github.com/QwaQ-dev/bala/pkg/jwt/middleware"
+	"github.com/QwaQ-dev/bala/pkg/payments"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -16,7 +18,26 @@ func InitRoutes(
 	userHandler *handlers.UserHandler,
 	articleHandler *handlers.ArticleHandler,
 	checklistHandler *handlers.ChecklistHandler,
-	courseHandler *handlers.CourseHandler) {
+	courseHandler *handlers.CourseHandler,
+	diplomaHandler *handlers.DiplomaHandler,
+	certificateHandler *handlers.CertificateHandler,
+	progressHandler *handlers.ProgressHandler,
+	mediaHandler *handlers.MediaHandler,
+	uploadHandler *handlers.UploadHandler,
+	moduleHandler *handlers.ModuleHandler,
+	webinarHandler *handlers.WebinarHandler,
+	calendarHandler *handlers.CalendarHandler,
+	quizHandler *handlers.QuizHandler,
+	homeworkHandler *handlers.HomeworkHandler,
+	orderHandler *handlers.OrderHandler,
+	promoHandler *handlers.PromoHandler,
+	voucherHandler *handlers.VoucherHandler,
+	accessRequestHandler *handlers.AccessRequestHandler,
+	reviewHandler *handlers.ReviewHandler,
+	planHandler *handlers.PlanHandler,
+	bundleHandler *handlers.BundleHandler,
+	pathHandler *handlers.PathHandler,
+	cohortHandler *handlers.CohortHandler) {
 
 	v1 := app.Group("/api/v1")
 
@@ -27,20 +48,47 @@ func InitRoutes(
 	admin.Use(middleware.JWTMiddleware(cfg.JWTSecretKey))
 	admin.Use(middleware.AdminOnly())
 
+	review := v1.Group("/review")
+	review.Use(middleware.JWTMiddleware(cfg.JWTSecretKey))
+	review.Use(middleware.RolesOnly(structures.RoleAdmin, structures.RoleCurator))
+
 	user := v1.Group("/user")
 
+	// catalogue and syllabus for anonymous visitors, signed-in users see their full courses here too
+	publicCourses := v1.Group("/course")
+	publicCourses.Use(middleware.OptionalJWTMiddleware(cfg.JWTSecretKey))
+
 	adminArticles := admin.Group("/article")
 	adminChecklists := admin.Group("/checklist")
 	adminCourses := admin.Group("/course")
+	adminCertificates := admin.Group("/certificate")
+	adminUploads := admin.Group("/upload")
+	adminOrders := admin.Group("/orders")
+	adminPromo := admin.Group("/promo")
+	adminVouchers := admin.Group("/vouchers")
+	adminAccessRequests := admin.Group("/access-requests")
+	adminReviews := admin.Group("/reviews")
+	adminBundles := admin.Group("/bundles")
+	adminPaths := admin.Group("/paths")
 
 	articles := v1.Group("/article")
 	checklists := v1.Group("/checklist")
+	certificates := v1.Group("/certificate")
+	media := v1.Group("/media")
+	// the mock checkout marks orders paid without a login, it only exists when the mock provider is configured
+	mockPayments := cfg.Payments.Provider == payments.ProviderMock
+	payments := v1.Group("/payments")
+	bundles := v1.Group("/bundles")
+	paths := v1.Group("/paths")
 
 	courses := authorizedGroup.Group("/course")
+	authorizedMedia := authorizedGroup.Group("/media")
+	orders := authorizedGroup.Group("/orders")
 
 	user.Post("/sign-in", userHandler.SignIn)
 	user.Post("/sign-up", userHandler.SignUp)
 	admin.Get("/users", userHandler.GetAllUsers)
+	admin.Put("/users/:id/role", userHandler.SetRole)
 	authorizedGroup.Get("/user-info", userHandler.GetUserViaToken)
 	authorizedGroup.Delete("/logout", userHandler.Logout)
 
@@ -60,11 +108,153 @@ func InitRoutes(
 	adminCourses.Put("/update", courseHandler.UpdateCourse)
 	courses.Get("/get", courseHandler.GetAllCourses)
 	courses.Get("/get/:id", courseHandler.GetCourseByID)
+	publicCourses.Get("/get", courseHandler.GetAllCourses)
+	publicCourses.Get("/get/:id", courseHandler.GetCourseByID)
 	courses.Get("/get-with-access", courseHandler.GetAllCoursesWithAccess)
 	adminCourses.Delete("/:id", courseHandler.DeleteCourse)
+	adminCourses.Put("/:id/status", courseHandler.SetStatus)
+	adminCourses.Post("/:id/duplicate", courseHandler.DuplicateCourse)
 	adminCourses.Post("/add-video", courseHandler.UploadVideos)
+	adminCourses.Put("/video/:videoId", courseHandler.UpdateVideo)
+	adminCourses.Delete("/video/:videoId", courseHandler.DeleteVideo)
 	adminCourses.Post("/give-access", courseHandler.GiveAccess)
 	adminCourses.Post("/take-away-access", courseHandler.TakeAwayAccess)
+	adminCourses.Get("/enrollments", courseHandler.GetEnrollments)
+	adminCourses.Post("/access/import", courseHandler.ImportAccess)
+
+	adminCourses.Post("/:id/modules", moduleHandler.CreateModule)
+	adminCourses.Put("/:id/modules/order", moduleHandler.ReorderModules)
+	adminCourses.Put("/module/:moduleId", moduleHandler.RenameModule)
+	adminCourses.Delete("/module/:moduleId", moduleHandler.DeleteModule)
+	adminCourses.Post("/module/:moduleId/lessons", moduleHandler.CreateLesson)
+	adminCourses.Put("/module/:moduleId/lessons/order", moduleHandler.ReorderLessons)
+	adminCourses.Put("/lesson/:lessonId", moduleHandler.UpdateLesson)
+	adminCourses.Put("/lesson/:lessonId/unlock", moduleHandler.SetUnlock)
+	adminCourses.Post("/lesson/:lessonId/attachments", moduleHandler.AddAttachment)
+	adminCourses.Delete("/attachment/:attachmentId", moduleHandler.DeleteAttachment)
+
+	adminCourses.Get("/:id/plans", planHandler.GetPlans)
+	adminCourses.Post("/:id/plans", planHandler.CreatePlan)
+	adminCourses.Put("/plan/:planId", planHandler.UpdatePlan)
+	adminCourses.Delete("/plan/:planId", planHandler.DeletePlan)
+
+	adminCourses.Get("/:id/cohorts", cohortHandler.GetCohorts)
+	adminCourses.Post("/:id/cohorts", cohortHandler.CreateCohort)
+	adminCourses.Put("/cohort/:cohortId", cohortHandler.UpdateCohort)
+	adminCourses.Delete("/cohort/:cohortId", cohortHandler.DeleteCohort)
+	publicCourses.Get("/:id/cohorts", cohortHandler.GetCourseCohorts)
+	review.Get("/cohorts", cohortHandler.GetMyCohorts)
+	review.Get("/cohort/:cohortId/learners", cohortHandler.GetLearners)
+
+	adminCourses.Get("/:id/webinars", webinarHandler.GetCourseWebinars)
+	adminCourses.Post("/:id/webinars", webinarHandler.CreateWebinars)
+	adminCourses.Put("/webinar/:webinarId", webinarHandler.UpdateWebinar)
+	adminCourses.Post("/webinar/:webinarId/cancel", webinarHandler.CancelWebinar)
+
+	authorizedGroup.Get("/calendar", calendarHandler.GetCalendarURL)
+	authorizedGroup.Post("/calendar/reset", calendarHandler.ResetCalendarURL)
+	v1.Get("/calendar/:token", calendarHandler.Feed)
+
+	adminCourses.Get("/:id/quizzes", quizHandler.GetCourseQuizzesForAdmin)
+	adminCourses.Post("/:id/quizzes", quizHandler.CreateQuiz)
+	adminCourses.Get("/quiz/:quizId", quizHandler.GetQuizForAdmin)
+	adminCourses.Put("/quiz/:quizId", quizHandler.UpdateQuiz)
+	adminCourses.Delete("/quiz/:quizId", quizHandler.DeleteQuiz)
+	adminCourses.Get("/quiz/:quizId/attempts", quizHandler.GetAllAttempts)
+	courses.Get("/:id/quizzes", quizHandler.GetCourseQuizzes)
+	courses.Get("/quiz/:quizId", quizHandler.GetQuiz)
+	courses.Post("/quiz/:quizId/attempts", quizHandler.SubmitAttempt)
+	courses.Get("/quiz/:quizId/attempts", quizHandler.GetAttempts)
+
+	adminCourses.Get("/:id/assignments", homeworkHandler.GetCourseAssignmentsForAdmin)
+	adminCourses.Post("/:id/assignments", homeworkHandler.CreateAssignment)
+	adminCourses.Put("/assignment/:assignmentId", homeworkHandler.UpdateAssignment)
+	adminCourses.Delete("/assignment/:assignmentId", homeworkHandler.DeleteAssignment)
+	courses.Get("/:id/assignments", homeworkHandler.GetCourseAssignments)
+	courses.Post("/assignment/:assignmentId/submissions", homeworkHandler.Submit)
+	courses.Get("/assignment/:assignmentId/submissions", homeworkHandler.GetMySubmissions)
+	review.Get("/submissions", homeworkHandler.GetSubmissions)
+	review.Get("/submission/:submissionId", homeworkHandler.GetSubmission)
+	review.Post("/submission/:submissionId", homeworkHandler.Review)
+
+	orders.Post("", orderHandler.Checkout)
+	orders.Get("", orderHandler.GetMyOrders)
+	orders.Get("/:id", orderHandler.GetMyOrder)
+	adminOrders.Get("", orderHandler.GetOrders)
+	adminOrders.Post("/:id/refund", orderHandler.Refund)
+	courses.Get("/:id/price", orderHandler.GetPrice)
+	authorizedGroup.Get("/bundles/:id/price", orderHandler.GetBundlePrice)
+	payments.Post("/webhook", orderHandler.Webhook)
+	if mockPayments {
+		payments.Post("/mock/:paymentId", orderHandler.MockPay)
+	}
+
+	bundles.Get("", bundleHandler.GetBundles)
+	bundles.Get("/:id", bundleHandler.GetBundle)
+	adminBundles.Get("", bundleHandler.GetBundles)
+	adminBundles.Post("", bundleHandler.CreateBundle)
+	adminBundles.Get("/:id", bundleHandler.GetBundle)
+	adminBundles.Put("/:id", bundleHandler.UpdateBundle)
+	adminBundles.Delete("/:id", bundleHandler.DeleteBundle)
+	adminBundles.Post("/:id/grant", bundleHandler.Grant)
+
+	paths.Get("", pathHandler.GetPaths)
+	paths.Get("/:id", pathHandler.GetPath)
+	authorizedGroup.Get("/paths", pathHandler.GetMyPaths)
+	adminPaths.Get("", pathHandler.GetPaths)
+	adminPaths.Post("", pathHandler.CreatePath)
+	adminPaths.Get("/:id", pathHandler.GetPath)
+	adminPaths.Put("/:id", pathHandler.UpdatePath)
+	adminPaths.Delete("/:id", pathHandler.DeletePath)
+
+	adminPromo.Get("", promoHandler.GetPromos)
+	adminPromo.Post("", promoHandler.CreatePromo)
+	adminPromo.Put("/:id", promoHandler.UpdatePromo)
+	adminPromo.Delete("/:id", promoHandler.DeletePromo)
+
+	adminVouchers.Get("", voucherHandler.GetBatches)
+	adminVouchers.Post("", voucherHandler.CreateBatch)
+	adminVouchers.Get("/:id", voucherHandler.GetBatch)
+	adminVouchers.Get("/:id/export", voucherHandler.ExportBatch)
+	adminVouchers.Delete("/:id", voucherHandler.DeleteBatch)
+	courses.Post("/redeem", voucherHandler.Redeem)
+
+	courses.Post("/:id/access-request", accessRequestHandler.RequestAccess)
+	courses.Get("/access-requests", accessRequestHandler.GetMyRequests)
+	adminAccessRequests.Get("", accessRequestHandler.GetRequests)
+	adminAccessRequests.Post("/:id/approve", accessRequestHandler.Approve)
+	adminAccessRequests.Post("/:id/reject", accessRequestHandler.Reject)
+
+	publicCourses.Get("/:id/reviews", reviewHandler.GetCourseReviews)
+	courses.Get("/:id/review", reviewHandler.GetMyReview)
+	courses.Post("/:id/review", reviewHandler.Submit)
+	courses.Delete("/:id/review", reviewHandler.DeleteMyReview)
+	adminReviews.Get("", reviewHandler.GetReviews)
+	adminReviews.Post("/:id/approve", reviewHandler.Approve)
+	adminReviews.Post("/:id/reject", reviewHandler.Reject)
+	adminReviews.Delete("/:id", reviewHandler.DeleteReview)
+
+	courses.Get("/:id/diploma", diplomaHandler.GetDiploma)
+	adminCourses.Get("/:id/diploma-fields", diplomaHandler.GetFields)
+	adminCourses.Put("/:id/diploma-fields", diplomaHandler.SetFields)
+
+	certificates.Get("/verify/:code", certificateHandler.Verify)
+	adminCertificates.Get("/get", certificateHandler.GetAllCertificates)
+	adminCertificates.Post("/revoke/:code", certificateHandler.Revoke)
+
+	courses.Post("/progress", progressHandler.Heartbeat)
+	adminCourses.Get("/:id/progress", progressHandler.GetCourseReport)
+
+	authorizedMedia.Get("/:kind/:id", mediaHandler.ServeMedia)
+	authorizedMedia.Get("/:kind/:id/url", mediaHandler.GetSignedURL)
+	media.Get("/:kind/:id", mediaHandler.ServeSignedMedia)
+
+	adminUploads.Options("", uploadHandler.Options)
+	adminUploads.Post("", uploadHandler.CreateUpload)
+	adminUploads.Head("/:id", uploadHandler.HeadUpload)
+	adminUploads.Get("/:id", uploadHandler.GetUpload)
+	adminUploads.Patch("/:id", uploadHandler.PatchUpload)
+	adminUploads.Delete("/:id", uploadHandler.DeleteUpload)
 
 	log.Debug("All routes were initialized")
 }
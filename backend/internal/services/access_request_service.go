@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/notify"
+)
+
+var (
+	ErrInvalidAccessRequest = errors.New("invalid access request")
+	ErrAccessRequestDecided = errors.New("access request is already processed")
+)
+
+type AccessRequestService struct {
+	repo          *postgres.AccessRequestRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	sender        notify.Sender
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewAccessRequestService(repo *postgres.AccessRequestRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService, sender notify.Sender) *AccessRequestService {
+	return &AccessRequestService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		sender:        sender,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// CreateRequest stores a pending request. receiptPath is the already saved receipt, if any.
+func (s *AccessRequestService) CreateRequest(userID, courseID int, contact, comment, receiptPath string) (structures.AccessRequest, error) {
+	const op = "service.access_request_service.CreateRequest"
+	log := s.log.With("op", op)
+
+	contact = strings.TrimSpace(contact)
+	if contact == "" || utf8.RuneCountInString(contact) > 255 {
+		return structures.AccessRequest{}, fmt.Errorf("%w: contact is required (up to 255 characters)", ErrInvalidAccessRequest)
+	}
+
+	course, err := s.courseRepo.SelectCourseById(courseID)
+	if err != nil || course.Status != structures.CoursePublished {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return structures.AccessRequest{}, ErrCourseNotFound
+	}
+
+	hasAccess, err := s.courseService.HasAccess(userID, courseID)
+	if err != nil {
+		return structures.AccessRequest{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if hasAccess {
+		return structures.AccessRequest{}, ErrAlreadyHasAccess
+	}
+
+	id, err := s.repo.InsertRequest(structures.AccessRequest{
+		UserID:      userID,
+		CourseID:    courseID,
+		Contact:     contact,
+		Comment:     strings.TrimSpace(comment),
+		ReceiptPath: receiptPath,
+	})
+	if err != nil {
+		return structures.AccessRequest{}, err
+	}
+
+	req, err := s.repo.SelectRequestById(id)
+	if err != nil {
+		return req, err
+	}
+	signReceipt(s.cfg, &req, userID)
+
+	return req, nil
+}
+
+func (s *AccessRequestService) GetMyRequests(userID int) ([]structures.AccessRequest, error) {
+	requests, err := s.repo.SelectRequests(structures.AccessRequestFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range requests {
+		signReceipt(s.cfg, &requests[i], userID)
+	}
+
+	return requests, nil
+}
+
+// GetRequests returns the admin queue, receipt links are signed for the admin
+func (s *AccessRequestService) GetRequests(filter structures.AccessRequestFilter, adminID int) ([]structures.AccessRequest, error) {
+	requests, err := s.repo.SelectRequests(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range requests {
+		signReceipt(s.cfg, &requests[i], adminID)
+	}
+
+	return requests, nil
+}
+
+// Approve closes the request and gives the user access to the course. The request is claimed first,
+// so of two admins approving it at once only one grants access.
+func (s *AccessRequestService) Approve(id, adminID int, decision structures.AccessDecision) error {
+	const op = "service.access_request_service.Approve"
+	log := s.log.With("op", op)
+
+	req, err := s.repo.SelectRequestById(id)
+	if err != nil {
+		return err
+	}
+
+	decided, err := s.repo.Decide(id, structures.AccessRequestApproved, strings.TrimSpace(decision.Comment), adminID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !decided {
+		return ErrAccessRequestDecided
+	}
+
+	err = s.courseService.GiveAccess(structures.Enrollment{
+		UserID:    req.UserID,
+		CourseID:  req.CourseID,
+		CohortID:  decision.CohortID,
+		Source:    structures.EnrollmentManual,
+		GrantedBy: adminID,
+		ExpiresAt: decision.ExpiresAt,
+	})
+	if err != nil {
+		if err := s.repo.Reopen(id); err != nil {
+			log.Error("failed to reopen access request", slog.Int("id", id), slog.Any("err", err))
+		}
+		return err
+	}
+
+	s.notify(log, req, structures.AccessRequestApproved, decision.Comment)
+	return nil
+}
+
+func (s *AccessRequestService) Reject(id, adminID int, decision structures.AccessDecision) error {
+	const op = "service.access_request_service.Reject"
+	log := s.log.With("op", op)
+
+	req, err := s.repo.SelectRequestById(id)
+	if err != nil {
+		return err
+	}
+
+	decided, err := s.repo.Decide(id, structures.AccessRequestRejected, strings.TrimSpace(decision.Comment), adminID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !decided {
+		return ErrAccessRequestDecided
+	}
+
+	s.notify(log, req, structures.AccessRequestRejected, decision.Comment)
+	return nil
+}
+
+func (s *AccessRequestService) notify(log *slog.Logger, req structures.AccessRequest, status, comment string) {
+	subject := "Доступ к курсу открыт"
+	body := fmt.Sprintf("Заявка на курс «%s» одобрена, курс доступен в личном кабинете.", req.CourseTitle)
+	if status == structures.AccessRequestRejected {
+		subject = "Заявка на доступ отклонена"
+		body = fmt.Sprintf("Заявка на курс «%s» отклонена.", req.CourseTitle)
+	}
+	if comment = strings.TrimSpace(comment); comment != "" {
+		body += "\n\n" + comment
+	}
+
+	err := s.sender.Send(notify.Message{
+		UserID:   req.UserID,
+		Username: req.Username,
+		Subject:  subject,
+		Body:     body,
+	})
+	if err != nil {
+		// the decision is saved, the user will still see it in the course list
+		log.Warn("failed to notify user", slog.Int("user_id", req.UserID), slog.Any("err", err))
+	}
+}
+
+func signReceipt(cfg *config.Config, req *structures.AccessRequest, userID int) {
+	if req.ReceiptPath != "" {
+		req.ReceiptURL, _ = signMediaURL(cfg, MediaReceipt, req.Id, userID)
+	}
+}
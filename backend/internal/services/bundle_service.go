@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+type BundleService struct {
+	repo          *postgres.BundleRepo
+	courseRepo    *postgres.CourseRepo
+	planRepo      *postgres.PlanRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewBundleService(repo *postgres.BundleRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, planRepo *postgres.PlanRepo, courseService *CourseService) *BundleService {
+	return &BundleService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		planRepo:      planRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+func (s *BundleService) validate(req structures.BundleRequest) (structures.Bundle, error) {
+	b := structures.Bundle{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Cost:        req.Cost,
+		Active:      req.Active == nil || *req.Active,
+		Courses:     req.Courses,
+	}
+	if b.Title == "" {
+		return b, fmt.Errorf("%w: title is required", ErrInvalidBundle)
+	}
+	if b.Cost < 0 {
+		return b, fmt.Errorf("%w: cost can't be negative", ErrInvalidBundle)
+	}
+	if len(b.Courses) == 0 {
+		return b, fmt.Errorf("%w: add at least one course", ErrInvalidBundle)
+	}
+
+	seen := make(map[int]bool, len(b.Courses))
+	for _, c := range b.Courses {
+		if seen[c.CourseID] {
+			return b, fmt.Errorf("%w: course %d is listed twice", ErrInvalidBundle, c.CourseID)
+		}
+		seen[c.CourseID] = true
+
+		if _, err := s.courseRepo.SelectCourseById(c.CourseID); err != nil {
+			return b, fmt.Errorf("%w: course %d is not found", ErrInvalidBundle, c.CourseID)
+		}
+		if c.PlanID != 0 {
+			plan, err := s.planRepo.SelectPlan(c.PlanID)
+			if err != nil || plan.CourseID != c.CourseID {
+				return b, fmt.Errorf("%w: plan %d is not a plan of course %d", ErrInvalidBundle, c.PlanID, c.CourseID)
+			}
+		}
+	}
+
+	return b, nil
+}
+
+func (s *BundleService) CreateBundle(req structures.BundleRequest) (structures.Bundle, error) {
+	b, err := s.validate(req)
+	if err != nil {
+		return b, err
+	}
+
+	id, err := s.repo.InsertBundle(b)
+	if err != nil {
+		return b, err
+	}
+
+	return s.repo.SelectBundle(id)
+}
+
+// UpdateBundle changes what the bundle sells from now on, access already bought stays as it was
+func (s *BundleService) UpdateBundle(id int, req structures.BundleRequest) (structures.Bundle, error) {
+	b, err := s.validate(req)
+	if err != nil {
+		return b, err
+	}
+	b.Id = id
+
+	if err := s.repo.UpdateBundle(b); err != nil {
+		return b, err
+	}
+
+	return s.repo.SelectBundle(id)
+}
+
+func (s *BundleService) DeleteBundle(id int) error {
+	return s.repo.DeleteBundle(id)
+}
+
+// GetBundles returns bundles on sale, or every bundle for admins
+func (s *BundleService) GetBundles(all bool) ([]structures.Bundle, error) {
+	return s.repo.SelectBundles(!all)
+}
+
+func (s *BundleService) GetBundle(id int, all bool) (structures.Bundle, error) {
+	b, err := s.repo.SelectBundle(id)
+	if err != nil {
+		return b, err
+	}
+	if !all && !b.Active {
+		return structures.Bundle{}, postgres.ErrBundleNotFound
+	}
+
+	return b, nil
+}
+
+// Covered reports whether the user already has everything the bundle gives
+func (s *BundleService) Covered(userID, bundleID int) (bool, error) {
+	b, err := s.repo.SelectBundle(bundleID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, c := range b.Courses {
+		covered, err := s.courseService.CoversPlan(userID, c.CourseID, c.PlanID)
+		if err != nil || !covered {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// CheckCohorts validates the cohorts chosen for a bundle: every course the user still has to get
+// that runs in cohorts needs a seat in one of cohortIDs
+func (s *BundleService) CheckCohorts(userID, bundleID int, cohortIDs []int) error {
+	b, err := s.repo.SelectBundle(bundleID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range b.Courses {
+		covered, err := s.courseService.CoversPlan(userID, c.CourseID, c.PlanID)
+		if err != nil {
+			return err
+		}
+		if covered {
+			continue
+		}
+
+		cohortID, err := s.courseService.CohortFor(c.CourseID, cohortIDs)
+		if err != nil {
+			return err
+		}
+		if err := s.courseService.CheckCohort(userID, c.CourseID, cohortID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Grant gives the user every course of the bundle on the terms of e (source, granting admin, expiry),
+// courses that run in cohorts go with their cohort out of cohortIDs.
+// Courses the user already has on the same or a bigger plan are left as they are.
+func (s *BundleService) Grant(bundleID int, e structures.Enrollment, cohortIDs []int) error {
+	const op = "service.bundle_service.Grant"
+	log := s.log.With("op", op)
+
+	b, err := s.repo.SelectBundle(bundleID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range b.Courses {
+		covered, err := s.courseService.CoversPlan(e.UserID, c.CourseID, c.PlanID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if covered {
+			continue
+		}
+
+		e.CourseID, e.PlanID = c.CourseID, c.PlanID
+		e.CohortID, err = s.courseService.CohortFor(c.CourseID, cohortIDs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := s.courseService.GiveAccess(e); err != nil {
+			log.Error("failed to give access", slog.Int("bundle_id", bundleID), slog.Int("course_id", c.CourseID), slog.Any("err", err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("bundle granted", slog.Int("bundle_id", bundleID), slog.Int("user_id", e.UserID))
+	return nil
+}
+
+// Revoke takes away the courses granted by a refunded bundle order, courses the user got another way are kept
+func (s *BundleService) Revoke(bundleID, orderID, userID int) error {
+	const op = "service.bundle_service.Revoke"
+
+	b, err := s.repo.SelectBundle(bundleID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range b.Courses {
+		if err := s.courseService.TakeAwayOrderAccess(orderID, userID, c.CourseID); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
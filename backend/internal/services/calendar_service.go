@@ -0,0 +1,112 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/ical"
+)
+
+var ErrCalendarNotFound = errors.New("calendar not found")
+
+type CalendarService struct {
+	userRepo    *postgres.UserRepo
+	webinarRepo *postgres.WebinarRepo
+	log         *slog.Logger
+	cfg         *config.Config
+}
+
+func NewCalendarService(userRepo *postgres.UserRepo, log *slog.Logger, cfg *config.Config, webinarRepo *postgres.WebinarRepo) *CalendarService {
+	return &CalendarService{
+		userRepo:    userRepo,
+		webinarRepo: webinarRepo,
+		log:         log,
+		cfg:         cfg,
+	}
+}
+
+// Token returns the secret of the user's calendar feed, creating it on first use
+func (s *CalendarService) Token(userID int) (string, error) {
+	const op = "service.calendar_service.Token"
+
+	token, err := s.userRepo.GetCalendarToken(userID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	return s.ResetToken(userID)
+}
+
+// ResetToken replaces the secret, so a leaked feed link stops working
+func (s *CalendarService) ResetToken(userID int) (string, error) {
+	const op = "service.calendar_service.ResetToken"
+	log := s.log.With("op", op)
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	token := hex.EncodeToString(b)
+
+	if err := s.userRepo.SetCalendarToken(userID, token); err != nil {
+		log.Error("failed to save calendar token", slog.Int("user_id", userID), slog.Any("err", err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// Feed renders the iCalendar feed with webinars of every course the token owner has access to
+func (s *CalendarService) Feed(token string) ([]byte, error) {
+	const op = "service.calendar_service.Feed"
+	log := s.log.With("op", op)
+
+	userID, err := s.userRepo.GetUserIdByCalendarToken(token)
+	if err != nil {
+		log.Error("failed to find calendar owner", slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if userID == 0 {
+		return nil, ErrCalendarNotFound
+	}
+
+	webinars, err := s.webinarRepo.SelectForUser(userID)
+	if err != nil {
+		log.Error("failed to get webinars", slog.Int("user_id", userID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	events := make([]ical.Event, 0, len(webinars))
+	for _, w := range webinars {
+		events = append(events, webinarEvent(w))
+	}
+
+	return ical.Calendar("Вебинары Bala", events), nil
+}
+
+func webinarEvent(w structures.CalendarWebinar) ical.Event {
+	summary := w.Title
+	if summary == "" {
+		summary = "Вебинар: " + w.CourseTitle
+	}
+
+	return ical.Event{
+		UID:         fmt.Sprintf("webinar-%d@bala", w.Id),
+		Summary:     summary,
+		Description: fmt.Sprintf("Курс: %s\n%s", w.CourseTitle, w.Link),
+		URL:         w.Link,
+		Start:       w.Date,
+		End:         w.Date.Add(time.Duration(w.Duration) * time.Minute),
+		Cancelled:   w.Status == structures.WebinarCancelled,
+	}
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/codegen"
+	"github.com/lib/pq"
+)
+
+var ErrCertificateRevoked = errors.New("certificate has been revoked")
+
+// Attempts to pick a free code before giving up; with 16 random characters a collision is practically impossible
+const certificateCodeAttempts = 3
+
+type CertificateService struct {
+	repo *postgres.CertificateRepo
+	log  *slog.Logger
+	cfg  *config.Config
+}
+
+func NewCertificateService(repo *postgres.CertificateRepo, log *slog.Logger, cfg *config.Config) *CertificateService {
+	return &CertificateService{
+		repo: repo,
+		log:  log,
+		cfg:  cfg,
+	}
+}
+
+// IssueCertificate returns the user's certificate for the course, registering a new one on the first call
+func (s *CertificateService) IssueCertificate(userID, courseID int, holderName, courseTitle string) (structures.Certificate, error) {
+	const op = "service.certificate_service.IssueCertificate"
+	log := s.log.With("op", op)
+
+	cert, err := s.repo.SelectByUserAndCourse(userID, courseID)
+	if err == nil {
+		return cert, nil
+	}
+	if !errors.Is(err, postgres.ErrCertificateNotFound) {
+		return cert, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := 0; i < certificateCodeAttempts; i++ {
+		code, err := codegen.Generate(4, 4)
+		if err != nil {
+			log.Error("failed to generate certificate code", slog.Any("err", err))
+			return cert, fmt.Errorf("%s: %w", op, err)
+		}
+
+		cert, err = s.repo.InsertCertificate(structures.Certificate{
+			Code:        code,
+			UserID:      userID,
+			CourseID:    courseID,
+			HolderName:  holderName,
+			CourseTitle: courseTitle,
+		})
+		if err == nil {
+			return cert, nil
+		}
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			log.Warn("certificate code collision, retrying", slog.String("code", code))
+			continue
+		}
+
+		return cert, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return cert, fmt.Errorf("%s: failed to generate a unique certificate code", op)
+}
+
+func (s *CertificateService) Verify(code string) (structures.CertificateVerification, error) {
+	const op = "service.certificate_service.Verify"
+
+	cert, err := s.repo.SelectByCode(codegen.Normalize(code))
+	if err != nil {
+		if errors.Is(err, postgres.ErrCertificateNotFound) {
+			return structures.CertificateVerification{}, err
+		}
+		return structures.CertificateVerification{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return structures.CertificateVerification{
+		Valid:       cert.RevokedAt == nil,
+		Code:        cert.Code,
+		HolderName:  cert.HolderName,
+		CourseTitle: cert.CourseTitle,
+		IssuedAt:    cert.IssuedAt,
+		RevokedAt:   cert.RevokedAt,
+	}, nil
+}
+
+func (s *CertificateService) Revoke(code, reason string) error {
+	const op = "service.certificate_service.Revoke"
+	log := s.log.With("op", op)
+
+	if err := s.repo.RevokeCertificate(codegen.Normalize(code), reason); err != nil {
+		if errors.Is(err, postgres.ErrCertificateNotFound) {
+			return err
+		}
+		log.Error("failed to revoke certificate", slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *CertificateService) GetAll() ([]structures.Certificate, error) {
+	const op = "service.certificate_service.GetAll"
+	log := s.log.With("op", op)
+
+	certificates, err := s.repo.SelectAll()
+	if err != nil {
+		log.Error("failed to get certificates", slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return certificates, nil
+}
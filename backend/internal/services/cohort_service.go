@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var ErrInvalidCohort = errors.New("invalid cohort")
+
+type CohortService struct {
+	repo           *postgres.CohortRepo
+	courseRepo     *postgres.CourseRepo
+	userRepo       *postgres.UserRepo
+	enrollmentRepo *postgres.EnrollmentRepo
+	courseService  *CourseService
+	log            *slog.Logger
+	cfg            *config.Config
+}
+
+func NewCohortService(repo *postgres.CohortRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, userRepo *postgres.UserRepo, enrollmentRepo *postgres.EnrollmentRepo, courseService *CourseService) *CohortService {
+	return &CohortService{
+		repo:           repo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+		enrollmentRepo: enrollmentRepo,
+		courseService:  courseService,
+		log:            log,
+		cfg:            cfg,
+	}
+}
+
+// validate checks the cohort fields and that every curator has the curator or admin role
+func (s *CohortService) validate(req structures.CohortRequest) (structures.Cohort, error) {
+	c := structures.Cohort{
+		Title:    strings.TrimSpace(req.Title),
+		StartsAt: req.StartsAt.UTC(),
+		Capacity: req.Capacity,
+		Curators: []structures.CohortCurator{},
+	}
+	if c.Title == "" {
+		return c, fmt.Errorf("%w: title is required", ErrInvalidCohort)
+	}
+	if req.StartsAt.IsZero() {
+		return c, fmt.Errorf("%w: starts_at is required", ErrInvalidCohort)
+	}
+	if c.Capacity < 0 {
+		return c, fmt.Errorf("%w: capacity can't be negative", ErrInvalidCohort)
+	}
+
+	var seen []int
+	for _, id := range req.CuratorIDs {
+		if slices.Contains(seen, id) {
+			continue
+		}
+		seen = append(seen, id)
+
+		user, err := s.userRepo.GetUserById(id)
+		if err != nil {
+			return c, fmt.Errorf("%w: user %d is not found", ErrInvalidCohort, id)
+		}
+		if user.Role != structures.RoleCurator && user.Role != structures.RoleAdmin {
+			return c, fmt.Errorf("%w: user %d is not a curator", ErrInvalidCohort, id)
+		}
+		c.Curators = append(c.Curators, structures.CohortCurator{UserID: id, Username: user.Username})
+	}
+
+	return c, nil
+}
+
+func (s *CohortService) CreateCohort(courseID int, req structures.CohortRequest) (structures.Cohort, error) {
+	const op = "service.cohort_service.CreateCohort"
+	log := s.log.With("op", op)
+
+	c, err := s.validate(req)
+	if err != nil {
+		return c, err
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return c, ErrCourseNotFound
+	}
+	c.CourseID = courseID
+
+	c.Id, err = s.repo.InsertCohort(c)
+	if err != nil {
+		return c, err
+	}
+
+	return s.repo.SelectCohort(c.Id)
+}
+
+// UpdateCohort replaces the cohort fields and curators. Lowering the capacity below the number
+// of learners keeps them in the cohort and only closes new seats.
+func (s *CohortService) UpdateCohort(id int, req structures.CohortRequest) (structures.Cohort, error) {
+	c, err := s.validate(req)
+	if err != nil {
+		return c, err
+	}
+	c.Id = id
+
+	if err := s.repo.UpdateCohort(c); err != nil {
+		return c, err
+	}
+
+	return s.repo.SelectCohort(id)
+}
+
+func (s *CohortService) DeleteCohort(id int) error {
+	return s.repo.DeleteCohort(id)
+}
+
+// GetCohorts returns every cohort of the course with its curators, for admins
+func (s *CohortService) GetCohorts(courseID int) ([]structures.Cohort, error) {
+	return s.repo.SelectCohorts(courseID)
+}
+
+// GetCourseCohorts returns the cohorts of a published course to choose from at checkout, curators hidden
+func (s *CohortService) GetCourseCohorts(courseID int) ([]structures.Cohort, error) {
+	course, err := s.courseRepo.SelectCourseById(courseID)
+	if err != nil || course.Status != structures.CoursePublished {
+		return nil, ErrCourseNotFound
+	}
+
+	cohorts, err := s.repo.SelectCohorts(courseID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range cohorts {
+		cohorts[i].Curators = nil
+	}
+
+	return cohorts, nil
+}
+
+// GetMyCohorts returns the cohorts the curator is assigned to
+func (s *CohortService) GetMyCohorts(curatorID int) ([]structures.Cohort, error) {
+	return s.repo.SelectByCurator(curatorID)
+}
+
+// GetLearners lists the learners of the cohort to admins and its curators
+func (s *CohortService) GetLearners(cohortID, reviewerID int) ([]structures.Enrollment, error) {
+	cohort, err := s.repo.SelectCohort(cohortID)
+	if err != nil {
+		return nil, err
+	}
+
+	curator := slices.ContainsFunc(cohort.Curators, func(c structures.CohortCurator) bool { return c.UserID == reviewerID })
+	if !curator {
+		admin, err := s.courseService.IsAdmin(reviewerID)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, ErrNoAccess
+		}
+	}
+
+	return s.enrollmentRepo.Select(structures.EnrollmentFilter{CohortID: cohortID})
+}
@@ -1,8 +1,17 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/QwaQ-dev/bala/internal/config"
 	"github.com/QwaQ-dev/bala/internal/repository/postgres"
@@ -10,19 +19,42 @@ import (
 	"github.com/QwaQ-dev/bala/pkg/sl"
 )
 
+var (
+	ErrNoAccess            = errors.New("user has no access to this course")
+	ErrInvalidAccess       = errors.New("invalid access grant")
+	ErrInvalidCourseStatus = errors.New("invalid course status")
+	ErrFeatureNotIncluded  = errors.New("this feature is not included in your plan")
+	ErrCohortRequired      = errors.New("this course runs in cohorts, choose one")
+	ErrCohortFull          = errors.New("no seats left in this cohort")
+)
+
 type CourseService struct {
-	repo     *postgres.CourseRepo
-	userRepo *postgres.UserRepo
-	log      *slog.Logger
-	cfg      *config.Config
+	repo           *postgres.CourseRepo
+	userRepo       *postgres.UserRepo
+	progressRepo   *postgres.ProgressRepo
+	quizRepo       *postgres.QuizRepo
+	enrollmentRepo *postgres.EnrollmentRepo
+	requestRepo    *postgres.AccessRequestRepo
+	planRepo       *postgres.PlanRepo
+	pathRepo       *postgres.PathRepo
+	cohortRepo     *postgres.CohortRepo
+	log            *slog.Logger
+	cfg            *config.Config
 }
 
-func NewCourseService(repo *postgres.CourseRepo, log *slog.Logger, cfg *config.Config, userRepo *postgres.UserRepo) *CourseService {
+func NewCourseService(repo *postgres.CourseRepo, log *slog.Logger, cfg *config.Config, userRepo *postgres.UserRepo, progressRepo *postgres.ProgressRepo, quizRepo *postgres.QuizRepo, enrollmentRepo *postgres.EnrollmentRepo, requestRepo *postgres.AccessRequestRepo, planRepo *postgres.PlanRepo, pathRepo *postgres.PathRepo, cohortRepo *postgres.CohortRepo) *CourseService {
 	return &CourseService{
-		repo:     repo,
-		userRepo: userRepo,
-		log:      log,
-		cfg:      cfg,
+		repo:           repo,
+		userRepo:       userRepo,
+		progressRepo:   progressRepo,
+		quizRepo:       quizRepo,
+		enrollmentRepo: enrollmentRepo,
+		requestRepo:    requestRepo,
+		planRepo:       planRepo,
+		pathRepo:       pathRepo,
+		cohortRepo:     cohortRepo,
+		log:            log,
+		cfg:            cfg,
 	}
 }
 
@@ -31,6 +63,15 @@ func (s *CourseService) CreateCourse(course structures.Course) (int, error) {
 	log := s.log.With("op", op)
 	log.Info("Creating course", slog.String("title", course.Title))
 
+	if course.Status == "" {
+		course.Status = structures.CourseDraft
+	}
+	publishAt, err := validateCourseStatus(course.Status, course.PublishAt, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	course.PublishAt = publishAt
+
 	courseID, err := s.repo.InsertCourse(course)
 	if err != nil {
 		log.Error("failed to create course", slog.String("op", op), slog.Any("err", err))
@@ -41,32 +82,111 @@ func (s *CourseService) CreateCourse(course structures.Course) (int, error) {
 	return courseID, nil
 }
 
-func (s *CourseService) GetCourseByID(courseID, userID int) (structures.Course, error) {
-	const op = "service.course_service.GetCourseByID"
+// validateCourseStatus returns the publish time to store with the status
+func validateCourseStatus(status string, publishAt *time.Time, now time.Time) (*time.Time, error) {
+	now = now.UTC()
+	if publishAt != nil {
+		t := publishAt.UTC()
+		publishAt = &t
+	}
+
+	switch status {
+	case structures.CourseDraft:
+		return nil, nil
+	case structures.CourseScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return nil, fmt.Errorf("%w: scheduled course needs publish_at in the future", ErrInvalidCourseStatus)
+		}
+		return publishAt, nil
+	case structures.CoursePublished:
+		if publishAt == nil || publishAt.After(now) {
+			return &now, nil
+		}
+		return publishAt, nil
+	case structures.CourseArchived:
+		return publishAt, nil
+	default:
+		return nil, fmt.Errorf("%w: status must be draft, scheduled, published or archived", ErrInvalidCourseStatus)
+	}
+}
+
+// SetStatus moves the course through its lifecycle. Archived courses keep their publish time.
+func (s *CourseService) SetStatus(id int, req structures.CourseStatusRequest) error {
+	const op = "service.course_service.SetStatus"
 	log := s.log.With("op", op)
 
-	user, err := s.userRepo.GetUserById(userID)
+	course, err := s.repo.SelectCourseById(id)
 	if err != nil {
-		log.Error("failed to get user by id", slog.Int("user_id", userID), slog.Any("err", err))
-		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+		log.Warn("course not found", slog.Int("course_id", id))
+		return ErrCourseNotFound
 	}
 
-	hasAccess := false
+	publishAt := req.PublishAt
+	if req.Status == structures.CourseArchived || (req.Status == structures.CoursePublished && publishAt == nil) {
+		publishAt = course.PublishAt
+	}
+	publishAt, err = validateCourseStatus(req.Status, publishAt, time.Now())
+	if err != nil {
+		return err
+	}
 
-	if user.Role == "admin" {
-		hasAccess = true
-	} else {
-		for _, id := range user.CourseIDs {
-			if int(id) == courseID {
-				hasAccess = true
-				break
-			}
+	if err := s.repo.UpdateStatus(id, req.Status, publishAt); err != nil {
+		log.Error("failed to update course status", slog.Int("course_id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// visible reports whether a non-admin may see the course: published ones, and archived ones
+// they are still enrolled in. Drafts and scheduled courses stay hidden even from enrolled users.
+func visible(course structures.Course, hasAccess bool) bool {
+	return course.Status == structures.CoursePublished || (hasAccess && course.Status == structures.CourseArchived)
+}
+
+// RunPublisher publishes scheduled courses at their publish time until ctx is cancelled
+func (s *CourseService) RunPublisher(ctx context.Context) {
+	const op = "service.course_service.RunPublisher"
+	log := s.log.With("op", op)
+
+	if s.cfg.Courses.PublishInterval <= 0 {
+		log.Info("scheduled course publishing is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Courses.PublishInterval)
+	defer ticker.Stop()
+
+	for {
+		ids, err := s.repo.PublishDue(time.Now())
+		if err != nil {
+			log.Error("failed to publish scheduled courses", slog.Any("err", err))
 		}
+		for _, id := range ids {
+			log.Info("scheduled course published", slog.Int("course_id", id))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *CourseService) GetCourseByID(courseID, userID int) (structures.Course, error) {
+	const op = "service.course_service.GetCourseByID"
+	log := s.log.With("op", op)
+
+	hasAccess, err := s.HasAccess(userID, courseID)
+	if err != nil {
+		log.Error("failed to check access", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
 	}
 
 	if !hasAccess {
 		log.Warn("user has no access to course", slog.Int("user_id", userID), slog.Int("course_id", courseID))
-		return structures.Course{}, fmt.Errorf("user has no access to this course")
+		return structures.Course{}, ErrNoAccess
 	}
 
 	course, err := s.repo.SelectCourseById(courseID)
@@ -75,9 +195,447 @@ func (s *CourseService) GetCourseByID(courseID, userID int) (structures.Course,
 		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
 	}
 
+	lessons, err := s.progressRepo.SelectLessonProgress(userID, []int{courseID})
+	if err != nil {
+		log.Error("failed to get course progress", slog.Int("course_id", courseID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	videoProgress := make(map[int]structures.VideoProgress, len(lessons))
+	for _, l := range lessons {
+		if l.UpdatedAt != nil {
+			videoProgress[l.VideoID] = l.VideoProgress
+		}
+	}
+	start, drip, err := s.dripStart(userID, courseID)
+	if err != nil {
+		log.Error("failed to get access start", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	course.RequiredCourses, err = s.MissingPrerequisites(userID, courseID)
+	if err != nil {
+		log.Error("failed to check prerequisites", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	decorate := func(v *structures.Video) {
+		if p, ok := videoProgress[v.Id]; ok {
+			v.Progress = &p
+		}
+
+		if len(course.RequiredCourses) > 0 && !v.IsPreview {
+			lockLesson(v)
+			return
+		}
+		if opensAt := lessonOpensAt(*v, start); drip && opensAt != nil && opensAt.After(now) {
+			lockLesson(v)
+			v.AvailableAt = opensAt
+			return
+		}
+		s.signLesson(v, userID)
+	}
+	for i := range course.Videos {
+		decorate(&course.Videos[i])
+	}
+	for i := range course.Modules {
+		for j := range course.Modules[i].Lessons {
+			decorate(&course.Modules[i].Lessons[j])
+		}
+	}
+
+	quizzes, err := s.quizRepo.SelectRequirements(userID, []int{courseID})
+	if err != nil {
+		log.Error("failed to get quiz requirements", slog.Int("course_id", courseID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	progress := summarizeProgress(lessons)
+	applyQuizRequirement(&progress, quizzes[courseID])
+	course.Progress = &progress
+
+	course.Features, _, err = s.Features(userID, courseID)
+	if err != nil {
+		log.Error("failed to get plan features", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if admin, err := s.IsAdmin(userID); err != nil || !admin {
+		cohortID, err := s.enrollmentRepo.Cohort(userID, courseID)
+		if err != nil {
+			log.Error("failed to get cohort", slog.Int("user_id", userID), slog.Any("err", err))
+			return structures.Course{}, fmt.Errorf("%s: %w", op, err)
+		}
+		course.Webinars = cohortWebinars(course.Webinars, cohortID)
+	}
+	if !slices.Contains(course.Features, structures.FeatureWebinars) {
+		for i := range course.Webinars {
+			course.Webinars[i].Link = ""
+		}
+	}
+
 	return course, nil
 }
 
+// ViewCourse returns the full course to users with access. Everyone else, anonymous visitors
+// included (userID 0), gets the syllabus: every lesson with its title and duration, but content
+// and playable links only in preview lessons.
+func (s *CourseService) ViewCourse(courseID, userID int) (structures.Course, bool, error) {
+	const op = "service.course_service.ViewCourse"
+	log := s.log.With("op", op)
+
+	hasAccess, err := s.HasAccess(userID, courseID)
+	if err != nil {
+		log.Error("failed to check access", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Course{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if hasAccess {
+		course, err := s.GetCourseByID(courseID, userID)
+		if err != nil {
+			return course, true, err
+		}
+		if !visible(course, true) {
+			// admins have access to every course, drafts included
+			if admin, err := s.IsAdmin(userID); err != nil || !admin {
+				return structures.Course{}, false, ErrCourseNotFound
+			}
+		}
+		// plans stay visible to users with access, so they can move to a bigger one
+		course.Plans, err = s.planRepo.SelectPlans(courseID, true)
+		if err != nil {
+			return structures.Course{}, true, fmt.Errorf("%s: %w", op, err)
+		}
+		return course, true, nil
+	}
+
+	course, err := s.repo.SelectCourseById(courseID)
+	if err != nil || !visible(course, false) {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return structures.Course{}, false, ErrCourseNotFound
+	}
+
+	lock := func(v *structures.Video) {
+		if v.IsPreview {
+			s.signLesson(v, userID)
+			return
+		}
+		lockLesson(v)
+	}
+	for i := range course.Videos {
+		lock(&course.Videos[i])
+	}
+	for i := range course.Modules {
+		for j := range course.Modules[i].Lessons {
+			lock(&course.Modules[i].Lessons[j])
+		}
+	}
+	course.Webinars = cohortWebinars(course.Webinars, 0)
+	for i := range course.Webinars {
+		course.Webinars[i].Link = ""
+	}
+
+	course.Plans, err = s.planRepo.SelectPlans(courseID, true)
+	if err != nil {
+		return structures.Course{}, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return course, false, nil
+}
+
+// cohortWebinars leaves the webinars of the whole course and those of the cohort
+func cohortWebinars(webinars []structures.Webinar, cohortID int) []structures.Webinar {
+	result := make([]structures.Webinar, 0, len(webinars))
+	for _, w := range webinars {
+		if w.CohortID == 0 || w.CohortID == cohortID {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// lockLesson strips everything but the title and duration from a lesson the user can't open yet
+func lockLesson(v *structures.Video) {
+	v.Locked = true
+	v.Path, v.File, v.Content = "", "", ""
+	v.Attachments = nil
+}
+
+// dripStart returns when the user's access to the course started (the cohort start for cohort learners),
+// the point drip schedules count from. drip is false for admins and users without an enrollment:
+// admins see every lesson unlocked.
+func (s *CourseService) dripStart(userID, courseID int) (start time.Time, drip bool, err error) {
+	admin, err := s.IsAdmin(userID)
+	if err != nil || admin {
+		return time.Time{}, false, err
+	}
+
+	startedAt, err := s.enrollmentRepo.StartedAt(userID, courseID)
+	if err != nil || startedAt == nil {
+		return time.Time{}, false, err
+	}
+
+	return *startedAt, true, nil
+}
+
+// lessonOpensAt returns when the lesson opens for access granted at start, nil if it is open right away.
+// With both rules set the later date wins. Preview lessons are never held back.
+func lessonOpensAt(v structures.Video, start time.Time) *time.Time {
+	if v.IsPreview {
+		return nil
+	}
+
+	var opensAt *time.Time
+	if v.UnlockAfterDays != nil && *v.UnlockAfterDays > 0 {
+		t := start.AddDate(0, 0, *v.UnlockAfterDays)
+		opensAt = &t
+	}
+	if v.UnlockAt != nil && (opensAt == nil || v.UnlockAt.After(*opensAt)) {
+		t := *v.UnlockAt
+		opensAt = &t
+	}
+
+	return opensAt
+}
+
+// LessonLocked reports whether the lesson is still closed for the user by its drip schedule
+// or by unfinished prerequisites of a learning path
+func (s *CourseService) LessonLocked(userID int, v structures.Video) (bool, error) {
+	if v.IsPreview {
+		return false, nil
+	}
+
+	missing, err := s.MissingPrerequisites(userID, v.CourseID)
+	if err != nil || len(missing) > 0 {
+		return len(missing) > 0, err
+	}
+	if v.UnlockAfterDays == nil && v.UnlockAt == nil {
+		return false, nil
+	}
+
+	start, drip, err := s.dripStart(userID, v.CourseID)
+	if err != nil || !drip {
+		return false, err
+	}
+
+	opensAt := lessonOpensAt(v, start)
+	return opensAt != nil && opensAt.After(time.Now()), nil
+}
+
+// CheckLessonOpen fails with ErrNoAccess while the lesson is locked for the user, so quizzes and homework
+// of a lesson open together with it
+func (s *CourseService) CheckLessonOpen(userID, videoID int) error {
+	video, err := s.repo.SelectVideoById(videoID)
+	if err != nil {
+		return err
+	}
+
+	locked, err := s.LessonLocked(userID, video)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrNoAccess
+	}
+	return nil
+}
+
+// MissingPrerequisites returns the prerequisites of the course in active learning paths
+// the user hasn't completed yet. Admins don't need any.
+func (s *CourseService) MissingPrerequisites(userID, courseID int) ([]int, error) {
+	required, err := s.pathRepo.SelectPrerequisites(courseID)
+	if err != nil || len(required) == 0 {
+		return nil, err
+	}
+
+	admin, err := s.IsAdmin(userID)
+	if err != nil || admin {
+		return nil, err
+	}
+
+	progress, err := s.Completion(userID, required)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []int
+	for _, id := range required {
+		if !progress[id].Completed {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing, nil
+}
+
+// Completion returns the user's progress in each of the courses, mandatory quizzes included
+func (s *CourseService) Completion(userID int, courseIDs []int) (map[int]structures.CourseProgress, error) {
+	lessons, err := s.progressRepo.SelectLessonProgress(userID, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	quizzes, err := s.quizRepo.SelectRequirements(userID, courseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	lessonsByCourse := make(map[int][]structures.LessonProgress, len(courseIDs))
+	for _, l := range lessons {
+		lessonsByCourse[l.CourseID] = append(lessonsByCourse[l.CourseID], l)
+	}
+
+	result := make(map[int]structures.CourseProgress, len(courseIDs))
+	for _, id := range courseIDs {
+		progress := summarizeProgress(lessonsByCourse[id])
+		applyQuizRequirement(&progress, quizzes[id])
+		result[id] = progress
+	}
+
+	return result, nil
+}
+
+// signLesson fills the short-lived links to the lesson media
+func (s *CourseService) signLesson(v *structures.Video, userID int) {
+	if v.Path != "" {
+		v.StreamURL, _ = signMediaURL(s.cfg, MediaVideo, v.Id, userID)
+	}
+	if v.File != "" {
+		v.FileURL, _ = signMediaURL(s.cfg, MediaFile, v.Id, userID)
+	}
+	for j := range v.Attachments {
+		v.Attachments[j].URL, _ = signMediaURL(s.cfg, MediaAttachment, v.Attachments[j].Id, userID)
+	}
+}
+
+// HasAccess reports whether the user may open the course. Admins have access to every course,
+// others need an enrollment that hasn't expired. Anonymous visitors (userID 0) have none.
+func (s *CourseService) HasAccess(userID, courseID int) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+
+	user, err := s.userRepo.GetUserById(userID)
+	if err != nil {
+		return false, err
+	}
+
+	if user.Role == "admin" {
+		return true, nil
+	}
+
+	return s.enrollmentRepo.IsActive(userID, courseID)
+}
+
+// CanOpen reports whether the user may use the content of the course: lessons, media, quizzes and homework.
+// Besides access, the course has to be visible to them, admins can open every course.
+func (s *CourseService) CanOpen(userID, courseID int) (bool, error) {
+	hasAccess, err := s.HasAccess(userID, courseID)
+	if err != nil || !hasAccess {
+		return false, err
+	}
+	return s.Visible(userID, courseID, true)
+}
+
+// Visible reports whether the user may see the course by its status, admins see every course
+func (s *CourseService) Visible(userID, courseID int, hasAccess bool) (bool, error) {
+	course, err := s.repo.SelectCourseById(courseID)
+	if err != nil {
+		return false, err
+	}
+	if visible(course, hasAccess) {
+		return true, nil
+	}
+	if userID == 0 {
+		return false, nil
+	}
+	return s.IsAdmin(userID)
+}
+
+// Features returns the plan features the user has in the course. Admins and access without
+// a plan have all of them. ok is false when the user has no access.
+func (s *CourseService) Features(userID, courseID int) (features []string, ok bool, err error) {
+	if userID == 0 {
+		return nil, false, nil
+	}
+
+	admin, err := s.IsAdmin(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if admin {
+		return structures.AllFeatures, true, nil
+	}
+
+	return s.enrollmentRepo.Features(userID, courseID)
+}
+
+// RequireFeature fails with ErrNoAccess without access to the course and with ErrFeatureNotIncluded
+// when the user's plan doesn't include the feature
+func (s *CourseService) RequireFeature(userID, courseID int, feature string) error {
+	features, ok, err := s.Features(userID, courseID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoAccess
+	}
+	if !slices.Contains(features, feature) {
+		return ErrFeatureNotIncluded
+	}
+	return nil
+}
+
+// CoversPlan reports whether the user's access already gives everything the plan does,
+// planID 0 stands for full access to the course
+func (s *CourseService) CoversPlan(userID, courseID, planID int) (bool, error) {
+	features, ok, err := s.Features(userID, courseID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	required := structures.AllFeatures
+	if planID != 0 {
+		plan, err := s.planRepo.SelectPlan(planID)
+		if err != nil {
+			return false, err
+		}
+		required = plan.Features
+	}
+	for _, f := range required {
+		if !slices.Contains(features, f) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// IsAdmin reports whether the user has the admin role
+func (s *CourseService) IsAdmin(userID int) (bool, error) {
+	user, err := s.userRepo.GetUserById(userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.Role == structures.RoleAdmin, nil
+}
+
+// CanReview reports whether the user may review the learner's homework in the course:
+// admins review everyone, curators only learners of the cohorts they are assigned to
+func (s *CourseService) CanReview(reviewerID, learnerID, courseID int) (bool, error) {
+	user, err := s.userRepo.GetUserById(reviewerID)
+	if err != nil {
+		return false, err
+	}
+
+	switch user.Role {
+	case structures.RoleAdmin:
+		return true, nil
+	case structures.RoleCurator:
+		return s.cohortRepo.CuratesLearner(reviewerID, learnerID, courseID)
+	}
+	return false, nil
+}
+
 func (s *CourseService) UpdateCourse(course *structures.Course) error {
 	const op = "service.course_service.UpdateCourse"
 	log := s.log.With("op", op)
@@ -90,25 +648,111 @@ func (s *CourseService) UpdateCourse(course *structures.Course) error {
 	return nil
 }
 
-func (s *CourseService) DeleteCourse(id int) error {
+// DeleteCourse deletes the course and returns the uploaded files it used, so they can be removed from disk
+func (s *CourseService) DeleteCourse(id int) ([]string, error) {
 	const op = "service.course_service.DeleteCourse"
 	log := s.log.With("op", op)
 
-	err := s.repo.DeleteCourse(id)
+	files, err := s.repo.SelectCourseFiles(id)
+	if err != nil {
+		log.Error("failed to get course files", slog.Int("id", id), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.repo.DeleteCourse(id)
 	if err != nil {
 		log.Error("failed to delete course", slog.Int("id", id), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return files, nil
+}
+
+// CourseMedia returns the uploaded files a copy of the course needs its own copies of
+func (s *CourseService) CourseMedia(id int) ([]string, error) {
+	const op = "service.course_service.CourseMedia"
+	log := s.log.With("op", op)
+
+	if _, err := s.repo.SelectCourseById(id); err != nil {
+		log.Warn("course not found", slog.Int("course_id", id))
+		return nil, ErrCourseNotFound
+	}
+
+	files, err := s.repo.SelectCourseMedia(id)
+	if err != nil {
+		log.Error("failed to get course media", slog.Int("id", id), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return files, nil
+}
+
+// DuplicateCourse copies the course into a new draft and returns its ID. files maps every path returned
+// by CourseMedia to its copy. Enrollments, cohorts and reviews stay with the original.
+func (s *CourseService) DuplicateCourse(id int, req structures.CourseCopyRequest, files map[string]string) (int, error) {
+	const op = "service.course_service.DuplicateCourse"
+	log := s.log.With("op", op)
+
+	course, err := s.repo.SelectCourseById(id)
+	if err != nil {
+		log.Warn("course not found", slog.Int("course_id", id))
+		return 0, ErrCourseNotFound
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = course.Title + " (копия)"
+	}
+
+	courseID, err := s.repo.CopyCourse(id, title, files)
+	if err != nil {
+		log.Error("failed to copy course", slog.Int("course_id", id), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("course duplicated", slog.Int("source_id", id), slog.Int("id", courseID))
+	return courseID, nil
+}
+
+func (s *CourseService) GetVideo(id int) (structures.Video, error) {
+	return s.repo.SelectVideoById(id)
+}
+
+func (s *CourseService) UpdateVideo(v structures.Video) error {
+	const op = "service.course_service.UpdateVideo"
+	log := s.log.With("op", op)
+
+	if err := s.repo.UpdateVideo(v); err != nil {
+		if errors.Is(err, postgres.ErrVideoNotFound) {
+			return err
+		}
+		log.Error("failed to update video", slog.Int("id", v.Id), slog.Any("err", err))
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (s *CourseService) AddVideoToCourse(courseID int, path string, title, file string) error {
+// DeleteVideo deletes the video and returns the files it used
+func (s *CourseService) DeleteVideo(id int) ([]string, error) {
+	const op = "service.course_service.DeleteVideo"
+	log := s.log.With("op", op)
+
+	files, err := s.repo.DeleteVideo(id)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVideoNotFound) {
+			return nil, err
+		}
+		log.Error("failed to delete video", slog.Int("id", id), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return files, nil
+}
+
+func (s *CourseService) AddVideoToCourse(courseID int, path string, title, file string, duration int) error {
 	const op = "service.course_service.AddVideoToCourse"
 	log := s.log.With("op", op)
 
 	log.Info("adding video to course", slog.Int("course_id", courseID), slog.String("path", path))
 
-	err := s.repo.AddVideoToCourse(courseID, path, title, file)
+	err := s.repo.AddVideoToCourse(courseID, path, title, file, duration)
 	if err != nil {
 		log.Error("failed to add video to course", slog.Any("err", err))
 		return fmt.Errorf("%s: %w", op, err)
@@ -117,7 +761,8 @@ func (s *CourseService) AddVideoToCourse(courseID int, path string, title, file
 	return nil
 }
 
-func (s *CourseService) GetAllCourses() ([]structures.Course, error) {
+// GetAllCourses returns the catalogue: published courses, or every course for admins
+func (s *CourseService) GetAllCourses(all bool) ([]structures.Course, error) {
 	const op = "service.course_service.GetAllCourses"
 	log := s.log.With("op", op)
 
@@ -126,49 +771,155 @@ func (s *CourseService) GetAllCourses() ([]structures.Course, error) {
 		log.Error("failed to get all courses", slog.Any("err", err))
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	if all {
+		return courses, nil
+	}
+
+	published := []structures.Course{}
+	for _, course := range courses {
+		if course.Status == structures.CoursePublished {
+			published = append(published, course)
+		}
+	}
 
-	return courses, nil
+	return published, nil
 }
 
-func (s *CourseService) GiveAccess(userId, courseId int) error {
+// GiveAccess enrolls the user in the course. Granting access again replaces the previous
+// source and expiry, so it also extends or shortens an existing enrollment.
+func (s *CourseService) GiveAccess(e structures.Enrollment) error {
 	const op = "service.course_service.GiveAccess"
 	log := s.log.With("op", op)
 
-	user, err := s.userRepo.GetUserById(userId)
-	if err != nil {
-		log.Error("failed to get user by id", slog.Int("user_id", userId), slog.Any("err", err))
-		return fmt.Errorf("%v", err)
+	if e.Source == "" {
+		e.Source = structures.EnrollmentManual
+	}
+	if e.ExpiresAt != nil && e.ExpiresAt.Before(time.Now()) {
+		return fmt.Errorf("%w: expires_at is in the past", ErrInvalidAccess)
 	}
 
-	hasAccess := false
-
-	if user.Role == "admin" {
-		hasAccess = true
+	if _, err := s.userRepo.GetUserById(e.UserID); err != nil {
+		log.Error("failed to get user by id", slog.Int("user_id", e.UserID), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := s.repo.SelectCourseById(e.CourseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", e.CourseID))
+		return ErrCourseNotFound
+	}
+	if e.PlanID != 0 {
+		plan, err := s.planRepo.SelectPlan(e.PlanID)
+		if err != nil || plan.CourseID != e.CourseID {
+			return fmt.Errorf("%w: plan %d is not a plan of the course", ErrInvalidAccess, e.PlanID)
+		}
+	}
+	if e.CohortID == 0 {
+		if err := s.requireCohort(e.UserID, e.CourseID); err != nil {
+			return err
+		}
 	} else {
-		for _, id := range user.CourseIDs {
-			if int(id) == courseId {
-				hasAccess = true
-				break
+		cohort, err := s.cohortRepo.SelectCohort(e.CohortID)
+		if err != nil || cohort.CourseID != e.CourseID {
+			return fmt.Errorf("%w: cohort %d is not a cohort of the course", ErrInvalidAccess, e.CohortID)
+		}
+		// a paid order keeps its seat even if the cohort filled up while the user was paying
+		if e.Source != structures.EnrollmentPayment {
+			if err := s.checkSeat(e.UserID, cohort); err != nil {
+				return err
 			}
 		}
 	}
 
-	if !hasAccess {
-		err = s.repo.UpdateUsersIds(userId, courseId)
-		if err != nil {
-			log.Error("Error with updating course id", sl.Err(err))
-			return err
+	if err := s.enrollmentRepo.Upsert(e); err != nil {
+		log.Error("Error with giving access", sl.Err(err))
+		return err
+	}
+
+	return nil
+}
+
+// CheckCohort validates the cohort chosen at checkout: courses with cohorts are sold for a seat
+// in one of them, courses without cohorts take no cohort
+func (s *CourseService) CheckCohort(userID, courseID, cohortID int) error {
+	cohorts, err := s.cohortRepo.SelectCohorts(courseID)
+	if err != nil {
+		return err
+	}
+	if cohortID == 0 {
+		if len(cohorts) > 0 {
+			return ErrCohortRequired
 		}
+		return nil
+	}
+
+	i := slices.IndexFunc(cohorts, func(c structures.Cohort) bool { return c.Id == cohortID })
+	if i < 0 {
+		return postgres.ErrCohortNotFound
+	}
+	return s.checkSeat(userID, cohorts[i])
+}
+
+// requireCohort fails with ErrCohortRequired when access to a course with cohorts is given without one
+// and the user isn't in a cohort of the course already
+func (s *CourseService) requireCohort(userID, courseID int) error {
+	cohorts, err := s.cohortRepo.SelectCohorts(courseID)
+	if err != nil {
+		return err
+	}
+	if len(cohorts) == 0 {
+		return nil
 	}
 
+	current, err := s.enrollmentRepo.Cohort(userID, courseID)
+	if err != nil {
+		return err
+	}
+	if current == 0 {
+		return ErrCohortRequired
+	}
 	return nil
 }
 
+// CohortFor picks the cohort of the course out of cohorts chosen for several courses at once (a bundle),
+// 0 when none of them belongs to the course
+func (s *CourseService) CohortFor(courseID int, cohortIDs []int) (int, error) {
+	if len(cohortIDs) == 0 {
+		return 0, nil
+	}
+
+	cohorts, err := s.cohortRepo.SelectCohorts(courseID)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range cohorts {
+		if slices.Contains(cohortIDs, c.Id) {
+			return c.Id, nil
+		}
+	}
+	return 0, nil
+}
+
+// checkSeat fails with ErrCohortFull when the cohort has no free seat for the user,
+// a learner already in the cohort keeps their seat
+func (s *CourseService) checkSeat(userID int, cohort structures.Cohort) error {
+	if cohort.Capacity == 0 || cohort.Enrolled < cohort.Capacity {
+		return nil
+	}
+
+	current, err := s.enrollmentRepo.Cohort(userID, cohort.CourseID)
+	if err != nil {
+		return err
+	}
+	if current == cohort.Id {
+		return nil
+	}
+	return ErrCohortFull
+}
+
 func (s *CourseService) TakeAwayAccess(userId, courseId int) error {
 	const op = "service.course_service.TakeAwayAccess"
 	log := s.log.With("op", op)
 
-	err := s.repo.RemoveCourseId(userId, courseId)
+	err := s.enrollmentRepo.Delete(userId, courseId)
 	if err != nil {
 		log.Error("Error with taking away access", sl.Err(err))
 		return err
@@ -177,6 +928,62 @@ func (s *CourseService) TakeAwayAccess(userId, courseId int) error {
 	return nil
 }
 
+// TakeAwayOrderAccess revokes access to the course bought with the order. Access the user got another way,
+// from an admin, a voucher or a different order, is kept. When the refunded order was an upgrade,
+// the access falls back to the user's latest other paid order of the course and its plan.
+func (s *CourseService) TakeAwayOrderAccess(orderID, userID, courseID int) error {
+	const op = "service.course_service.TakeAwayOrderAccess"
+	log := s.log.With("op", op)
+
+	enrollments, err := s.enrollmentRepo.Select(structures.EnrollmentFilter{UserID: userID, CourseID: courseID})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(enrollments) == 0 || !grantedByOrder(enrollments[0], orderID) {
+		log.Info("access was not granted by the order, keeping it", slog.Int("order_id", orderID),
+			slog.Int("user_id", userID), slog.Int("course_id", courseID))
+		return nil
+	}
+
+	paid, err := s.enrollmentRepo.SelectPaidGrants(userID, courseID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	fallback, ok := fallbackGrant(paid, orderID)
+	if !ok {
+		return s.TakeAwayAccess(userID, courseID)
+	}
+
+	fallback.ExpiresAt = enrollments[0].ExpiresAt
+	if err := s.enrollmentRepo.Upsert(fallback); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("access falls back to an earlier order", slog.Int("refunded_order_id", orderID), slog.Int("order_id", fallback.OrderID),
+		slog.Int("user_id", userID), slog.Int("course_id", courseID))
+	return nil
+}
+
+// grantedByOrder reports whether the enrollment is the access bought with the order
+func grantedByOrder(e structures.Enrollment, orderID int) bool {
+	return e.Source == structures.EnrollmentPayment && e.OrderID == orderID
+}
+
+// fallbackGrant picks the access left after the order is refunded out of the grants of paid orders (newest first)
+func fallbackGrant(paid []structures.Enrollment, refundedOrderID int) (structures.Enrollment, bool) {
+	for _, e := range paid {
+		if e.OrderID != refundedOrderID {
+			return e, true
+		}
+	}
+	return structures.Enrollment{}, false
+}
+
+// GetEnrollments lists enrollments for admins, expired ones included
+func (s *CourseService) GetEnrollments(filter structures.EnrollmentFilter) ([]structures.Enrollment, error) {
+	return s.enrollmentRepo.Select(filter)
+}
+
 func (s *CourseService) GetAllCoursesWithAccess(userID int) ([]structures.CourseWithAccess, error) {
 	courses, err := s.repo.SelectAllCourses()
 	if err != nil {
@@ -187,18 +994,199 @@ func (s *CourseService) GetAllCoursesWithAccess(userID int) ([]structures.Course
 		return nil, err
 	}
 
+	enrollments, err := s.enrollmentRepo.SelectActive(userID)
+	if err != nil {
+		return nil, err
+	}
+
 	courseMap := make(map[int]bool)
-	for _, id := range user.CourseIDs {
-		courseMap[int(id)] = true
+	expiresAt := make(map[int]*time.Time)
+	for _, e := range enrollments {
+		courseMap[e.CourseID] = true
+		expiresAt[e.CourseID] = e.ExpiresAt
+	}
+
+	requests, err := s.requestRepo.SelectLatest(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var accessible []int
+	for _, course := range courses {
+		if user.Role == "admin" || courseMap[course.Id] {
+			accessible = append(accessible, course.Id)
+		}
+	}
+
+	lessons, err := s.progressRepo.SelectLessonProgress(userID, accessible)
+	if err != nil {
+		return nil, err
+	}
+
+	quizzes, err := s.quizRepo.SelectRequirements(userID, accessible)
+	if err != nil {
+		return nil, err
+	}
+
+	lessonsByCourse := make(map[int][]structures.LessonProgress)
+	for _, l := range lessons {
+		lessonsByCourse[l.CourseID] = append(lessonsByCourse[l.CourseID], l)
 	}
 
 	var result []structures.CourseWithAccess
 	for _, course := range courses {
-		result = append(result, structures.CourseWithAccess{
-			Course:    course,
-			HasAccess: user.Role == "admin" || courseMap[int(course.Id)],
-		})
+		hasAccess := user.Role == "admin" || courseMap[course.Id]
+		if user.Role != "admin" && !visible(course, hasAccess) {
+			continue
+		}
+		if hasAccess {
+			progress := summarizeProgress(lessonsByCourse[course.Id])
+			applyQuizRequirement(&progress, quizzes[course.Id])
+			course.Progress = &progress
+		}
+
+		item := structures.CourseWithAccess{
+			Course:          course,
+			HasAccess:       hasAccess,
+			AccessExpiresAt: expiresAt[course.Id],
+		}
+		if req, ok := requests[course.Id]; ok {
+			signReceipt(s.cfg, &req, userID)
+			item.AccessRequest = &req
+		}
+
+		result = append(result, item)
 	}
 
 	return result, nil
 }
+
+const maxAccessImportRows = 5000
+
+// ImportAccess grants or revokes access for every row of the CSV. Columns are user (username or id),
+// course_id, expires_at and cohort_id; a missing course_id falls back to defaultCourseID. Rows that can't be
+// parsed are reported as invalid and don't stop the import.
+func (s *CourseService) ImportAccess(data []byte, action string, defaultCourseID, adminID int, dryRun bool) (structures.AccessImportReport, error) {
+	const op = "service.course_service.ImportAccess"
+	log := s.log.With("op", op)
+
+	if action == "" {
+		action = structures.AccessGrant
+	}
+	if action != structures.AccessGrant && action != structures.AccessRevoke {
+		return structures.AccessImportReport{}, fmt.Errorf("%w: action must be grant or revoke", ErrInvalidAccess)
+	}
+
+	rows, err := parseAccessCSV(data, defaultCourseID)
+	if err != nil {
+		return structures.AccessImportReport{}, fmt.Errorf("%w: %v", ErrInvalidAccess, err)
+	}
+	if len(rows) == 0 {
+		return structures.AccessImportReport{}, fmt.Errorf("%w: file has no rows", ErrInvalidAccess)
+	}
+	if len(rows) > maxAccessImportRows {
+		return structures.AccessImportReport{}, fmt.Errorf("%w: at most %d rows per file", ErrInvalidAccess, maxAccessImportRows)
+	}
+
+	if err := s.enrollmentRepo.Import(rows, action, adminID, dryRun); err != nil {
+		log.Error("failed to import access", slog.Any("err", err))
+		return structures.AccessImportReport{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	report := structures.AccessImportReport{
+		Action:  action,
+		DryRun:  dryRun,
+		Summary: make(map[string]int),
+		Rows:    rows,
+	}
+	for _, row := range rows {
+		report.Summary[row.Status]++
+	}
+
+	return report, nil
+}
+
+// parseAccessCSV accepts comma or semicolon separated files (Excel uses ';' in ru locale) with an optional header
+func parseAccessCSV(data []byte, defaultCourseID int) ([]structures.AccessImportRow, error) {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	var rows []structures.AccessImportRow
+	for i := 0; ; i++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+
+		for j := range record {
+			record[j] = strings.TrimSpace(record[j])
+		}
+		if len(record) == 0 || (len(record) == 1 && record[0] == "") {
+			continue
+		}
+		if i == 0 && slices.Contains([]string{"user", "username", "user_id"}, strings.ToLower(record[0])) {
+			continue
+		}
+
+		row := structures.AccessImportRow{Line: line, User: record[0], CourseID: defaultCourseID}
+		if row.User == "" {
+			row.Status, row.Error = structures.AccessInvalidRow, "user is empty"
+		}
+		if len(record) > 1 && record[1] != "" && row.Status == "" {
+			id, err := strconv.Atoi(record[1])
+			if err != nil || id <= 0 {
+				row.Status, row.Error = structures.AccessInvalidRow, "course_id must be a number"
+			}
+			row.CourseID = id
+		}
+		if row.CourseID == 0 && row.Status == "" {
+			row.Status, row.Error = structures.AccessInvalidRow, "course_id is missing"
+		}
+		if len(record) > 2 && record[2] != "" && row.Status == "" {
+			expiresAt, err := parseAccessExpiry(record[2])
+			switch {
+			case err != nil:
+				row.Status, row.Error = structures.AccessInvalidRow, "expires_at must be YYYY-MM-DD or RFC 3339"
+			case expiresAt.Before(time.Now()):
+				row.Status, row.Error = structures.AccessInvalidRow, "expires_at is in the past"
+			default:
+				row.ExpiresAt = &expiresAt
+			}
+		}
+		if len(record) > 3 && record[3] != "" && row.Status == "" {
+			id, err := strconv.Atoi(record[3])
+			if err != nil || id <= 0 {
+				row.Status, row.Error = structures.AccessInvalidRow, "cohort_id must be a number"
+			}
+			row.CohortID = id
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// parseAccessExpiry treats a bare date as the end of that day (UTC)
+func parseAccessExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+func TestGrantedByOrder(t *testing.T) {
+	const orderID = 42
+
+	tests := []struct {
+		name       string
+		enrollment structures.Enrollment
+		want       bool
+	}{
+		{
+			name:       "bought with the refunded order",
+			enrollment: structures.Enrollment{Source: structures.EnrollmentPayment, OrderID: orderID},
+			want:       true,
+		},
+		{
+			name:       "granted by an admin",
+			enrollment: structures.Enrollment{Source: structures.EnrollmentManual},
+			want:       false,
+		},
+		{
+			name:       "redeemed voucher",
+			enrollment: structures.Enrollment{Source: structures.EnrollmentVoucher},
+			want:       false,
+		},
+		{
+			name:       "bought with another order or bundle",
+			enrollment: structures.Enrollment{Source: structures.EnrollmentPayment, OrderID: 7},
+			want:       false,
+		},
+		{
+			name:       "admin grant after the purchase",
+			enrollment: structures.Enrollment{Source: structures.EnrollmentManual, OrderID: 0},
+			want:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := grantedByOrder(tt.enrollment, orderID); got != tt.want {
+				t.Errorf("grantedByOrder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefundUpgrade(t *testing.T) {
+	const (
+		baseOrder    = 10
+		upgradeOrder = 11
+		basePlan     = 1
+		biggerPlan   = 2
+	)
+
+	// the upgrade overwrote the order and plan of the enrollment
+	enrollment := structures.Enrollment{Source: structures.EnrollmentPayment, OrderID: upgradeOrder, PlanID: biggerPlan}
+	paid := []structures.Enrollment{
+		{Source: structures.EnrollmentPayment, OrderID: upgradeOrder, PlanID: biggerPlan},
+		{Source: structures.EnrollmentPayment, OrderID: baseOrder, PlanID: basePlan},
+	}
+
+	if grantedByOrder(enrollment, baseOrder) {
+		t.Fatal("refunding the base order must keep the access of the upgrade")
+	}
+	if !grantedByOrder(enrollment, upgradeOrder) {
+		t.Fatal("refunding the upgrade must change the access")
+	}
+
+	got, ok := fallbackGrant(paid, upgradeOrder)
+	if !ok {
+		t.Fatal("refunding the upgrade must keep the access of the base order")
+	}
+	if got.OrderID != baseOrder || got.PlanID != basePlan {
+		t.Errorf("fallbackGrant() = order %d plan %d, want order %d plan %d", got.OrderID, got.PlanID, baseOrder, basePlan)
+	}
+
+	if _, ok := fallbackGrant(paid[:1], upgradeOrder); ok {
+		t.Error("refunding the only paid order must revoke the access")
+	}
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/diploma"
+)
+
+// Layout used for templates without configured fields: every line is centered
+// under the legacy diploma_x/diploma_y point of the course.
+var defaultDiplomaLayout = []struct {
+	kind     structures.DiplomaFieldKind
+	offsetY  int
+	fontSize float64
+}{
+	{structures.DiplomaFieldName, 0, 48},
+	{structures.DiplomaFieldCourseTitle, 70, 28},
+	{structures.DiplomaFieldIssueDate, 120, 20},
+	{structures.DiplomaFieldCertificateNumber, 155, 20},
+}
+
+type DiplomaService struct {
+	repo               *postgres.DiplomaRepo
+	userRepo           *postgres.UserRepo
+	courseService      *CourseService
+	certificateService *CertificateService
+	log                *slog.Logger
+	cfg                *config.Config
+}
+
+func NewDiplomaService(repo *postgres.DiplomaRepo, log *slog.Logger, cfg *config.Config, userRepo *postgres.UserRepo, courseService *CourseService, certificateService *CertificateService) *DiplomaService {
+	return &DiplomaService{
+		repo:               repo,
+		userRepo:           userRepo,
+		courseService:      courseService,
+		certificateService: certificateService,
+		log:                log,
+		cfg:                cfg,
+	}
+}
+
+func (s *DiplomaService) GetFields(courseID int) ([]structures.DiplomaField, error) {
+	const op = "service.diploma_service.GetFields"
+	log := s.log.With("op", op)
+
+	fields, err := s.repo.SelectFields(courseID)
+	if err != nil {
+		log.Error("failed to get diploma fields", slog.Int("course_id", courseID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fields, nil
+}
+
+func (s *DiplomaService) SetFields(courseID int, fields []structures.DiplomaField) error {
+	const op = "service.diploma_service.SetFields"
+	log := s.log.With("op", op)
+
+	for i := range fields {
+		f := &fields[i]
+
+		switch f.Kind {
+		case structures.DiplomaFieldName, structures.DiplomaFieldCourseTitle, structures.DiplomaFieldIssueDate,
+			structures.DiplomaFieldCertificateNumber, structures.DiplomaFieldText:
+		default:
+			return fmt.Errorf("unknown field kind %q", f.Kind)
+		}
+
+		switch f.Align {
+		case "":
+			f.Align = diploma.AlignLeft
+		case diploma.AlignLeft, diploma.AlignCenter, diploma.AlignRight:
+		default:
+			return fmt.Errorf("unknown align %q", f.Align)
+		}
+
+		if f.Color == "" {
+			f.Color = "#000000"
+		}
+		if _, err := diploma.ParseColor(f.Color); err != nil {
+			return err
+		}
+
+		if f.FontSize <= 0 {
+			f.FontSize = 32
+		}
+	}
+
+	if err := s.repo.ReplaceFields(courseID, fields); err != nil {
+		log.Error("failed to save diploma fields", slog.Int("course_id", courseID), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IssueDiploma checks course access, registers the certificate on the first download
+// and collects the values that must be printed on the user's diploma
+func (s *DiplomaService) IssueDiploma(courseID, userID int) (structures.Diploma, error) {
+	const op = "service.diploma_service.IssueDiploma"
+	log := s.log.With("op", op)
+
+	course, err := s.courseService.GetCourseByID(courseID, userID)
+	if err != nil {
+		return structures.Diploma{}, err
+	}
+
+	if !slices.Contains(course.Features, structures.FeatureDiploma) {
+		log.Warn("diploma is not included in the plan", slog.Int("course_id", courseID), slog.Int("user_id", userID))
+		return structures.Diploma{}, ErrFeatureNotIncluded
+	}
+
+	if p := course.Progress; p != nil && p.QuizzesPassed < p.QuizzesRequired {
+		log.Warn("required quizzes are not passed", slog.Int("course_id", courseID), slog.Int("user_id", userID))
+		return structures.Diploma{}, ErrQuizzesNotPassed
+	}
+
+	if course.DiplomaPath == "" {
+		log.Warn("course has no diploma template", slog.Int("course_id", courseID))
+		return structures.Diploma{}, fmt.Errorf("course has no diploma template")
+	}
+
+	user, err := s.userRepo.GetUserById(userID)
+	if err != nil {
+		log.Error("failed to get user by id", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Diploma{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fields, err := s.repo.SelectFields(courseID)
+	if err != nil {
+		log.Error("failed to get diploma fields", slog.Int("course_id", courseID), slog.Any("err", err))
+		return structures.Diploma{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(fields) == 0 {
+		for _, l := range defaultDiplomaLayout {
+			fields = append(fields, structures.DiplomaField{
+				CourseID: courseID,
+				Kind:     l.kind,
+				X:        course.Diploma_x,
+				Y:        course.Diploma_y + l.offsetY,
+				FontSize: l.fontSize,
+				Color:    "#000000",
+				Align:    diploma.AlignCenter,
+			})
+		}
+	}
+
+	cert, err := s.certificateService.IssueCertificate(userID, courseID, user.Username, course.Title)
+	if err != nil {
+		log.Error("failed to issue certificate", slog.Int("course_id", courseID), slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.Diploma{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cert.RevokedAt != nil {
+		log.Warn("certificate is revoked", slog.String("code", cert.Code))
+		return structures.Diploma{}, ErrCertificateRevoked
+	}
+
+	d := structures.Diploma{
+		TemplatePath:      course.DiplomaPath,
+		HolderName:        cert.HolderName,
+		CourseTitle:       cert.CourseTitle,
+		CertificateNumber: cert.Code,
+		IssuedAt:          cert.IssuedAt,
+	}
+
+	for _, f := range fields {
+		switch f.Kind {
+		case structures.DiplomaFieldName:
+			f.Text = d.HolderName
+		case structures.DiplomaFieldCourseTitle:
+			f.Text = d.CourseTitle
+		case structures.DiplomaFieldIssueDate:
+			f.Text = d.IssuedAt.Format("02.01.2006")
+		case structures.DiplomaFieldCertificateNumber:
+			f.Text = "№ " + d.CertificateNumber
+		}
+		d.Fields = append(d.Fields, f)
+	}
+
+	log.Info("diploma issued", slog.Int("course_id", courseID), slog.Int("user_id", userID))
+	return d, nil
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/notify"
+)
+
+var (
+	ErrInvalidAssignment = errors.New("invalid assignment")
+	ErrInvalidReview     = errors.New("status must be accepted or needs_revision")
+	ErrSubmissionPending = errors.New("previous submission is waiting for review")
+	ErrAlreadyAccepted   = errors.New("assignment is already accepted")
+)
+
+type HomeworkService struct {
+	repo          *postgres.HomeworkRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	sender        notify.Sender
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewHomeworkService(repo *postgres.HomeworkRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService, sender notify.Sender) *HomeworkService {
+	return &HomeworkService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		sender:        sender,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// assignmentFromRequest validates the request and checks that the lesson belongs to the course
+func (s *HomeworkService) assignmentFromRequest(courseID int, req structures.AssignmentRequest) (structures.Assignment, error) {
+	a := structures.Assignment{
+		CourseID:    courseID,
+		VideoID:     req.VideoID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if a.Title == "" {
+		return a, fmt.Errorf("%w: title is required", ErrInvalidAssignment)
+	}
+	if a.VideoID == 0 {
+		return a, fmt.Errorf("%w: video_id is required", ErrInvalidAssignment)
+	}
+
+	video, err := s.courseRepo.SelectVideoById(a.VideoID)
+	if err != nil {
+		return a, err
+	}
+	if video.CourseID != courseID {
+		return a, fmt.Errorf("%w: lesson belongs to another course", ErrInvalidAssignment)
+	}
+
+	return a, nil
+}
+
+func (s *HomeworkService) CreateAssignment(courseID int, req structures.AssignmentRequest) (int, error) {
+	const op = "service.homework_service.CreateAssignment"
+	log := s.log.With("op", op)
+
+	a, err := s.assignmentFromRequest(courseID, req)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.InsertAssignment(a)
+	if err != nil {
+		log.Error("failed to create assignment", slog.Int("course_id", courseID), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *HomeworkService) UpdateAssignment(id int, req structures.AssignmentRequest) error {
+	const op = "service.homework_service.UpdateAssignment"
+	log := s.log.With("op", op)
+
+	existing, err := s.repo.SelectAssignmentById(id)
+	if err != nil {
+		return err
+	}
+
+	a, err := s.assignmentFromRequest(existing.CourseID, req)
+	if err != nil {
+		return err
+	}
+	a.Id = id
+
+	if err := s.repo.UpdateAssignment(a); err != nil {
+		log.Error("failed to update assignment", slog.Int("id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteAssignment removes the assignment with its submissions and returns the files to clean up
+func (s *HomeworkService) DeleteAssignment(id int) ([]string, error) {
+	return s.repo.DeleteAssignment(id)
+}
+
+func (s *HomeworkService) GetCourseAssignmentsForAdmin(courseID int) ([]structures.Assignment, error) {
+	return s.repo.SelectCourseAssignments(courseID)
+}
+
+// GetCourseAssignments lists the assignments of a course with the user's latest submission on each
+func (s *HomeworkService) GetCourseAssignments(courseID, userID int) ([]structures.Assignment, error) {
+	const op = "service.homework_service.GetCourseAssignments"
+	log := s.log.With("op", op)
+
+	if err := s.checkAccess(userID, courseID); err != nil {
+		return nil, err
+	}
+
+	assignments, err := s.repo.SelectCourseAssignments(courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	last, err := s.repo.SelectLastSubmissions(userID, courseID)
+	if err != nil {
+		log.Error("failed to get last submissions", slog.Int("user_id", userID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range assignments {
+		if sub, ok := last[assignments[i].Id]; ok {
+			s.signFiles(&sub, userID)
+			assignments[i].LastSubmission = &sub
+		}
+	}
+
+	return assignments, nil
+}
+
+func (s *HomeworkService) checkAccess(userID, courseID int) error {
+	canOpen, err := s.courseService.CanOpen(userID, courseID)
+	if err != nil {
+		return err
+	}
+	if !canOpen {
+		return ErrNoAccess
+	}
+	return nil
+}
+
+// Submit stores a new submission. A learner may resubmit only after the previous one was sent back for revision.
+func (s *HomeworkService) Submit(assignmentID, userID int, comment string, files []structures.SubmissionFile) (structures.Submission, error) {
+	const op = "service.homework_service.Submit"
+	log := s.log.With("op", op)
+
+	a, err := s.repo.SelectAssignmentById(assignmentID)
+	if err != nil {
+		return structures.Submission{}, err
+	}
+
+	if err := s.checkAccess(userID, a.CourseID); err != nil {
+		return structures.Submission{}, err
+	}
+	if err := s.courseService.RequireFeature(userID, a.CourseID, structures.FeatureHomework); err != nil {
+		return structures.Submission{}, err
+	}
+	if a.VideoID != 0 {
+		if err := s.courseService.CheckLessonOpen(userID, a.VideoID); err != nil {
+			return structures.Submission{}, err
+		}
+	}
+
+	comment = strings.TrimSpace(comment)
+	if comment == "" && len(files) == 0 {
+		return structures.Submission{}, fmt.Errorf("%w: attach a file or write a comment", ErrInvalidAssignment)
+	}
+
+	previous, err := s.repo.SelectSubmissions(structures.SubmissionFilter{AssignmentID: assignmentID, UserID: userID})
+	if err != nil {
+		return structures.Submission{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(previous) > 0 {
+		switch previous[0].Status {
+		case structures.SubmissionPending:
+			return structures.Submission{}, ErrSubmissionPending
+		case structures.SubmissionAccepted:
+			return structures.Submission{}, ErrAlreadyAccepted
+		}
+	}
+
+	sub, err := s.repo.InsertSubmission(structures.Submission{
+		AssignmentID: assignmentID,
+		UserID:       userID,
+		Comment:      comment,
+		Files:        files,
+	})
+	if err != nil {
+		log.Error("failed to save submission", slog.Int("assignment_id", assignmentID), slog.Int("user_id", userID), slog.Any("err", err))
+		return sub, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.signFiles(&sub, userID)
+	return sub, nil
+}
+
+// GetMySubmissions returns the user's own submission history of an assignment
+func (s *HomeworkService) GetMySubmissions(assignmentID, userID int) ([]structures.Submission, error) {
+	a, err := s.repo.SelectAssignmentById(assignmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAccess(userID, a.CourseID); err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.repo.SelectSubmissions(structures.SubmissionFilter{AssignmentID: assignmentID, UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+	for i := range submissions {
+		s.signFiles(&submissions[i], userID)
+	}
+
+	return submissions, nil
+}
+
+// GetSubmissions is the review queue for admins and curators, curators get submissions of their cohorts only
+func (s *HomeworkService) GetSubmissions(filter structures.SubmissionFilter, reviewerID int) ([]structures.Submission, error) {
+	admin, err := s.courseService.IsAdmin(reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		filter.CuratorID = reviewerID
+	}
+
+	submissions, err := s.repo.SelectSubmissions(filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range submissions {
+		s.signFiles(&submissions[i], reviewerID)
+	}
+
+	return submissions, nil
+}
+
+func (s *HomeworkService) GetSubmission(id, reviewerID int) (structures.Submission, error) {
+	sub, err := s.repo.SelectSubmissionById(id)
+	if err != nil {
+		return sub, err
+	}
+
+	if _, err := s.checkReviewer(reviewerID, sub); err != nil {
+		return structures.Submission{}, err
+	}
+
+	s.signFiles(&sub, reviewerID)
+	return sub, nil
+}
+
+// checkReviewer returns the assignment of the submission if the user may review it, ErrNoAccess otherwise
+func (s *HomeworkService) checkReviewer(reviewerID int, sub structures.Submission) (structures.Assignment, error) {
+	a, err := s.repo.SelectAssignmentById(sub.AssignmentID)
+	if err != nil {
+		return a, err
+	}
+
+	ok, err := s.courseService.CanReview(reviewerID, sub.UserID, a.CourseID)
+	if err != nil {
+		return a, err
+	}
+	if !ok {
+		return a, ErrNoAccess
+	}
+	return a, nil
+}
+
+// Review sets the verdict on a submission and notifies the learner
+func (s *HomeworkService) Review(id, reviewerID int, req structures.ReviewRequest) error {
+	const op = "service.homework_service.Review"
+	log := s.log.With("op", op)
+
+	if req.Status != structures.SubmissionAccepted && req.Status != structures.SubmissionNeedsRevision {
+		return ErrInvalidReview
+	}
+	feedback := strings.TrimSpace(req.Feedback)
+
+	sub, err := s.repo.SelectSubmissionById(id)
+	if err != nil {
+		return err
+	}
+
+	a, err := s.checkReviewer(reviewerID, sub)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.ReviewSubmission(id, reviewerID, req.Status, feedback); err != nil {
+		log.Error("failed to review submission", slog.Int("id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.sender.Send(reviewMessage(sub, a, req.Status, feedback)); err != nil {
+		// the review is saved, the learner will still see it in the course
+		log.Warn("failed to notify learner", slog.Int("user_id", sub.UserID), slog.Any("err", err))
+	}
+
+	return nil
+}
+
+func reviewMessage(sub structures.Submission, a structures.Assignment, status, feedback string) notify.Message {
+	verdict := "Работа принята"
+	if status == structures.SubmissionNeedsRevision {
+		verdict = "Работу нужно доработать"
+	}
+
+	body := fmt.Sprintf("%s: «%s».", verdict, a.Title)
+	if feedback != "" {
+		body += "\n\n" + feedback
+	}
+
+	return notify.Message{
+		UserID:   sub.UserID,
+		Username: sub.Username,
+		Subject:  "Домашнее задание проверено",
+		Body:     body,
+	}
+}
+
+func (s *HomeworkService) signFiles(sub *structures.Submission, userID int) {
+	for i := range sub.Files {
+		sub.Files[i].URL, _ = signMediaURL(s.cfg, MediaHomework, sub.Files[i].Id, userID)
+	}
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/signurl"
+)
+
+const (
+	MediaVideo      = "video"
+	MediaFile       = "file"
+	MediaAttachment = "attachment" // id is a lesson_attachments id, not a video id
+	MediaHomework   = "homework"   // id is a submission_files id
+	MediaReceipt    = "receipt"    // id is an access_requests id
+)
+
+var (
+	ErrMediaNotFound    = errors.New("media not found")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+type MediaService struct {
+	courseRepo    *postgres.CourseRepo
+	moduleRepo    *postgres.ModuleRepo
+	homeworkRepo  *postgres.HomeworkRepo
+	requestRepo   *postgres.AccessRequestRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewMediaService(courseRepo *postgres.CourseRepo, log *slog.Logger, cfg *config.Config, courseService *CourseService, moduleRepo *postgres.ModuleRepo, homeworkRepo *postgres.HomeworkRepo, requestRepo *postgres.AccessRequestRepo) *MediaService {
+	return &MediaService{
+		courseRepo:    courseRepo,
+		moduleRepo:    moduleRepo,
+		homeworkRepo:  homeworkRepo,
+		requestRepo:   requestRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// ResolveMedia checks that the user may watch the video (or download its attachment)
+// and returns the stored "/uploads/..." path of the requested file
+func (s *MediaService) ResolveMedia(kind string, id, userID int) (string, error) {
+	const op = "service.media_service.ResolveMedia"
+	log := s.log.With("op", op)
+
+	switch kind {
+	case MediaHomework:
+		return s.resolveHomework(id, userID)
+	case MediaReceipt:
+		return s.resolveReceipt(id, userID)
+	}
+
+	videoID := id
+	var attachment structures.LessonAttachment
+	if kind == MediaAttachment {
+		var err error
+		attachment, err = s.moduleRepo.SelectAttachmentById(id)
+		if err != nil {
+			if errors.Is(err, postgres.ErrAttachmentNotFound) {
+				return "", ErrMediaNotFound
+			}
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		videoID = attachment.VideoID
+	}
+
+	video, err := s.courseRepo.SelectVideoById(videoID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrVideoNotFound) {
+			return "", ErrMediaNotFound
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	hasAccess, err := s.courseService.HasAccess(userID, video.CourseID)
+	if err != nil {
+		log.Error("failed to check access", slog.Int("user_id", userID), slog.Any("err", err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	// preview lessons are open to everyone, including anonymous signed links, but only in courses they can see
+	shown, err := s.courseService.Visible(userID, video.CourseID, hasAccess)
+	if err != nil {
+		log.Error("failed to check course status", slog.Int("course_id", video.CourseID), slog.Any("err", err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if !shown || (!hasAccess && !video.IsPreview) {
+		log.Warn("user has no access to media", slog.Int("user_id", userID), slog.String("kind", kind), slog.Int("id", id))
+		return "", ErrNoAccess
+	}
+
+	locked, err := s.courseService.LessonLocked(userID, video)
+	if err != nil {
+		log.Error("failed to check lesson schedule", slog.Int("user_id", userID), slog.Any("err", err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if locked {
+		log.Warn("lesson is not unlocked yet", slog.Int("user_id", userID), slog.Int("video_id", video.Id))
+		return "", ErrNoAccess
+	}
+
+	path := video.Path
+	switch kind {
+	case MediaFile:
+		path = video.File
+	case MediaAttachment:
+		path = attachment.Path
+	}
+	if path == "" {
+		return "", ErrMediaNotFound
+	}
+
+	return path, nil
+}
+
+// resolveHomework lets the author of a submission and its reviewers (admins and curators of the learner's cohort) download its files
+func (s *MediaService) resolveHomework(fileID, userID int) (string, error) {
+	const op = "service.media_service.resolveHomework"
+	log := s.log.With("op", op)
+
+	file, err := s.homeworkRepo.SelectSubmissionFile(fileID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrSubmissionNotFound) {
+			return "", ErrMediaNotFound
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	sub, err := s.homeworkRepo.SelectSubmissionById(file.SubmissionID)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if sub.UserID != userID {
+		a, err := s.homeworkRepo.SelectAssignmentById(sub.AssignmentID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		reviewer, err := s.courseService.CanReview(userID, sub.UserID, a.CourseID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if !reviewer {
+			log.Warn("user may not read homework file", slog.Int("user_id", userID), slog.Int("file_id", fileID))
+			return "", ErrNoAccess
+		}
+	}
+
+	return file.Path, nil
+}
+
+// resolveReceipt lets the author of an access request and admins open its payment receipt
+func (s *MediaService) resolveReceipt(requestID, userID int) (string, error) {
+	const op = "service.media_service.resolveReceipt"
+	log := s.log.With("op", op)
+
+	req, err := s.requestRepo.SelectRequestById(requestID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrAccessRequestNotFound) {
+			return "", ErrMediaNotFound
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	if req.ReceiptPath == "" {
+		return "", ErrMediaNotFound
+	}
+
+	if req.UserID != userID {
+		admin, err := s.courseService.IsAdmin(userID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if !admin {
+			log.Warn("user may not read receipt", slog.Int("user_id", userID), slog.Int("request_id", requestID))
+			return "", ErrNoAccess
+		}
+	}
+
+	return req.ReceiptPath, nil
+}
+
+// SignedURL returns a link that lets the user fetch the media without the auth cookie until it expires
+func (s *MediaService) SignedURL(kind string, videoID, userID int) (string, time.Time) {
+	return signMediaURL(s.cfg, kind, videoID, userID)
+}
+
+// ResolveSigned verifies a signed link and resolves the media for the user it was issued to
+func (s *MediaService) ResolveSigned(kind string, videoID int, uid, exp, sig string) (string, error) {
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return "", ErrInvalidSignature
+	}
+
+	userID, err := strconv.Atoi(uid)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+
+	if !signurl.Verify(mediaSigningKey(s.cfg), sig, kind, strconv.Itoa(videoID), uid, exp) {
+		return "", ErrInvalidSignature
+	}
+
+	return s.ResolveMedia(kind, videoID, userID)
+}
+
+func mediaSigningKey(cfg *config.Config) string {
+	if cfg.Media.SigningKey != "" {
+		return cfg.Media.SigningKey
+	}
+	return cfg.JWTSecretKey
+}
+
+func signMediaURL(cfg *config.Config, kind string, videoID, userID int) (string, time.Time) {
+	expiresAt := time.Now().Add(cfg.Media.SignedURLTTL)
+
+	id := strconv.Itoa(videoID)
+	uid := strconv.Itoa(userID)
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	q := url.Values{}
+	q.Set("uid", uid)
+	q.Set("exp", exp)
+	q.Set("sig", signurl.Sign(mediaSigningKey(cfg), kind, id, uid, exp))
+
+	return fmt.Sprintf("/api/v1/media/%s/%s?%s", kind, id, q.Encode()), expiresAt
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+// maxUnlockDays keeps drip schedules within a sane range, ten years
+const maxUnlockDays = 3650
+
+var (
+	ErrModuleNotEmpty = errors.New("module still has lessons")
+	ErrCourseNotFound = errors.New("course not found")
+	ErrInvalidUnlock  = errors.New("invalid lesson unlock rule")
+)
+
+type ModuleService struct {
+	repo       *postgres.ModuleRepo
+	courseRepo *postgres.CourseRepo
+	log        *slog.Logger
+	cfg        *config.Config
+}
+
+func NewModuleService(repo *postgres.ModuleRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo) *ModuleService {
+	return &ModuleService{
+		repo:       repo,
+		courseRepo: courseRepo,
+		log:        log,
+		cfg:        cfg,
+	}
+}
+
+func (s *ModuleService) CreateModule(courseID int, title string) (structures.Module, error) {
+	const op = "service.module_service.CreateModule"
+	log := s.log.With("op", op)
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return structures.Module{}, ErrCourseNotFound
+	}
+
+	module, err := s.repo.InsertModule(courseID, title)
+	if err != nil {
+		log.Error("failed to create module", slog.Int("course_id", courseID), slog.Any("err", err))
+		return module, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return module, nil
+}
+
+func (s *ModuleService) RenameModule(id int, title string) error {
+	return s.repo.UpdateModuleTitle(id, title)
+}
+
+// DeleteModule removes an empty module. Lessons have to be moved or deleted first.
+func (s *ModuleService) DeleteModule(id int) error {
+	const op = "service.module_service.DeleteModule"
+	log := s.log.With("op", op)
+
+	count, err := s.repo.CountLessons(id)
+	if err != nil {
+		log.Error("failed to count lessons", slog.Int("module_id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if count > 0 {
+		return ErrModuleNotEmpty
+	}
+
+	return s.repo.DeleteModule(id)
+}
+
+func (s *ModuleService) ReorderModules(courseID int, ids []int) error {
+	return s.repo.ReorderModules(courseID, ids)
+}
+
+func (s *ModuleService) CreateLesson(moduleID int, lesson structures.Video) (int, error) {
+	const op = "service.module_service.CreateLesson"
+	log := s.log.With("op", op)
+
+	id, err := s.repo.InsertLesson(moduleID, lesson)
+	if err != nil {
+		if errors.Is(err, postgres.ErrModuleNotFound) {
+			return 0, err
+		}
+		log.Error("failed to create lesson", slog.Int("module_id", moduleID), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+func (s *ModuleService) UpdateLesson(id int, title, content string) error {
+	return s.repo.UpdateLesson(id, title, content)
+}
+
+// SetUnlock sets when the lesson opens: a number of days after access was granted, a date, or both
+// (whichever comes later). Without either the lesson is open as soon as the user has access.
+func (s *ModuleService) SetUnlock(id int, req structures.LessonUnlockRequest) error {
+	if req.UnlockAfterDays != nil && (*req.UnlockAfterDays < 0 || *req.UnlockAfterDays > maxUnlockDays) {
+		return fmt.Errorf("%w: unlock_after_days must be between 0 and %d", ErrInvalidUnlock, maxUnlockDays)
+	}
+
+	var unlockAt *time.Time
+	if req.UnlockAt != nil {
+		t := req.UnlockAt.UTC()
+		unlockAt = &t
+	}
+
+	return s.repo.UpdateUnlock(id, req.UnlockAfterDays, unlockAt)
+}
+
+func (s *ModuleService) ReorderLessons(moduleID int, ids []int) error {
+	return s.repo.ReorderLessons(moduleID, ids)
+}
+
+func (s *ModuleService) AddAttachment(videoID int, title, path string) (structures.LessonAttachment, error) {
+	const op = "service.module_service.AddAttachment"
+	log := s.log.With("op", op)
+
+	if _, err := s.courseRepo.SelectVideoById(videoID); err != nil {
+		return structures.LessonAttachment{}, err
+	}
+
+	attachment, err := s.repo.InsertAttachment(videoID, title, path)
+	if err != nil {
+		log.Error("failed to add attachment", slog.Int("video_id", videoID), slog.Any("err", err))
+		return attachment, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attachment, nil
+}
+
+// DeleteAttachment removes the attachment row and returns it so the caller can clean up the file
+func (s *ModuleService) DeleteAttachment(id int) (structures.LessonAttachment, error) {
+	attachment, err := s.repo.SelectAttachmentById(id)
+	if err != nil {
+		return attachment, err
+	}
+
+	return attachment, s.repo.DeleteAttachment(id)
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/payments"
+)
+
+var (
+	ErrAlreadyHasAccess = errors.New("user already has access to this course")
+	ErrNotForSale       = errors.New("course is not for sale")
+	ErrOrderNotPaid     = errors.New("only paid orders can be refunded")
+	ErrMockDisabled     = errors.New("mock payments are disabled")
+)
+
+// orders fully covered by a promo code don't go through the payment provider
+const freeOrderProvider = "free"
+
+type OrderService struct {
+	repo          *postgres.OrderRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	promoService  *PromoService
+	bundleService *BundleService
+	provider      payments.Provider
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewOrderService(repo *postgres.OrderRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService, promoService *PromoService, bundleService *BundleService, provider payments.Provider) *OrderService {
+	return &OrderService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		promoService:  promoService,
+		bundleService: bundleService,
+		provider:      provider,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// Checkout creates an order for the course plan or bundle and a payment at the provider.
+// An unpaid order for the same item and price is returned again instead of creating a duplicate.
+func (s *OrderService) Checkout(userID int, req structures.CheckoutRequest) (structures.Order, error) {
+	const op = "service.order_service.Checkout"
+	log := s.log.With("op", op)
+
+	// cohorts of a bundle are a set, sorted so the pending order is found again whatever their order
+	if req.BundleID == 0 {
+		req.CohortIDs = nil
+	}
+	slices.Sort(req.CohortIDs)
+	req.CohortIDs = slices.Compact(req.CohortIDs)
+
+	pending, err := s.repo.SelectPendingOrder(userID, req)
+	if err != nil && !errors.Is(err, postgres.ErrOrderNotFound) {
+		return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	price, err := s.promoService.quote(userID, req, pending.Id)
+	if err != nil {
+		return structures.Order{}, err
+	}
+	if price.Cost <= 0 {
+		return structures.Order{}, ErrNotForSale
+	}
+
+	var covered bool
+	if req.BundleID != 0 {
+		covered, err = s.bundleService.Covered(userID, req.BundleID)
+	} else {
+		covered, err = s.courseService.CoversPlan(userID, req.CourseID, req.PlanID)
+	}
+	if err != nil {
+		return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if covered {
+		return structures.Order{}, ErrAlreadyHasAccess
+	}
+	if req.BundleID != 0 {
+		err = s.bundleService.CheckCohorts(userID, req.BundleID, req.CohortIDs)
+	} else {
+		err = s.courseService.CheckCohort(userID, req.CourseID, req.CohortID)
+	}
+	if err != nil {
+		return structures.Order{}, err
+	}
+
+	if pending.Id != 0 {
+		if pending.Amount == price.FinalPrice && pending.PromoCodeID == price.PromoCodeID {
+			return pending, nil
+		}
+		// the price changed, the old order must not keep holding a use of its promo code
+		if _, err := s.repo.UpdateStatus(pending.Id, structures.OrderFailed, []string{structures.OrderPending}); err != nil {
+			return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	order := structures.Order{
+		UserID:      userID,
+		CourseID:    req.CourseID,
+		PlanID:      req.PlanID,
+		BundleID:    req.BundleID,
+		CohortID:    req.CohortID,
+		CohortIDs:   req.CohortIDs,
+		Amount:      price.FinalPrice,
+		Discount:    price.Discount,
+		PromoCodeID: price.PromoCodeID,
+		Currency:    price.Currency,
+		Provider:    s.provider.Name(),
+	}
+	if order.Amount == 0 {
+		order.Provider = freeOrderProvider
+	}
+
+	order.Id, err = s.repo.InsertOrder(order)
+	if err != nil {
+		switch {
+		case errors.Is(err, postgres.ErrPromoLimitReached):
+			return structures.Order{}, ErrPromoExhausted
+		case errors.Is(err, postgres.ErrPromoNotFound):
+			return structures.Order{}, err
+		}
+		return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if order.Amount == 0 {
+		order.Status = structures.OrderPending
+		if err := s.applyEvent(order, payments.EventPaid); err != nil {
+			return structures.Order{}, err
+		}
+		return s.repo.SelectOrderById(order.Id)
+	}
+
+	payment, err := s.provider.CreatePayment(payments.Checkout{
+		OrderID:     order.Id,
+		Amount:      order.Amount,
+		Currency:    order.Currency,
+		Description: price.Title,
+		ReturnURL:   s.cfg.Payments.ReturnURL,
+	})
+	if err != nil {
+		log.Error("failed to create payment", slog.Int("order_id", order.Id), slog.Any("err", err))
+		if _, err := s.repo.UpdateStatus(order.Id, structures.OrderFailed, []string{structures.OrderPending}); err != nil {
+			log.Error("failed to mark order as failed", slog.Int("order_id", order.Id), slog.Any("err", err))
+		}
+		return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.repo.SetPayment(order.Id, payment.ID, payment.URL); err != nil {
+		return structures.Order{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.repo.SelectOrderById(order.Id)
+}
+
+// Quote returns the price the user would pay for the course plan or bundle with the promo code
+func (s *OrderService) Quote(userID int, req structures.CheckoutRequest) (structures.Price, error) {
+	return s.promoService.Quote(userID, req)
+}
+
+func (s *OrderService) GetMyOrders(userID int) ([]structures.Order, error) {
+	return s.repo.SelectOrders(structures.OrderFilter{UserID: userID})
+}
+
+// GetMyOrder returns the order only to its owner
+func (s *OrderService) GetMyOrder(id, userID int) (structures.Order, error) {
+	order, err := s.repo.SelectOrderById(id)
+	if err != nil {
+		return order, err
+	}
+	if order.UserID != userID {
+		return structures.Order{}, postgres.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) GetOrders(filter structures.OrderFilter) ([]structures.Order, error) {
+	return s.repo.SelectOrders(filter)
+}
+
+// HandleWebhook verifies a provider notification and applies it to the order
+func (s *OrderService) HandleWebhook(body []byte, header func(string) string) error {
+	const op = "service.order_service.HandleWebhook"
+	log := s.log.With("op", op)
+
+	event, err := s.provider.ParseWebhook(body, header)
+	if err != nil {
+		log.Warn("rejected webhook", slog.Any("err", err))
+		return err
+	}
+
+	order, err := s.repo.SelectOrderByPayment(s.provider.Name(), event.PaymentID)
+	if err != nil {
+		if errors.Is(err, postgres.ErrOrderNotFound) {
+			log.Warn("webhook for unknown payment", slog.String("payment_id", event.PaymentID))
+		}
+		return err
+	}
+
+	return s.applyEvent(order, event.Type)
+}
+
+// applyEvent moves the order to the status of the event and grants or revokes access.
+// Access changes are idempotent and repeated for an order already in the target status,
+// so a webhook retried after a failed grant still completes it.
+func (s *OrderService) applyEvent(order structures.Order, event string) error {
+	const op = "service.order_service.applyEvent"
+	log := s.log.With("op", op)
+
+	var status string
+	var from []string
+	switch event {
+	case payments.EventPaid:
+		status, from = structures.OrderPaid, []string{structures.OrderPending, structures.OrderFailed}
+	case payments.EventFailed:
+		status, from = structures.OrderFailed, []string{structures.OrderPending}
+	case payments.EventRefunded:
+		status, from = structures.OrderRefunded, []string{structures.OrderPaid}
+	default:
+		return fmt.Errorf("%s: unknown event %q", op, event)
+	}
+
+	changed, err := s.repo.UpdateStatus(order.Id, status, from)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !changed && order.Status != status {
+		log.Warn("ignored order event", slog.Int("order_id", order.Id), slog.String("status", order.Status), slog.String("event", event))
+		return nil
+	}
+
+	if order.BundleID != 0 {
+		return s.applyBundle(order, status)
+	}
+	if order.CourseID == 0 {
+		return nil
+	}
+
+	switch status {
+	case structures.OrderPaid:
+		if err := s.courseService.GiveAccess(structures.Enrollment{
+			UserID:   order.UserID,
+			CourseID: order.CourseID,
+			PlanID:   order.PlanID,
+			CohortID: order.CohortID,
+			Source:   structures.EnrollmentPayment,
+			OrderID:  order.Id,
+		}); err != nil {
+			log.Error("failed to give access", slog.Int("order_id", order.Id), slog.Any("err", err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	case structures.OrderRefunded:
+		if err := s.courseService.TakeAwayOrderAccess(order.Id, order.UserID, order.CourseID); err != nil {
+			log.Error("failed to take away access", slog.Int("order_id", order.Id), slog.Any("err", err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return nil
+}
+
+// applyBundle grants or revokes every course of a bundle order
+func (s *OrderService) applyBundle(order structures.Order, status string) error {
+	const op = "service.order_service.applyBundle"
+	log := s.log.With("op", op)
+
+	var err error
+	switch status {
+	case structures.OrderPaid:
+		err = s.bundleService.Grant(order.BundleID, structures.Enrollment{
+			UserID:  order.UserID,
+			Source:  structures.EnrollmentPayment,
+			OrderID: order.Id,
+		}, order.CohortIDs)
+	case structures.OrderRefunded:
+		err = s.bundleService.Revoke(order.BundleID, order.Id, order.UserID)
+	}
+	if err != nil {
+		log.Error("failed to apply bundle order", slog.Int("order_id", order.Id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Refund returns the money through the provider and revokes the access bought with the order
+func (s *OrderService) Refund(id int) error {
+	const op = "service.order_service.Refund"
+	log := s.log.With("op", op)
+
+	order, err := s.repo.SelectOrderById(id)
+	if err != nil {
+		return err
+	}
+	if order.Status != structures.OrderPaid {
+		return ErrOrderNotPaid
+	}
+
+	if order.Provider == freeOrderProvider {
+		return s.applyEvent(order, payments.EventRefunded)
+	}
+
+	if err := s.provider.Refund(order.PaymentID, order.Amount); err != nil {
+		log.Error("provider refused refund", slog.Int("order_id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.applyEvent(order, payments.EventRefunded)
+}
+
+// SimulatePayment completes a mock payment by sending the signed webhook through the regular path
+func (s *OrderService) SimulatePayment(paymentID, result string) error {
+	mock, ok := s.provider.(*payments.MockProvider)
+	if !ok {
+		return ErrMockDisabled
+	}
+
+	body, signature, err := mock.Webhook(paymentID, result)
+	if err != nil {
+		return err
+	}
+
+	return s.HandleWebhook(body, func(key string) string {
+		if key == payments.MockSignatureHeader {
+			return signature
+		}
+		return ""
+	})
+}
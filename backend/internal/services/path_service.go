@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var ErrInvalidPath = errors.New("invalid learning path")
+
+type PathService struct {
+	repo          *postgres.PathRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewPathService(repo *postgres.PathRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService) *PathService {
+	return &PathService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// validate checks the steps of the path: every course is listed once and a prerequisite
+// is one of the earlier courses of the same path
+func (s *PathService) validate(req structures.LearningPathRequest) (structures.LearningPath, error) {
+	p := structures.LearningPath{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Active:      req.Active == nil || *req.Active,
+		Courses:     req.Courses,
+	}
+	if p.Title == "" {
+		return p, fmt.Errorf("%w: title is required", ErrInvalidPath)
+	}
+	if len(p.Courses) == 0 {
+		return p, fmt.Errorf("%w: add at least one course", ErrInvalidPath)
+	}
+
+	seen := make(map[int]bool, len(p.Courses))
+	for _, c := range p.Courses {
+		if seen[c.CourseID] {
+			return p, fmt.Errorf("%w: course %d is listed twice", ErrInvalidPath, c.CourseID)
+		}
+		if c.PrerequisiteID != 0 && !seen[c.PrerequisiteID] {
+			return p, fmt.Errorf("%w: prerequisite of course %d must be an earlier course of the path", ErrInvalidPath, c.CourseID)
+		}
+		seen[c.CourseID] = true
+
+		if _, err := s.courseRepo.SelectCourseById(c.CourseID); err != nil {
+			return p, fmt.Errorf("%w: course %d is not found", ErrInvalidPath, c.CourseID)
+		}
+	}
+
+	return p, nil
+}
+
+// checkCycles rejects an active path whose prerequisites, together with those of the other active paths,
+// make courses wait for each other: prerequisites of all active paths apply at once, so such courses would never open
+func (s *PathService) checkCycles(p structures.LearningPath) error {
+	if !p.Active {
+		return nil
+	}
+
+	paths, err := s.repo.SelectPaths(true)
+	if err != nil {
+		return err
+	}
+
+	prerequisites := make(map[int][]int)
+	add := func(courses []structures.PathCourse) {
+		for _, c := range courses {
+			if c.PrerequisiteID != 0 {
+				prerequisites[c.CourseID] = append(prerequisites[c.CourseID], c.PrerequisiteID)
+			}
+		}
+	}
+	for _, other := range paths {
+		// the stored version of the path being updated is replaced by p
+		if other.Id != p.Id {
+			add(other.Courses)
+		}
+	}
+	add(p.Courses)
+
+	if course, ok := prerequisiteCycle(prerequisites); ok {
+		return fmt.Errorf("%w: with the other active paths course %d becomes its own prerequisite", ErrInvalidPath, course)
+	}
+	return nil
+}
+
+// prerequisiteCycle finds a course that, through a chain of prerequisites, requires itself
+func prerequisiteCycle(prerequisites map[int][]int) (int, bool) {
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[int]int)
+
+	var visit func(course int) bool
+	visit = func(course int) bool {
+		switch state[course] {
+		case visiting:
+			return true
+		case done:
+			return false
+		}
+		state[course] = visiting
+		for _, prerequisite := range prerequisites[course] {
+			if visit(prerequisite) {
+				return true
+			}
+		}
+		state[course] = done
+		return false
+	}
+
+	for course := range prerequisites {
+		if visit(course) {
+			return course, true
+		}
+	}
+	return 0, false
+}
+
+func (s *PathService) CreatePath(req structures.LearningPathRequest) (structures.LearningPath, error) {
+	p, err := s.validate(req)
+	if err != nil {
+		return p, err
+	}
+	if err := s.checkCycles(p); err != nil {
+		return p, err
+	}
+
+	id, err := s.repo.InsertPath(p)
+	if err != nil {
+		return p, err
+	}
+
+	return s.repo.SelectPath(id)
+}
+
+func (s *PathService) UpdatePath(id int, req structures.LearningPathRequest) (structures.LearningPath, error) {
+	p, err := s.validate(req)
+	if err != nil {
+		return p, err
+	}
+	p.Id = id
+	if err := s.checkCycles(p); err != nil {
+		return p, err
+	}
+
+	if err := s.repo.UpdatePath(p); err != nil {
+		return p, err
+	}
+
+	return s.repo.SelectPath(id)
+}
+
+func (s *PathService) DeletePath(id int) error {
+	return s.repo.DeletePath(id)
+}
+
+// GetPaths returns active paths, or every path for admins
+func (s *PathService) GetPaths(all bool) ([]structures.LearningPath, error) {
+	return s.repo.SelectPaths(!all)
+}
+
+func (s *PathService) GetPath(id int, all bool) (structures.LearningPath, error) {
+	p, err := s.repo.SelectPath(id)
+	if err != nil {
+		return p, err
+	}
+	if !all && !p.Active {
+		return structures.LearningPath{}, postgres.ErrPathNotFound
+	}
+
+	return p, nil
+}
+
+// GetMyPaths shows where the user is in every active path: which courses are completed,
+// which are unlocked and the course to take next
+func (s *PathService) GetMyPaths(userID int) ([]structures.PathProgress, error) {
+	const op = "service.path_service.GetMyPaths"
+	log := s.log.With("op", op)
+
+	paths, err := s.repo.SelectPaths(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var courseIDs []int
+	seen := make(map[int]bool)
+	for _, p := range paths {
+		for _, c := range p.Courses {
+			if !seen[c.CourseID] {
+				seen[c.CourseID] = true
+				courseIDs = append(courseIDs, c.CourseID)
+			}
+		}
+	}
+
+	progress, err := s.courseService.Completion(userID, courseIDs)
+	if err != nil {
+		log.Error("failed to get course progress", slog.Int("user_id", userID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	access := make(map[int]bool, len(courseIDs))
+	unlocked := make(map[int]bool, len(courseIDs))
+	for _, id := range courseIDs {
+		access[id], err = s.courseService.HasAccess(userID, id)
+		if err != nil {
+			log.Error("failed to check access", slog.Int("user_id", userID), slog.Any("err", err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		// prerequisites of every active path apply, not only those of the path shown
+		missing, err := s.courseService.MissingPrerequisites(userID, id)
+		if err != nil {
+			log.Error("failed to check prerequisites", slog.Int("user_id", userID), slog.Any("err", err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		unlocked[id] = len(missing) == 0
+	}
+
+	result := make([]structures.PathProgress, 0, len(paths))
+	for _, p := range paths {
+		pp := structures.PathProgress{
+			Id:          p.Id,
+			Title:       p.Title,
+			Description: p.Description,
+			Steps:       make([]structures.PathStep, 0, len(p.Courses)),
+			Total:       len(p.Courses),
+		}
+		for _, c := range p.Courses {
+			cp := progress[c.CourseID]
+			step := structures.PathStep{
+				PathCourse: c,
+				HasAccess:  access[c.CourseID],
+				Unlocked:   unlocked[c.CourseID],
+				Completed:  cp.Completed,
+				Percent:    cp.Percent,
+			}
+			if step.Completed {
+				pp.Completed++
+			} else if pp.CurrentCourseID == 0 {
+				pp.CurrentCourseID = c.CourseID
+			}
+			pp.Steps = append(pp.Steps, step)
+		}
+		result = append(result, pp)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var ErrInvalidPlan = errors.New("invalid plan")
+
+type PlanService struct {
+	repo       *postgres.PlanRepo
+	courseRepo *postgres.CourseRepo
+	log        *slog.Logger
+	cfg        *config.Config
+}
+
+func NewPlanService(repo *postgres.PlanRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo) *PlanService {
+	return &PlanService{
+		repo:       repo,
+		courseRepo: courseRepo,
+		log:        log,
+		cfg:        cfg,
+	}
+}
+
+func validatePlan(req structures.CoursePlanRequest) (structures.CoursePlan, error) {
+	p := structures.CoursePlan{
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Cost:        req.Cost,
+		Features:    []string{},
+		Position:    req.Position,
+		Active:      req.Active == nil || *req.Active,
+	}
+	if p.Title == "" {
+		return p, fmt.Errorf("%w: title is required", ErrInvalidPlan)
+	}
+	if p.Cost < 0 {
+		return p, fmt.Errorf("%w: cost can't be negative", ErrInvalidPlan)
+	}
+
+	for _, f := range req.Features {
+		if !slices.Contains(structures.AllFeatures, f) {
+			return p, fmt.Errorf("%w: unknown feature %q", ErrInvalidPlan, f)
+		}
+		if !slices.Contains(p.Features, f) {
+			p.Features = append(p.Features, f)
+		}
+	}
+
+	return p, nil
+}
+
+func (s *PlanService) CreatePlan(courseID int, req structures.CoursePlanRequest) (structures.CoursePlan, error) {
+	const op = "service.plan_service.CreatePlan"
+	log := s.log.With("op", op)
+
+	p, err := validatePlan(req)
+	if err != nil {
+		return p, err
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return p, ErrCourseNotFound
+	}
+	p.CourseID = courseID
+
+	p.Id, err = s.repo.InsertPlan(p)
+	if err != nil {
+		return p, err
+	}
+
+	return s.repo.SelectPlan(p.Id)
+}
+
+// UpdatePlan changes the plan for new buyers, features change for current holders too
+func (s *PlanService) UpdatePlan(id int, req structures.CoursePlanRequest) (structures.CoursePlan, error) {
+	p, err := validatePlan(req)
+	if err != nil {
+		return p, err
+	}
+	p.Id = id
+
+	if err := s.repo.UpdatePlan(p); err != nil {
+		return p, err
+	}
+
+	return s.repo.SelectPlan(id)
+}
+
+func (s *PlanService) DeletePlan(id int) error {
+	return s.repo.DeletePlan(id)
+}
+
+// GetPlans returns every plan of the course including inactive ones, for admins
+func (s *PlanService) GetPlans(courseID int) ([]structures.CoursePlan, error) {
+	return s.repo.SelectPlans(courseID, false)
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+const (
+	// Heartbeats are sent every few seconds, a bigger delta means the client is lying or was offline
+	maxHeartbeatWatched = 60
+	// Share of the video that has to be watched to count it as completed
+	completionThreshold = 0.9
+)
+
+type ProgressService struct {
+	repo          *postgres.ProgressRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewProgressService(repo *postgres.ProgressRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService) *ProgressService {
+	return &ProgressService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// Heartbeat records the playback state of a video for the user
+func (s *ProgressService) Heartbeat(userID int, hb structures.ProgressHeartbeat) (structures.VideoProgress, error) {
+	const op = "service.progress_service.Heartbeat"
+	log := s.log.With("op", op)
+
+	video, err := s.courseRepo.SelectVideoById(hb.VideoID)
+	if err != nil {
+		return structures.VideoProgress{}, err
+	}
+
+	canOpen, err := s.courseService.CanOpen(userID, video.CourseID)
+	if err != nil {
+		log.Error("failed to check access", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.VideoProgress{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !canOpen {
+		return structures.VideoProgress{}, ErrNoAccess
+	}
+
+	locked, err := s.courseService.LessonLocked(userID, video)
+	if err != nil {
+		log.Error("failed to check lesson schedule", slog.Int("user_id", userID), slog.Any("err", err))
+		return structures.VideoProgress{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if locked {
+		return structures.VideoProgress{}, ErrNoAccess
+	}
+
+	p, err := s.repo.SelectVideoProgress(userID, hb.VideoID)
+	if err != nil {
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	p = applyHeartbeat(p, hb, video.Duration, time.Now())
+
+	if err := s.repo.UpsertVideoProgress(userID, p); err != nil {
+		log.Error("failed to save progress", slog.Int("user_id", userID), slog.Int("video_id", hb.VideoID), slog.Any("err", err))
+		return p, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// applyHeartbeat adds a heartbeat to the progress. Watched time can't exceed the time passed since the previous
+// heartbeat. The duration always comes from the lesson, the player's duration and completed flag are ignored:
+// a video is completed only once enough of it was actually watched, and never while the lesson has no duration.
+func applyHeartbeat(p structures.VideoProgress, hb structures.ProgressHeartbeat, videoDuration int, now time.Time) structures.VideoProgress {
+	watched := min(max(hb.Watched, 0), maxHeartbeatWatched)
+	if p.UpdatedAt != nil {
+		watched = min(watched, max(int(now.Sub(*p.UpdatedAt).Seconds()), 0))
+	}
+
+	p.Duration = videoDuration
+	p.LastPosition = max(hb.Position, 0)
+	p.WatchedSeconds += watched
+	if p.Duration > 0 {
+		p.LastPosition = min(p.LastPosition, p.Duration)
+		p.WatchedSeconds = min(p.WatchedSeconds, p.Duration)
+	}
+
+	if p.Duration > 0 && float64(p.WatchedSeconds) >= float64(p.Duration)*completionThreshold {
+		p.Completed = true
+	}
+
+	return p
+}
+
+func (s *ProgressService) GetCourseReport(courseID int) ([]structures.LearnerProgress, error) {
+	const op = "service.progress_service.GetCourseReport"
+	log := s.log.With("op", op)
+
+	learners, err := s.repo.SelectCourseLearners(courseID)
+	if err != nil {
+		log.Error("failed to get course learners", slog.Int("course_id", courseID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return learners, nil
+}
+
+// summarizeProgress turns per-video progress of one course into a completion percentage and a "continue watching" pointer.
+// Lessons must be in course order.
+func summarizeProgress(lessons []structures.LessonProgress) structures.CourseProgress {
+	var cp structures.CourseProgress
+	var lastActive, firstPending *structures.LessonProgress
+
+	for i := range lessons {
+		l := &lessons[i]
+		cp.TotalVideos++
+
+		if l.Completed {
+			cp.CompletedVideos++
+			continue
+		}
+
+		if firstPending == nil {
+			firstPending = l
+		}
+		if l.UpdatedAt != nil && (lastActive == nil || l.UpdatedAt.After(*lastActive.UpdatedAt)) {
+			lastActive = l
+		}
+	}
+
+	if cp.TotalVideos > 0 {
+		cp.Percent = cp.CompletedVideos * 100 / cp.TotalVideos
+	}
+
+	next := lastActive
+	if next == nil {
+		next = firstPending
+	}
+	if next != nil {
+		cp.ContinueWatching = &structures.ContinueWatching{
+			VideoID:  next.VideoID,
+			Title:    next.Title,
+			Position: next.LastPosition,
+		}
+	}
+
+	return cp
+}
+
+// applyQuizRequirement adds mandatory quiz results to the course progress and decides whether the course is complete
+func applyQuizRequirement(cp *structures.CourseProgress, req structures.QuizRequirement) {
+	cp.QuizzesRequired = req.Required
+	cp.QuizzesPassed = req.Passed
+	cp.Completed = cp.TotalVideos+cp.QuizzesRequired > 0 &&
+		cp.CompletedVideos == cp.TotalVideos &&
+		cp.QuizzesPassed >= cp.QuizzesRequired
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+func TestApplyHeartbeat(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name          string
+		progress      structures.VideoProgress
+		hb            structures.ProgressHeartbeat
+		videoDuration int
+		wantCompleted bool
+		wantDuration  int
+		wantWatched   int
+	}{
+		{
+			name:          "bare completed flag",
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Completed: true},
+			videoDuration: 600,
+			wantCompleted: false,
+			wantDuration:  600,
+		},
+		{
+			name:          "completed flag without known duration",
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Completed: true},
+			wantCompleted: false,
+		},
+		{
+			name:          "client duration is ignored when the lesson has one",
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Duration: 1, Watched: 1},
+			videoDuration: 600,
+			wantCompleted: false,
+			wantDuration:  600,
+		},
+		{
+			name:          "watched past the threshold",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 520},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Position: 560, Watched: 30},
+			videoDuration: 600,
+			wantCompleted: true,
+			wantDuration:  600,
+		},
+		{
+			name:          "watched below the threshold",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 500},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Position: 590, Watched: 30, Completed: true},
+			videoDuration: 600,
+			wantCompleted: false,
+			wantDuration:  600,
+		},
+		{
+			name:          "watched delta is capped",
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Watched: 600},
+			videoDuration: 600,
+			wantCompleted: false,
+			wantDuration:  600,
+		},
+		{
+			name:          "player duration is ignored for lessons without one",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 100},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Duration: 120, Watched: 10},
+			wantCompleted: false,
+			wantDuration:  0,
+			wantWatched:   110,
+		},
+		{
+			name:          "watched delta is capped by time since the previous heartbeat",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 100, UpdatedAt: ago(5 * time.Second)},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Watched: 30},
+			videoDuration: 600,
+			wantDuration:  600,
+			wantWatched:   105,
+		},
+		{
+			name:          "repeated heartbeats can't complete a video faster than real time",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 530, UpdatedAt: ago(0)},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Watched: 60},
+			videoDuration: 600,
+			wantCompleted: false,
+			wantDuration:  600,
+			wantWatched:   530,
+		},
+		{
+			name:          "completed lesson stays completed",
+			progress:      structures.VideoProgress{VideoID: 1, WatchedSeconds: 600, Completed: true},
+			hb:            structures.ProgressHeartbeat{VideoID: 1, Position: 10},
+			videoDuration: 600,
+			wantCompleted: true,
+			wantDuration:  600,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := applyHeartbeat(tt.progress, tt.hb, tt.videoDuration, now)
+			if p.Completed != tt.wantCompleted {
+				t.Errorf("Completed = %v, want %v", p.Completed, tt.wantCompleted)
+			}
+			if p.Duration != tt.wantDuration {
+				t.Errorf("Duration = %d, want %d", p.Duration, tt.wantDuration)
+			}
+			if tt.wantWatched != 0 && p.WatchedSeconds != tt.wantWatched {
+				t.Errorf("WatchedSeconds = %d, want %d", p.WatchedSeconds, tt.wantWatched)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var (
+	ErrInvalidPromo       = errors.New("invalid promo code")
+	ErrPromoExpired       = errors.New("promo code has expired")
+	ErrPromoNotApplicable = errors.New("promo code does not apply to this course")
+	ErrPromoExhausted     = errors.New("promo code usage limit reached")
+	ErrPlanRequired       = errors.New("choose a plan of the course")
+)
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,64}$`)
+
+type PromoService struct {
+	repo       *postgres.PromoRepo
+	courseRepo *postgres.CourseRepo
+	planRepo   *postgres.PlanRepo
+	bundleRepo *postgres.BundleRepo
+	log        *slog.Logger
+	cfg        *config.Config
+}
+
+func NewPromoService(repo *postgres.PromoRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, planRepo *postgres.PlanRepo, bundleRepo *postgres.BundleRepo) *PromoService {
+	return &PromoService{
+		repo:       repo,
+		courseRepo: courseRepo,
+		planRepo:   planRepo,
+		bundleRepo: bundleRepo,
+		log:        log,
+		cfg:        cfg,
+	}
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func validatePromo(p *structures.PromoCode) error {
+	p.Code = normalizePromoCode(p.Code)
+	if !promoCodePattern.MatchString(p.Code) {
+		return fmt.Errorf("%w: code must be 3-64 latin letters, digits, '-' or '_'", ErrInvalidPromo)
+	}
+
+	switch p.Kind {
+	case structures.PromoPercent:
+		if p.Value <= 0 || p.Value > 100 {
+			return fmt.Errorf("%w: percent must be between 1 and 100", ErrInvalidPromo)
+		}
+	case structures.PromoFixed:
+		if p.Value <= 0 {
+			return fmt.Errorf("%w: amount must be positive", ErrInvalidPromo)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be percent or fixed", ErrInvalidPromo)
+	}
+
+	if p.MaxUses < 0 || p.MaxUsesPerUser < 0 {
+		return fmt.Errorf("%w: usage limits can't be negative", ErrInvalidPromo)
+	}
+	if p.CourseIDs == nil {
+		p.CourseIDs = []int{}
+	}
+
+	return nil
+}
+
+func (s *PromoService) CreatePromo(p structures.PromoCode) (int, error) {
+	if err := validatePromo(&p); err != nil {
+		return 0, err
+	}
+
+	return s.repo.InsertPromo(p)
+}
+
+func (s *PromoService) UpdatePromo(id int, p structures.PromoCode) error {
+	if err := validatePromo(&p); err != nil {
+		return err
+	}
+	p.Id = id
+
+	return s.repo.UpdatePromo(p)
+}
+
+func (s *PromoService) DeletePromo(id int) error {
+	return s.repo.DeletePromo(id)
+}
+
+func (s *PromoService) GetPromos() ([]structures.PromoCode, error) {
+	return s.repo.SelectPromos()
+}
+
+// basePrice returns the regular price of the item and the courses it gives access to.
+// A course with plans is only sold by plan.
+func (s *PromoService) basePrice(item structures.CheckoutRequest) (structures.Price, []int, error) {
+	const op = "service.promo_service.basePrice"
+	log := s.log.With("op", op)
+
+	price := structures.Price{Currency: s.cfg.Payments.Currency}
+
+	if item.BundleID != 0 {
+		bundle, err := s.bundleRepo.SelectBundle(item.BundleID)
+		if err != nil {
+			return price, nil, err
+		}
+		if !bundle.Active || len(bundle.Courses) == 0 {
+			return price, nil, ErrNotForSale
+		}
+
+		courseIDs := make([]int, len(bundle.Courses))
+		for i, c := range bundle.Courses {
+			courseIDs[i] = c.CourseID
+		}
+		price.BundleID, price.Title, price.Cost = bundle.Id, bundle.Title, bundle.Cost
+		return price, courseIDs, nil
+	}
+
+	course, err := s.courseRepo.SelectCourseById(item.CourseID)
+	if err != nil {
+		log.Warn("course not found", slog.Int("course_id", item.CourseID))
+		return price, nil, ErrCourseNotFound
+	}
+	if course.Status != structures.CoursePublished {
+		return price, nil, ErrNotForSale
+	}
+	price.CourseID, price.Title, price.Cost = course.Id, course.Title, course.Cost
+
+	plans, err := s.planRepo.SelectPlans(course.Id, true)
+	if err != nil {
+		return price, nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if item.PlanID == 0 {
+		if len(plans) > 0 {
+			return price, nil, ErrPlanRequired
+		}
+		return price, []int{course.Id}, nil
+	}
+
+	i := slices.IndexFunc(plans, func(p structures.CoursePlan) bool { return p.Id == item.PlanID })
+	if i < 0 {
+		return price, nil, postgres.ErrPlanNotFound
+	}
+	price.PlanID = plans[i].Id
+	price.Title = course.Title + " - " + plans[i].Title
+	price.Cost = plans[i].Cost
+
+	return price, []int{course.Id}, nil
+}
+
+// Quote returns the price of a course, a plan or a bundle for the user. An empty code gives the regular price.
+// A promo code limited to some courses applies to a bundle only if it covers every course of the bundle.
+func (s *PromoService) Quote(userID int, item structures.CheckoutRequest) (structures.Price, error) {
+	return s.quote(userID, item, 0)
+}
+
+// quote prices the item, pendingOrderID is the user's unpaid order for it that already holds a use of the code
+func (s *PromoService) quote(userID int, item structures.CheckoutRequest, pendingOrderID int) (structures.Price, error) {
+	const op = "service.promo_service.Quote"
+	log := s.log.With("op", op)
+
+	price, courseIDs, err := s.basePrice(item)
+	if err != nil {
+		return structures.Price{}, err
+	}
+	price.FinalPrice = price.Cost
+
+	code := normalizePromoCode(item.PromoCode)
+	if code == "" {
+		return price, nil
+	}
+
+	promo, err := s.repo.SelectPromoByCode(code)
+	if err != nil {
+		return price, err
+	}
+	if !promo.Active {
+		return price, postgres.ErrPromoNotFound
+	}
+	if promo.ExpiresAt != nil && time.Now().After(*promo.ExpiresAt) {
+		return price, ErrPromoExpired
+	}
+	if len(promo.CourseIDs) > 0 {
+		for _, id := range courseIDs {
+			if !slices.Contains(promo.CourseIDs, id) {
+				return price, ErrPromoNotApplicable
+			}
+		}
+	}
+	if promo.MaxUses > 0 || promo.MaxUsesPerUser > 0 {
+		total, byUser, err := s.repo.CountUses(promo.Id, userID, pendingOrderID)
+		if err != nil {
+			log.Error("failed to count promo uses", slog.Int("promo_id", promo.Id), slog.Any("err", err))
+			return price, fmt.Errorf("%s: %w", op, err)
+		}
+		if (promo.MaxUses > 0 && total >= promo.MaxUses) || (promo.MaxUsesPerUser > 0 && byUser >= promo.MaxUsesPerUser) {
+			return price, ErrPromoExhausted
+		}
+	}
+
+	price.Discount = promoDiscount(promo, price.Cost)
+	price.FinalPrice = price.Cost - price.Discount
+	price.PromoCode = promo.Code
+	price.PromoCodeID = promo.Id
+
+	return price, nil
+}
+
+// promoDiscount never exceeds the cost, percent discounts are rounded down
+func promoDiscount(p structures.PromoCode, cost int) int {
+	var discount int
+	switch p.Kind {
+	case structures.PromoPercent:
+		discount = cost * p.Value / 100
+	case structures.PromoFixed:
+		discount = p.Value
+	}
+
+	return min(discount, cost)
+}
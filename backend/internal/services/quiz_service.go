@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+var (
+	ErrInvalidQuiz      = errors.New("invalid quiz")
+	ErrQuizzesNotPassed = errors.New("required quizzes are not passed")
+)
+
+type QuizService struct {
+	repo          *postgres.QuizRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewQuizService(repo *postgres.QuizRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService) *QuizService {
+	return &QuizService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+func validateQuiz(q *structures.Quiz) error {
+	q.Title = strings.TrimSpace(q.Title)
+	if q.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidQuiz)
+	}
+	if q.PassScore < 0 || q.PassScore > 100 {
+		return fmt.Errorf("%w: pass_score must be between 0 and 100", ErrInvalidQuiz)
+	}
+	if len(q.Questions) == 0 {
+		return fmt.Errorf("%w: quiz has no questions", ErrInvalidQuiz)
+	}
+
+	for i := range q.Questions {
+		qq := &q.Questions[i]
+		if strings.TrimSpace(qq.Text) == "" {
+			return fmt.Errorf("%w: question %d has no text", ErrInvalidQuiz, i+1)
+		}
+		if qq.Points <= 0 {
+			qq.Points = 1
+		}
+
+		correct := 0
+		for _, o := range qq.Options {
+			if o.IsCorrect {
+				correct++
+			}
+		}
+
+		switch qq.Kind {
+		case structures.QuestionSingle:
+			if len(qq.Options) < 2 || correct != 1 {
+				return fmt.Errorf("%w: question %d needs at least 2 options and exactly one correct", ErrInvalidQuiz, i+1)
+			}
+			qq.AcceptedAnswers = nil
+		case structures.QuestionMultiple:
+			if len(qq.Options) < 2 || correct == 0 {
+				return fmt.Errorf("%w: question %d needs at least 2 options and a correct one", ErrInvalidQuiz, i+1)
+			}
+			qq.AcceptedAnswers = nil
+		case structures.QuestionText:
+			if len(qq.AcceptedAnswers) == 0 {
+				return fmt.Errorf("%w: question %d needs accepted_answers", ErrInvalidQuiz, i+1)
+			}
+			qq.Options = nil
+		default:
+			return fmt.Errorf("%w: question %d has unknown kind %q", ErrInvalidQuiz, i+1, qq.Kind)
+		}
+	}
+
+	return nil
+}
+
+// checkLesson makes sure a lesson-level quiz points to a lesson of the same course
+func (s *QuizService) checkLesson(q structures.Quiz) error {
+	if q.VideoID == 0 {
+		return nil
+	}
+
+	video, err := s.courseRepo.SelectVideoById(q.VideoID)
+	if err != nil {
+		return err
+	}
+	if video.CourseID != q.CourseID {
+		return fmt.Errorf("%w: lesson belongs to another course", ErrInvalidQuiz)
+	}
+
+	return nil
+}
+
+func (s *QuizService) CreateQuiz(courseID int, q structures.Quiz) (int, error) {
+	const op = "service.quiz_service.CreateQuiz"
+	log := s.log.With("op", op)
+
+	q.CourseID = courseID
+	if err := validateQuiz(&q); err != nil {
+		return 0, err
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return 0, ErrCourseNotFound
+	}
+	if err := s.checkLesson(q); err != nil {
+		return 0, err
+	}
+
+	id, err := s.repo.InsertQuiz(q)
+	if err != nil {
+		log.Error("failed to create quiz", slog.Int("course_id", courseID), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// UpdateQuiz replaces the quiz settings and questions. Earlier attempts keep their scores.
+func (s *QuizService) UpdateQuiz(id int, q structures.Quiz) error {
+	const op = "service.quiz_service.UpdateQuiz"
+	log := s.log.With("op", op)
+
+	existing, err := s.repo.SelectQuizById(id)
+	if err != nil {
+		return err
+	}
+
+	q.Id = id
+	q.CourseID = existing.CourseID
+	if err := validateQuiz(&q); err != nil {
+		return err
+	}
+	if err := s.checkLesson(q); err != nil {
+		return err
+	}
+
+	if err := s.repo.ReplaceQuiz(q); err != nil {
+		log.Error("failed to update quiz", slog.Int("id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (s *QuizService) DeleteQuiz(id int) error {
+	return s.repo.DeleteQuiz(id)
+}
+
+// GetQuizForAdmin returns the quiz with its correct answers
+func (s *QuizService) GetQuizForAdmin(id int) (structures.Quiz, error) {
+	return s.repo.SelectQuizById(id)
+}
+
+func (s *QuizService) GetCourseQuizzesForAdmin(courseID int) ([]structures.Quiz, error) {
+	return s.repo.SelectCourseQuizzes(courseID)
+}
+
+func (s *QuizService) checkAccess(userID, courseID int) error {
+	canOpen, err := s.courseService.CanOpen(userID, courseID)
+	if err != nil {
+		return err
+	}
+	if !canOpen {
+		return ErrNoAccess
+	}
+	return nil
+}
+
+// checkQuizAccess is checkAccess for one quiz: a quiz of a lesson opens together with the lesson
+func (s *QuizService) checkQuizAccess(userID int, q structures.Quiz) error {
+	if err := s.checkAccess(userID, q.CourseID); err != nil {
+		return err
+	}
+	if q.VideoID == 0 {
+		return nil
+	}
+	return s.courseService.CheckLessonOpen(userID, q.VideoID)
+}
+
+// GetCourseQuizzes lists the quizzes of a course with the user's best attempt on each
+func (s *QuizService) GetCourseQuizzes(courseID, userID int) ([]structures.Quiz, error) {
+	const op = "service.quiz_service.GetCourseQuizzes"
+	log := s.log.With("op", op)
+
+	if err := s.checkAccess(userID, courseID); err != nil {
+		return nil, err
+	}
+
+	quizzes, err := s.repo.SelectCourseQuizzes(courseID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	best, err := s.repo.SelectBestAttempts(userID, courseID)
+	if err != nil {
+		log.Error("failed to get best attempts", slog.Int("user_id", userID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range quizzes {
+		if a, ok := best[quizzes[i].Id]; ok {
+			quizzes[i].BestAttempt = &a
+		}
+	}
+
+	return quizzes, nil
+}
+
+// GetQuiz returns the quiz for a learner, without correct answers
+func (s *QuizService) GetQuiz(quizID, userID int) (structures.Quiz, error) {
+	q, err := s.repo.SelectQuizById(quizID)
+	if err != nil {
+		return q, err
+	}
+
+	if err := s.checkQuizAccess(userID, q); err != nil {
+		return structures.Quiz{}, err
+	}
+
+	for i := range q.Questions {
+		q.Questions[i].AcceptedAnswers = nil
+		for j := range q.Questions[i].Options {
+			q.Questions[i].Options[j].IsCorrect = false
+		}
+	}
+
+	return q, nil
+}
+
+// Submit scores the answers and stores them as a new attempt
+func (s *QuizService) Submit(quizID, userID int, sub structures.QuizSubmission) (structures.QuizAttempt, error) {
+	const op = "service.quiz_service.Submit"
+	log := s.log.With("op", op)
+
+	q, err := s.repo.SelectQuizById(quizID)
+	if err != nil {
+		return structures.QuizAttempt{}, err
+	}
+
+	if err := s.checkQuizAccess(userID, q); err != nil {
+		return structures.QuizAttempt{}, err
+	}
+
+	attempt := scoreQuiz(q, sub)
+	attempt.UserID = userID
+
+	attempt, err = s.repo.InsertAttempt(attempt)
+	if err != nil {
+		log.Error("failed to save attempt", slog.Int("quiz_id", quizID), slog.Int("user_id", userID), slog.Any("err", err))
+		return attempt, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return attempt, nil
+}
+
+// GetAttempts returns the user's own attempt history of a quiz
+func (s *QuizService) GetAttempts(quizID, userID int) ([]structures.QuizAttempt, error) {
+	q, err := s.repo.SelectQuizById(quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkAccess(userID, q.CourseID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SelectAttempts(quizID, userID)
+}
+
+// GetAllAttempts returns attempts of every learner, for admins
+func (s *QuizService) GetAllAttempts(quizID int) ([]structures.QuizAttempt, error) {
+	if _, err := s.repo.SelectQuizById(quizID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.SelectAttempts(quizID, 0)
+}
+
+func scoreQuiz(q structures.Quiz, sub structures.QuizSubmission) structures.QuizAttempt {
+	answers := make(map[int]structures.QuizAnswer, len(sub.Answers))
+	for _, a := range sub.Answers {
+		answers[a.QuestionID] = a
+	}
+
+	attempt := structures.QuizAttempt{QuizID: q.Id}
+	for _, qq := range q.Questions {
+		a := answers[qq.Id]
+		a.QuestionID = qq.Id
+		a.Correct = isCorrect(qq, a)
+
+		attempt.MaxScore += qq.Points
+		if a.Correct {
+			attempt.Score += qq.Points
+		}
+		attempt.Answers = append(attempt.Answers, a)
+	}
+
+	if attempt.MaxScore > 0 {
+		attempt.Percent = attempt.Score * 100 / attempt.MaxScore
+	}
+	attempt.Passed = attempt.Percent >= q.PassScore
+
+	return attempt
+}
+
+func isCorrect(qq structures.QuizQuestion, a structures.QuizAnswer) bool {
+	switch qq.Kind {
+	case structures.QuestionText:
+		given := normalizeAnswer(a.Text)
+		if given == "" {
+			return false
+		}
+		for _, accepted := range qq.AcceptedAnswers {
+			if normalizeAnswer(accepted) == given {
+				return true
+			}
+		}
+		return false
+	default:
+		var correct []int
+		for _, o := range qq.Options {
+			if o.IsCorrect {
+				correct = append(correct, o.Id)
+			}
+		}
+
+		chosen := slices.Clone(a.OptionIDs)
+		slices.Sort(chosen)
+		chosen = slices.Compact(chosen)
+		slices.Sort(correct)
+
+		if qq.Kind == structures.QuestionSingle && len(chosen) != 1 {
+			return false
+		}
+		return slices.Equal(chosen, correct)
+	}
+}
+
+func normalizeAnswer(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/notify"
+)
+
+type ReminderService struct {
+	repo   *postgres.ReminderRepo
+	sender notify.Sender
+	log    *slog.Logger
+	cfg    *config.Config
+}
+
+func NewReminderService(repo *postgres.ReminderRepo, log *slog.Logger, cfg *config.Config, sender notify.Sender) *ReminderService {
+	return &ReminderService{
+		repo:   repo,
+		sender: sender,
+		log:    log,
+		cfg:    cfg,
+	}
+}
+
+// Run checks for due reminders every cfg.Reminders.Interval until ctx is cancelled
+func (s *ReminderService) Run(ctx context.Context) {
+	const op = "service.reminder_service.Run"
+	log := s.log.With("op", op)
+
+	if len(s.cfg.Reminders.Offsets) == 0 || s.cfg.Reminders.Interval <= 0 {
+		log.Info("webinar reminders are disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Reminders.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.SendDue(time.Now())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue notifies users about webinars that entered one of the reminder windows.
+// Offsets are handled from the smallest, so a webinar scheduled an hour ahead
+// gets only the 1h reminder and not a late 24h one as well.
+func (s *ReminderService) SendDue(now time.Time) {
+	const op = "service.reminder_service.SendDue"
+	log := s.log.With("op", op)
+
+	offsets := make([]int, 0, len(s.cfg.Reminders.Offsets))
+	for _, o := range s.cfg.Reminders.Offsets {
+		offsets = append(offsets, int(o.Minutes()))
+	}
+	slices.Sort(offsets)
+	offsets = slices.Compact(offsets)
+
+	for i, offset := range offsets {
+		reminders, err := s.repo.SelectDue(now, offset)
+		if err != nil {
+			log.Error("failed to get due reminders", slog.Int("offset", offset), slog.Any("err", err))
+			return
+		}
+
+		for _, rm := range reminders {
+			if err := s.sender.Send(reminderMessage(rm, now)); err != nil {
+				log.Error("failed to send reminder", slog.Int("webinar_id", rm.WebinarID), slog.Int("user_id", rm.UserID), slog.Any("err", err))
+				continue
+			}
+
+			if err := s.repo.MarkSent(rm.WebinarID, rm.UserID, offsets[i:]); err != nil {
+				log.Error("failed to mark reminder as sent", slog.Int("webinar_id", rm.WebinarID), slog.Int("user_id", rm.UserID), slog.Any("err", err))
+			}
+		}
+
+		if len(reminders) > 0 {
+			log.Info("webinar reminders sent", slog.Int("offset", offset), slog.Int("count", len(reminders)))
+		}
+	}
+}
+
+func reminderMessage(rm structures.WebinarReminder, now time.Time) notify.Message {
+	title := rm.Title
+	if title == "" {
+		title = rm.CourseTitle
+	}
+
+	left := rm.Date.Sub(now).Round(time.Minute)
+
+	return notify.Message{
+		UserID:   rm.UserID,
+		Username: rm.Username,
+		Subject:  "Напоминание о вебинаре",
+		Body: fmt.Sprintf("Вебинар «%s» курса «%s» начнётся %s UTC (через %s). Ссылка: %s",
+			title, rm.CourseTitle, rm.Date.Format("02.01.2006 15:04"), left, rm.Link),
+	}
+}
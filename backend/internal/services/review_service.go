@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+const (
+	maxReviewLength   = 5000
+	defaultReviewPage = 20
+	maxReviewPage     = 100
+)
+
+var ErrInvalidCourseReview = errors.New("invalid course review")
+
+type ReviewService struct {
+	repo          *postgres.ReviewRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewReviewService(repo *postgres.ReviewRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService) *ReviewService {
+	return &ReviewService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// Submit saves the user's review of a course they have access to. There is one review per user and
+// course, submitting again replaces it and sends it back to moderation.
+func (s *ReviewService) Submit(userID, courseID int, req structures.CourseReviewRequest) (structures.CourseReview, error) {
+	const op = "service.review_service.Submit"
+	log := s.log.With("op", op)
+
+	text := strings.TrimSpace(req.Text)
+	if req.Rating < 1 || req.Rating > 5 {
+		return structures.CourseReview{}, fmt.Errorf("%w: rating must be from 1 to 5", ErrInvalidCourseReview)
+	}
+	if utf8.RuneCountInString(text) > maxReviewLength {
+		return structures.CourseReview{}, fmt.Errorf("%w: text is longer than %d characters", ErrInvalidCourseReview, maxReviewLength)
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return structures.CourseReview{}, ErrCourseNotFound
+	}
+
+	hasAccess, err := s.courseService.HasAccess(userID, courseID)
+	if err != nil {
+		return structures.CourseReview{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !hasAccess {
+		return structures.CourseReview{}, ErrNoAccess
+	}
+
+	id, err := s.repo.Upsert(structures.CourseReview{
+		UserID:   userID,
+		CourseID: courseID,
+		Rating:   req.Rating,
+		Text:     text,
+	})
+	if err != nil {
+		return structures.CourseReview{}, err
+	}
+
+	return s.repo.SelectReviewById(id)
+}
+
+func (s *ReviewService) GetMyReview(userID, courseID int) (structures.CourseReview, error) {
+	return s.repo.SelectByUser(userID, courseID)
+}
+
+func (s *ReviewService) DeleteMyReview(userID, courseID int) error {
+	review, err := s.repo.SelectByUser(userID, courseID)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(review.Id, userID)
+}
+
+// GetCourseReviews returns a page of approved reviews of a course that is on sale, newest first
+func (s *ReviewService) GetCourseReviews(courseID, page, limit int) (structures.CourseReviewPage, error) {
+	const op = "service.review_service.GetCourseReviews"
+	log := s.log.With("op", op)
+
+	course, err := s.courseRepo.SelectCourseById(courseID)
+	if err != nil || !visible(course, false) {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return structures.CourseReviewPage{}, ErrCourseNotFound
+	}
+
+	result, err := s.getPage(structures.CourseReviewFilter{
+		CourseID: courseID,
+		Status:   structures.CourseReviewApproved,
+	}, page, limit, false)
+	if err != nil {
+		return result, err
+	}
+
+	// moderation details are for admins only
+	for i := range result.Reviews {
+		r := &result.Reviews[i]
+		r.CourseTitle, r.Status, r.AdminComment = "", "", ""
+		r.ModeratedBy, r.ModeratedAt = 0, nil
+	}
+
+	return result, nil
+}
+
+// GetReviews returns a page of the admin moderation queue, oldest first
+func (s *ReviewService) GetReviews(filter structures.CourseReviewFilter, page, limit int) (structures.CourseReviewPage, error) {
+	return s.getPage(filter, page, limit, true)
+}
+
+func (s *ReviewService) getPage(filter structures.CourseReviewFilter, page, limit int, oldestFirst bool) (structures.CourseReviewPage, error) {
+	if limit <= 0 {
+		limit = defaultReviewPage
+	}
+	limit = min(limit, maxReviewPage)
+	page = max(page, 1)
+
+	filter.Limit = limit
+	filter.Offset = (page - 1) * limit
+
+	reviews, total, err := s.repo.SelectReviews(filter, oldestFirst)
+	if err != nil {
+		return structures.CourseReviewPage{}, err
+	}
+
+	return structures.CourseReviewPage{
+		Reviews: reviews,
+		Total:   total,
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+func (s *ReviewService) Approve(id, adminID int, comment string) error {
+	return s.repo.Moderate(id, structures.CourseReviewApproved, strings.TrimSpace(comment), adminID)
+}
+
+func (s *ReviewService) Reject(id, adminID int, comment string) error {
+	return s.repo.Moderate(id, structures.CourseReviewRejected, strings.TrimSpace(comment), adminID)
+}
+
+func (s *ReviewService) DeleteReview(id int) error {
+	return s.repo.Delete(id, 0)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+// MaxUploadSize is the biggest video accepted by the resumable upload
+const MaxUploadSize int64 = 20 * 1024 * 1024 * 1024
+
+var ErrUploadTooLarge = errors.New("upload exceeds the maximum size")
+
+type UploadService struct {
+	repo       *postgres.UploadRepo
+	courseRepo *postgres.CourseRepo
+	log        *slog.Logger
+	cfg        *config.Config
+}
+
+func NewUploadService(repo *postgres.UploadRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo) *UploadService {
+	return &UploadService{
+		repo:       repo,
+		courseRepo: courseRepo,
+		log:        log,
+		cfg:        cfg,
+	}
+}
+
+func (s *UploadService) CreateSession(u structures.UploadSession) (structures.UploadSession, error) {
+	const op = "service.upload_service.CreateSession"
+	log := s.log.With("op", op)
+
+	if u.Size <= 0 || u.Size > MaxUploadSize {
+		return u, ErrUploadTooLarge
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(u.CourseID); err != nil {
+		log.Warn("course for upload not found", slog.Int("course_id", u.CourseID))
+		return u, fmt.Errorf("course with id=%d not found", u.CourseID)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+	u.Id = hex.EncodeToString(b)
+	u.Status = structures.UploadStatusUploading
+
+	if err := s.repo.InsertSession(u); err != nil {
+		log.Error("failed to create upload session", slog.Any("err", err))
+		return u, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.repo.SelectSession(u.Id)
+}
+
+func (s *UploadService) GetSession(id string) (structures.UploadSession, error) {
+	return s.repo.SelectSession(id)
+}
+
+// Advance runs write for a chunk of n bytes at offset from while the session is locked and records the new offset.
+// It fails with ErrUploadOffsetMoved without writing when another chunk got there first.
+func (s *UploadService) Advance(id string, from, n int64, write func() error) error {
+	return s.repo.AdvanceOffset(id, from, n, write)
+}
+
+// Complete attaches the assembled video to its course. A session completed by an earlier attempt is not an error,
+// the retry of the last chunk must not add the lesson again.
+func (s *UploadService) Complete(u structures.UploadSession, videoPath string) error {
+	const op = "service.upload_service.Complete"
+	log := s.log.With("op", op)
+
+	if err := s.repo.CompleteSession(u.Id, videoPath); err != nil {
+		if errors.Is(err, postgres.ErrUploadNotUploading) {
+			log.Info("upload session already completed", slog.String("id", u.Id))
+			return nil
+		}
+		log.Error("failed to complete upload session", slog.String("id", u.Id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RunCleanup deletes uploads abandoned for longer than uploads.session_ttl until ctx is cancelled.
+// removeParts gets the ids of the deleted sessions and the time older partial files may be removed before.
+func (s *UploadService) RunCleanup(ctx context.Context, removeParts func(ids []string, staleBefore time.Time)) {
+	const op = "service.upload_service.RunCleanup"
+	log := s.log.With("op", op)
+
+	if s.cfg.Uploads.CleanupInterval <= 0 || s.cfg.Uploads.SessionTTL <= 0 {
+		log.Info("abandoned upload cleanup is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.Uploads.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		staleBefore := time.Now().Add(-s.cfg.Uploads.SessionTTL)
+		ids, err := s.repo.DeleteStaleSessions(staleBefore)
+		if err != nil {
+			log.Error("failed to delete abandoned uploads", slog.Any("err", err))
+		} else {
+			if len(ids) > 0 {
+				log.Info("abandoned uploads deleted", slog.Int("count", len(ids)))
+			}
+			removeParts(ids, staleBefore)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *UploadService) DeleteSession(id string) error {
+	return s.repo.DeleteSession(id)
+}
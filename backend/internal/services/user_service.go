@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 
@@ -12,6 +13,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var ErrInvalidRole = errors.New("role must be user, curator or admin")
+
 type UserService struct {
 	log  *slog.Logger
 	repo *postgres.UserRepo
@@ -112,3 +115,25 @@ func (s *UserService) GetAllUsers() ([]structures.User, error) {
 
 	return users, nil
 }
+
+// SetRole changes the user's role. The new role applies after the user signs in again.
+func (s *UserService) SetRole(id int, role string) error {
+	const op = "service.user_service.SetRole"
+	log := s.log.With("op", op)
+
+	switch role {
+	case structures.RoleUser, structures.RoleCurator, structures.RoleAdmin:
+	default:
+		return ErrInvalidRole
+	}
+
+	if err := s.repo.UpdateRole(id, role); err != nil {
+		if errors.Is(err, postgres.ErrUserNotFound) {
+			return err
+		}
+		log.Error("failed to update role", slog.Int("id", id), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
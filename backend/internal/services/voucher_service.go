@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+	"github.com/QwaQ-dev/bala/pkg/codegen"
+)
+
+const maxVoucherBatch = 5000
+
+var (
+	ErrInvalidVoucherBatch = errors.New("invalid voucher batch")
+	ErrVoucherExpired      = errors.New("voucher has expired")
+)
+
+type VoucherService struct {
+	repo          *postgres.VoucherRepo
+	courseRepo    *postgres.CourseRepo
+	courseService *CourseService
+	log           *slog.Logger
+	cfg           *config.Config
+}
+
+func NewVoucherService(repo *postgres.VoucherRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, courseService *CourseService) *VoucherService {
+	return &VoucherService{
+		repo:          repo,
+		courseRepo:    courseRepo,
+		courseService: courseService,
+		log:           log,
+		cfg:           cfg,
+	}
+}
+
+// CreateBatch generates count single-use codes for the course. Codes for a course with cohorts give a seat in the cohort of the batch.
+func (s *VoucherService) CreateBatch(req structures.VoucherBatchRequest) (structures.VoucherBatch, error) {
+	const op = "service.voucher_service.CreateBatch"
+	log := s.log.With("op", op)
+
+	if req.Count <= 0 || req.Count > maxVoucherBatch {
+		return structures.VoucherBatch{}, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidVoucherBatch, maxVoucherBatch)
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		return structures.VoucherBatch{}, fmt.Errorf("%w: expires_at is in the past", ErrInvalidVoucherBatch)
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(req.CourseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", req.CourseID))
+		return structures.VoucherBatch{}, ErrCourseNotFound
+	}
+	if err := s.courseService.CheckCohort(0, req.CourseID, req.CohortID); err != nil {
+		return structures.VoucherBatch{}, err
+	}
+
+	batch := structures.VoucherBatch{
+		CourseID:  req.CourseID,
+		CohortID:  req.CohortID,
+		Title:     strings.TrimSpace(req.Title),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	id, err := s.repo.InsertBatch(batch, req.Count, func() (string, error) {
+		return codegen.Generate(3, 4)
+	})
+	if err != nil {
+		log.Error("failed to create voucher batch", slog.Int("course_id", req.CourseID), slog.Any("err", err))
+		return structures.VoucherBatch{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return s.repo.SelectBatch(id)
+}
+
+func (s *VoucherService) GetBatches(courseID int) ([]structures.VoucherBatch, error) {
+	return s.repo.SelectBatches(courseID)
+}
+
+func (s *VoucherService) GetBatch(id int) (structures.VoucherBatch, error) {
+	return s.repo.SelectBatch(id)
+}
+
+func (s *VoucherService) DeleteBatch(id int) error {
+	return s.repo.DeleteBatch(id)
+}
+
+// ExportBatch renders the codes of the batch as CSV for printing
+func (s *VoucherService) ExportBatch(id int) (structures.VoucherBatch, []byte, error) {
+	batch, err := s.repo.SelectBatch(id)
+	if err != nil {
+		return batch, nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff") // BOM, so Excel opens Cyrillic course titles correctly
+
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"code", "course", "expires_at", "redeemed_by", "redeemed_at"})
+
+	expires := ""
+	if batch.ExpiresAt != nil {
+		expires = batch.ExpiresAt.Format(time.DateOnly)
+	}
+	for _, v := range batch.Vouchers {
+		redeemedAt := ""
+		if v.RedeemedAt != nil {
+			redeemedAt = v.RedeemedAt.Format(time.DateTime)
+		}
+		w.Write([]string{v.Code, batch.CourseTitle, expires, v.Username, redeemedAt})
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return batch, nil, err
+	}
+
+	return batch, buf.Bytes(), nil
+}
+
+// Redeem checks the code and gives the user access to its course
+func (s *VoucherService) Redeem(userID int, code string) (int, error) {
+	const op = "service.voucher_service.Redeem"
+	log := s.log.With("op", op)
+
+	voucher, err := s.repo.SelectByCode(codegen.Normalize(code))
+	if err != nil {
+		return 0, err
+	}
+	if voucher.RedeemedAt != nil {
+		return 0, postgres.ErrVoucherRedeemed
+	}
+	if voucher.ExpiresAt != nil && time.Now().After(*voucher.ExpiresAt) {
+		return 0, ErrVoucherExpired
+	}
+
+	// a code is not burnt on someone who can already open the course
+	hasAccess, err := s.courseService.HasAccess(userID, voucher.CourseID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if hasAccess {
+		return voucher.CourseID, ErrAlreadyHasAccess
+	}
+
+	if err := s.repo.MarkRedeemed(voucher.Id, userID); err != nil {
+		return 0, err
+	}
+
+	if err := s.courseService.GiveAccess(structures.Enrollment{
+		UserID:   userID,
+		CourseID: voucher.CourseID,
+		CohortID: voucher.CohortID,
+		Source:   structures.EnrollmentVoucher,
+	}); err != nil {
+		log.Error("failed to give access", slog.Int("voucher_id", voucher.Id), slog.Int("user_id", userID), slog.Any("err", err))
+		if err := s.repo.Release(voucher.Id); err != nil {
+			log.Error("failed to release voucher", slog.Int("voucher_id", voucher.Id), slog.Any("err", err))
+		}
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("voucher redeemed", slog.Int("voucher_id", voucher.Id), slog.Int("user_id", userID), slog.Int("course_id", voucher.CourseID))
+	return voucher.CourseID, nil
+}
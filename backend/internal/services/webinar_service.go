@@ -0,0 +1,185 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/QwaQ-dev/bala/internal/config"
+	"github.com/QwaQ-dev/bala/internal/repository/postgres"
+	"github.com/QwaQ-dev/bala/internal/structures"
+)
+
+const (
+	// DefaultWebinarDuration is used when the admin doesn't set one, in minutes
+	DefaultWebinarDuration = 60
+	// maxSeriesLength keeps a typo in "count" from creating years of sessions
+	maxSeriesLength = 52
+)
+
+var ErrInvalidWebinar = errors.New("invalid webinar")
+
+// repeat intervals of a webinar series
+var webinarRepeats = map[string]time.Duration{
+	"":         0,
+	"none":     0,
+	"daily":    24 * time.Hour,
+	"weekly":   7 * 24 * time.Hour,
+	"biweekly": 14 * 24 * time.Hour,
+}
+
+type WebinarService struct {
+	repo       *postgres.WebinarRepo
+	courseRepo *postgres.CourseRepo
+	cohortRepo *postgres.CohortRepo
+	log        *slog.Logger
+	cfg        *config.Config
+}
+
+func NewWebinarService(repo *postgres.WebinarRepo, log *slog.Logger, cfg *config.Config, courseRepo *postgres.CourseRepo, cohortRepo *postgres.CohortRepo) *WebinarService {
+	return &WebinarService{
+		repo:       repo,
+		courseRepo: courseRepo,
+		cohortRepo: cohortRepo,
+		log:        log,
+		cfg:        cfg,
+	}
+}
+
+// CreateWebinars schedules a single webinar or a recurring series and returns the created webinars.
+// With CohortID the webinars are on the schedule of that cohort only.
+func (s *WebinarService) CreateWebinars(courseID int, req structures.WebinarRequest) ([]structures.Webinar, error) {
+	const op = "service.webinar_service.CreateWebinars"
+	log := s.log.With("op", op)
+
+	if req.Link == "" || req.Date.IsZero() {
+		return nil, fmt.Errorf("%w: link and date are required", ErrInvalidWebinar)
+	}
+
+	interval, ok := webinarRepeats[req.Repeat]
+	if !ok {
+		return nil, fmt.Errorf("%w: repeat must be none, daily, weekly or biweekly", ErrInvalidWebinar)
+	}
+
+	count := 1
+	if interval > 0 {
+		count = req.Count
+		if count < 1 || count > maxSeriesLength {
+			return nil, fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidWebinar, maxSeriesLength)
+		}
+	}
+
+	if req.Duration <= 0 {
+		req.Duration = DefaultWebinarDuration
+	}
+
+	if _, err := s.courseRepo.SelectCourseById(courseID); err != nil {
+		log.Warn("course not found", slog.Int("course_id", courseID))
+		return nil, ErrCourseNotFound
+	}
+	if req.CohortID != 0 {
+		cohort, err := s.cohortRepo.SelectCohort(req.CohortID)
+		if err != nil || cohort.CourseID != courseID {
+			return nil, fmt.Errorf("%w: cohort %d is not a cohort of the course", ErrInvalidWebinar, req.CohortID)
+		}
+	}
+
+	seriesID := ""
+	if count > 1 {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		seriesID = hex.EncodeToString(b)
+	}
+
+	webinars := make([]structures.Webinar, count)
+	for i := range webinars {
+		webinars[i] = structures.Webinar{
+			CourseID: courseID,
+			Title:    req.Title,
+			Link:     req.Link,
+			Date:     req.Date.UTC().Add(time.Duration(i) * interval),
+			Duration: req.Duration,
+			Status:   structures.WebinarScheduled,
+			SeriesID: seriesID,
+			CohortID: req.CohortID,
+		}
+	}
+
+	ids, err := s.repo.InsertWebinars(courseID, webinars)
+	if err != nil {
+		log.Error("failed to create webinars", slog.Int("course_id", courseID), slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for i, id := range ids {
+		webinars[i].Id = id
+	}
+
+	return webinars, nil
+}
+
+func (s *WebinarService) GetCourseWebinars(courseID int) ([]structures.Webinar, error) {
+	return s.repo.SelectByCourse(courseID)
+}
+
+// UpdateWebinar edits a single occurrence, other webinars of its series stay unchanged
+func (s *WebinarService) UpdateWebinar(id int, req structures.WebinarRequest) (structures.Webinar, error) {
+	const op = "service.webinar_service.UpdateWebinar"
+	log := s.log.With("op", op)
+
+	w, err := s.repo.SelectById(id)
+	if err != nil {
+		return w, err
+	}
+
+	if req.Title != "" {
+		w.Title = req.Title
+	}
+	if req.Link != "" {
+		w.Link = req.Link
+	}
+	if !req.Date.IsZero() {
+		w.Date = req.Date.UTC()
+	}
+	if req.Duration > 0 {
+		w.Duration = req.Duration
+	}
+
+	if err := s.repo.UpdateWebinar(w); err != nil {
+		log.Error("failed to update webinar", slog.Int("id", id), slog.Any("err", err))
+		return w, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return w, nil
+}
+
+// CancelWebinar cancels one webinar or, with wholeSeries, it and every later occurrence of its series
+func (s *WebinarService) CancelWebinar(id int, wholeSeries bool) (int, error) {
+	const op = "service.webinar_service.CancelWebinar"
+	log := s.log.With("op", op)
+
+	w, err := s.repo.SelectById(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if wholeSeries && w.SeriesID != "" {
+		count, err := s.repo.CancelSeries(w.SeriesID, w.Date)
+		if err != nil {
+			log.Error("failed to cancel series", slog.String("series_id", w.SeriesID), slog.Any("err", err))
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return count, nil
+	}
+
+	if err := s.repo.CancelWebinar(id); err != nil {
+		log.Error("failed to cancel webinar", slog.Int("id", id), slog.Any("err", err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return 1, nil
+}
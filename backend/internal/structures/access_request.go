@@ -0,0 +1,41 @@
+package structures
+
+import "time"
+
+const (
+	AccessRequestPending  = "pending"
+	AccessRequestApproved = "approved"
+	AccessRequestRejected = "rejected"
+)
+
+// AccessRequest is a user's request to be let into a course after paying outside the site
+type AccessRequest struct {
+	Id           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Username     string     `json:"username,omitempty"`
+	CourseID     int        `json:"course_id"`
+	CourseTitle  string     `json:"course_title,omitempty"`
+	Contact      string     `json:"contact"`
+	Comment      string     `json:"comment"`
+	ReceiptPath  string     `json:"-"`
+	ReceiptURL   string     `json:"receipt_url,omitempty"`
+	Status       string     `json:"status"`
+	AdminComment string     `json:"admin_comment"`
+	ReviewedBy   int        `json:"reviewed_by,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type AccessRequestFilter struct {
+	UserID   int
+	CourseID int
+	Status   string
+}
+
+// AccessDecision approves or rejects a request, ExpiresAt limits the access given on approval
+// and CohortID places the user in a cohort of the course
+type AccessDecision struct {
+	Comment   string     `json:"comment"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CohortID  int        `json:"cohort_id"`
+}
@@ -0,0 +1,37 @@
+package structures
+
+import "time"
+
+// Bundle sells several courses at a combined price
+type Bundle struct {
+	Id          int            `json:"id"`
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Cost        int            `json:"cost"`
+	Active      bool           `json:"active"`
+	Courses     []BundleCourse `json:"courses"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// BundleCourse is a course of the bundle, PlanID 0 gives full access to it
+type BundleCourse struct {
+	CourseID    int    `json:"course_id"`
+	CourseTitle string `json:"course_title,omitempty"`
+	PlanID      int    `json:"plan_id,omitempty"`
+	PlanTitle   string `json:"plan_title,omitempty"`
+}
+
+type BundleRequest struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description"`
+	Cost        int            `json:"cost"`
+	Active      *bool          `json:"active"` // true by default
+	Courses     []BundleCourse `json:"courses"`
+}
+
+// BundleGrant gives a user every course of a bundle without payment
+type BundleGrant struct {
+	UserID    int        `json:"user_id"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CohortIDs []int      `json:"cohort_ids"`
+}
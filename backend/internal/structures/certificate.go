@@ -0,0 +1,29 @@
+package structures
+
+import "time"
+
+type Certificate struct {
+	Id           int        `json:"id"`
+	Code         string     `json:"code"`
+	UserID       int        `json:"user_id"`
+	CourseID     int        `json:"course_id"`
+	HolderName   string     `json:"holder_name"`
+	CourseTitle  string     `json:"course_title"`
+	IssuedAt     time.Time  `json:"issued_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	RevokeReason string     `json:"revoke_reason,omitempty"`
+}
+
+// CertificateVerification is the public answer of the verification registry
+type CertificateVerification struct {
+	Valid       bool       `json:"valid"`
+	Code        string     `json:"code"`
+	HolderName  string     `json:"holder_name"`
+	CourseTitle string     `json:"course_title"`
+	IssuedAt    time.Time  `json:"issued_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CertificateRevokeRequest struct {
+	Reason string `json:"reason"`
+}
@@ -1,7 +1,7 @@
 package structures
 
 type Checklist struct {
-	Id          int64  `json:"id, omitempty"`
+	Id          int64  `json:"id"`
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	ForAge      int    `json:"forAge"`
@@ -0,0 +1,28 @@
+package structures
+
+import "time"
+
+// Cohort is a run of a course with a fixed group of learners who start together
+type Cohort struct {
+	Id       int       `json:"id"`
+	CourseID int       `json:"course_id"`
+	Title    string    `json:"title"`
+	StartsAt time.Time `json:"starts_at"`
+	Capacity int       `json:"capacity"` // 0 - unlimited
+	Enrolled int       `json:"enrolled"` // learners with active access
+	// curators review homework of this cohort only, hidden from learners
+	Curators  []CohortCurator `json:"curators,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type CohortCurator struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+type CohortRequest struct {
+	Title      string    `json:"title"`
+	StartsAt   time.Time `json:"starts_at"`
+	Capacity   int       `json:"capacity"`
+	CuratorIDs []int     `json:"curator_ids"`
+}
@@ -2,39 +2,162 @@ package structures
 
 import "time"
 
+// Course lifecycle: only published courses are listed to users, scheduled ones are published by a job at PublishAt
+const (
+	CourseDraft     = "draft"
+	CourseScheduled = "scheduled"
+	CoursePublished = "published"
+	CourseArchived  = "archived"
+)
+
 type Course struct {
-	Id          int     `json:"id"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Cost        int     `json:"cost"`
-	DiplomaPath string  `json:"diploma_path"`
-	Diploma_x   int     `json:"diploma_x"`
-	Diploma_y   int     `json:"diploma_y"`
-	Videos      []Video `json:"videos, omitempty"`
-	Webinars    Webinar `json:"webinars"`
-	Img         string  `json:"img"`
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Cost        int        `json:"cost"`
+	DiplomaPath string     `json:"diploma_path"`
+	Diploma_x   int        `json:"diploma_x"`
+	Diploma_y   int        `json:"diploma_y"`
+	Modules     []Module   `json:"modules,omitempty"`
+	Videos      []Video    `json:"videos,omitempty"`
+	Webinars    []Webinar  `json:"webinars"`
+	Img         string     `json:"img"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+
+	Plans []CoursePlan `json:"plans,omitempty"` // plans on sale
+
+	// features the viewer has, only set for users with access
+	Features []string `json:"features,omitempty"`
+
+	// prerequisite courses of learning paths the viewer hasn't completed yet, lessons stay locked until they are
+	RequiredCourses []int `json:"required_courses,omitempty"`
+
+	// average of approved reviews, 0 without any
+	Rating      float64 `json:"rating"`
+	ReviewCount int     `json:"review_count"`
+
+	Progress *CourseProgress `json:"progress,omitempty"`
+}
+
+// Module is a section of a course, its lessons are ordered by Position
+type Module struct {
+	Id       int     `json:"id"`
+	CourseID int     `json:"course_id"`
+	Title    string  `json:"title"`
+	Position int     `json:"position"`
+	Lessons  []Video `json:"lessons"`
 }
 
+// Video is a lesson of a module. Path is empty for text-only lessons.
 type Video struct {
-	Id    int    `json:"id"`
-	Path  string `json:"path"`
+	Id          int                `json:"id"`
+	CourseID    int                `json:"course_id"`
+	ModuleID    int                `json:"module_id"`
+	Position    int                `json:"position"`
+	Path        string             `json:"path"`
+	Title       string             `json:"title"`
+	Content     string             `json:"content"`
+	File        string             `json:"file"`
+	IsPreview   bool               `json:"is_preview"` // free lesson, open without enrollment
+	Duration    int                `json:"duration"`   // seconds
+	Attachments []LessonAttachment `json:"attachments,omitempty"`
+
+	// Drip schedule: the lesson opens UnlockAfterDays after access was granted and not before UnlockAt
+	UnlockAfterDays *int       `json:"unlock_after_days"`
+	UnlockAt        *time.Time `json:"unlock_at"`
+
+	// Locked lessons come without content and links to users who can't open them.
+	// AvailableAt is set when the lesson opens for the user by itself on the drip schedule.
+	Locked      bool       `json:"locked,omitempty"`
+	AvailableAt *time.Time `json:"available_at,omitempty"`
+
+	// Short-lived signed links for <video> / <a> tags, the raw paths are not served publicly
+	StreamURL string `json:"stream_url,omitempty"`
+	FileURL   string `json:"file_url,omitempty"`
+
+	Progress *VideoProgress `json:"progress,omitempty"`
+}
+
+type LessonAttachment struct {
+	Id       int    `json:"id"`
+	VideoID  int    `json:"video_id"`
+	Title    string `json:"title"`
+	Path     string `json:"path"`
+	Position int    `json:"position"`
+
+	URL string `json:"url,omitempty"`
+}
+
+type ModuleRequest struct {
+	Title string `json:"title"`
+}
+
+type LessonUpdateRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// LessonUnlockRequest sets the drip schedule of a lesson, both fields empty open it right away
+type LessonUnlockRequest struct {
+	UnlockAfterDays *int       `json:"unlock_after_days"`
+	UnlockAt        *time.Time `json:"unlock_at"`
+}
+
+// OrderRequest lists ids in their new order
+type OrderRequest struct {
+	Ids []int `json:"ids"`
+}
+
+type CourseStatusRequest struct {
+	Status    string     `json:"status"`
+	PublishAt *time.Time `json:"publish_at"` // required for scheduled
+}
+
+// CourseCopyRequest duplicates a course as a new draft, an empty title keeps the original one with a "(копия)" suffix
+type CourseCopyRequest struct {
 	Title string `json:"title"`
-	File  string `json:"file"`
 }
 
 type CourseAccessRequest struct {
-	UserID   int `json:"user_id"`
-	CourseID int `json:"course_id"`
+	UserID    int        `json:"user_id"`
+	CourseID  int        `json:"course_id"`
+	PlanID    int        `json:"plan_id"`    // 0 - every feature
+	CohortID  int        `json:"cohort_id"`  // 0 - keeps the current cohort; required for courses with cohorts otherwise
+	ExpiresAt *time.Time `json:"expires_at"` // nil - forever
 }
 
 type CourseWithAccess struct {
 	Course
-	HasAccess bool `json:"has_access"`
+	HasAccess       bool           `json:"has_access"`
+	AccessExpiresAt *time.Time     `json:"access_expires_at,omitempty"`
+	AccessRequest   *AccessRequest `json:"access_request,omitempty"` // the latest request of the user
 }
 
+const (
+	WebinarScheduled = "scheduled"
+	WebinarCancelled = "cancelled"
+)
+
 type Webinar struct {
 	Id       int       `json:"id"`
+	CourseID int       `json:"course_id"`
+	Title    string    `json:"title"`
 	Link     string    `json:"link"`
 	Date     time.Time `json:"date"`
-	CourseID int       `json:"course_id"`
+	Duration int       `json:"duration"` // minutes
+	Status   string    `json:"status"`
+	SeriesID string    `json:"series_id,omitempty"`
+	CohortID int       `json:"cohort_id,omitempty"` // 0 - shown to every learner of the course
+}
+
+// WebinarRequest creates one webinar or, with Repeat and Count, a recurring series
+type WebinarRequest struct {
+	Title    string    `json:"title"`
+	Link     string    `json:"link"`
+	Date     time.Time `json:"date"`
+	Duration int       `json:"duration"`
+	Repeat   string    `json:"repeat"` // none, daily, weekly, biweekly
+	Count    int       `json:"count"`
+	CohortID int       `json:"cohort_id"` // schedule of one cohort, set on creation only
 }
@@ -0,0 +1,35 @@
+package structures
+
+import "time"
+
+type DiplomaFieldKind string
+
+const (
+	DiplomaFieldName              DiplomaFieldKind = "name"
+	DiplomaFieldCourseTitle       DiplomaFieldKind = "course_title"
+	DiplomaFieldIssueDate         DiplomaFieldKind = "issue_date"
+	DiplomaFieldCertificateNumber DiplomaFieldKind = "certificate_number"
+	DiplomaFieldText              DiplomaFieldKind = "text"
+)
+
+type DiplomaField struct {
+	Id       int              `json:"id"`
+	CourseID int              `json:"course_id"`
+	Kind     DiplomaFieldKind `json:"kind"`
+	Text     string           `json:"text"`
+	X        int              `json:"x"`
+	Y        int              `json:"y"`
+	FontSize float64          `json:"font_size"`
+	Color    string           `json:"color"`
+	Align    string           `json:"align"`
+}
+
+// Diploma is everything needed to render a diploma for one user
+type Diploma struct {
+	TemplatePath      string         `json:"template_path"`
+	HolderName        string         `json:"holder_name"`
+	CourseTitle       string         `json:"course_title"`
+	CertificateNumber string         `json:"certificate_number"`
+	IssuedAt          time.Time      `json:"issued_at"`
+	Fields            []DiplomaField `json:"fields"`
+}
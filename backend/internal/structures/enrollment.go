@@ -0,0 +1,72 @@
+package structures
+
+import "time"
+
+// Enrollment sources
+const (
+	EnrollmentManual  = "manual"
+	EnrollmentPayment = "payment"
+	EnrollmentVoucher = "voucher"
+)
+
+// Enrollment gives a user access to a course until ExpiresAt (nil - forever)
+type Enrollment struct {
+	Id          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Username    string     `json:"username,omitempty"`
+	CourseID    int        `json:"course_id"`
+	CourseTitle string     `json:"course_title,omitempty"`
+	PlanID      int        `json:"plan_id,omitempty"` // 0 - every feature of the course
+	PlanTitle   string     `json:"plan_title,omitempty"`
+	CohortID    int        `json:"cohort_id,omitempty"` // 0 - self-paced
+	CohortTitle string     `json:"cohort_title,omitempty"`
+	Source      string     `json:"source"`
+	OrderID     int        `json:"order_id,omitempty"` // the paid order that granted the access
+	GrantedBy   int        `json:"granted_by,omitempty"`
+	GrantedAt   time.Time  `json:"granted_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	Active      bool       `json:"active"`
+}
+
+type EnrollmentFilter struct {
+	UserID   int
+	CourseID int
+	CohortID int
+}
+
+// Bulk access import
+const (
+	AccessGrant  = "grant"
+	AccessRevoke = "revoke"
+
+	AccessGranted       = "granted"
+	AccessAlreadyHad    = "already_has_access"
+	AccessRevoked       = "revoked"
+	AccessNotEnrolled   = "not_enrolled"
+	AccessUnknownUser   = "unknown_user"
+	AccessUnknownCourse = "unknown_course"
+	AccessUnknownCohort = "unknown_cohort"
+	AccessNeedsCohort   = "cohort_required"
+	AccessCohortFull    = "cohort_full"
+	AccessInvalidRow    = "invalid"
+)
+
+// AccessImportRow is one CSV line: a username or user id, a course id, an optional expiry and a cohort id
+// (required for courses with cohorts)
+type AccessImportRow struct {
+	Line      int        `json:"line"`
+	User      string     `json:"user"`
+	UserID    int        `json:"user_id,omitempty"`
+	CourseID  int        `json:"course_id"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CohortID  int        `json:"cohort_id,omitempty"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type AccessImportReport struct {
+	Action  string            `json:"action"`
+	DryRun  bool              `json:"dry_run"`
+	Summary map[string]int    `json:"summary"`
+	Rows    []AccessImportRow `json:"rows"`
+}
@@ -0,0 +1,65 @@
+package structures
+
+import "time"
+
+const (
+	SubmissionPending       = "pending"
+	SubmissionAccepted      = "accepted"
+	SubmissionNeedsRevision = "needs_revision"
+)
+
+// Assignment is a homework task of a lesson
+type Assignment struct {
+	Id          int       `json:"id"`
+	CourseID    int       `json:"course_id"`
+	VideoID     int       `json:"video_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Filled for learners: their latest submission
+	LastSubmission *Submission `json:"last_submission,omitempty"`
+}
+
+type Submission struct {
+	Id           int              `json:"id"`
+	AssignmentID int              `json:"assignment_id"`
+	UserID       int              `json:"user_id"`
+	Username     string           `json:"username,omitempty"`
+	Comment      string           `json:"comment"`
+	Status       string           `json:"status"`
+	Feedback     string           `json:"feedback"`
+	ReviewerID   int              `json:"reviewer_id,omitempty"`
+	ReviewedAt   *time.Time       `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+	Files        []SubmissionFile `json:"files"`
+}
+
+type SubmissionFile struct {
+	Id           int    `json:"id"`
+	SubmissionID int    `json:"submission_id"`
+	Title        string `json:"title"`
+	Path         string `json:"path"`
+
+	URL string `json:"url,omitempty"`
+}
+
+type AssignmentRequest struct {
+	VideoID     int    `json:"video_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+type ReviewRequest struct {
+	Status   string `json:"status"`
+	Feedback string `json:"feedback"`
+}
+
+// SubmissionFilter narrows the review queue, zero values match everything
+type SubmissionFilter struct {
+	AssignmentID int
+	CourseID     int
+	UserID       int
+	Status       string
+	CuratorID    int // only learners of the curator's cohorts
+}
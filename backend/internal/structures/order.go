@@ -0,0 +1,57 @@
+package structures
+
+import "time"
+
+const (
+	OrderPending  = "pending"
+	OrderPaid     = "paid"
+	OrderFailed   = "failed"
+	OrderRefunded = "refunded"
+)
+
+type Order struct {
+	Id          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Username    string     `json:"username,omitempty"`
+	CourseID    int        `json:"course_id"`
+	CourseTitle string     `json:"course_title"`
+	PlanID      int        `json:"plan_id,omitempty"`
+	PlanTitle   string     `json:"plan_title,omitempty"`
+	BundleID    int        `json:"bundle_id,omitempty"`
+	BundleTitle string     `json:"bundle_title,omitempty"`
+	CohortID    int        `json:"cohort_id,omitempty"`
+	CohortTitle string     `json:"cohort_title,omitempty"`
+	CohortIDs   []int      `json:"cohort_ids,omitempty"` // cohorts of the bundle's courses that run in cohorts
+	Amount      int        `json:"amount"`               // what the user pays, after the discount
+	Discount    int        `json:"discount"`             // taken off Course.Cost by the promo code
+	PromoCode   string     `json:"promo_code,omitempty"`
+	Currency    string     `json:"currency"`
+	Status      string     `json:"status"`
+	Provider    string     `json:"provider"`
+	PaymentID   string     `json:"payment_id,omitempty"`
+	CheckoutURL string     `json:"checkout_url,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	PaidAt      *time.Time `json:"paid_at,omitempty"`
+	RefundedAt  *time.Time `json:"refunded_at,omitempty"`
+
+	PromoCodeID int `json:"-"`
+}
+
+// CheckoutRequest buys a course, a plan of a course (required when the course has plans) or a bundle.
+// Courses run in cohorts are bought for a seat in one of them.
+type CheckoutRequest struct {
+	CourseID  int    `json:"course_id"`
+	PlanID    int    `json:"plan_id"`
+	CohortID  int    `json:"cohort_id"`
+	BundleID  int    `json:"bundle_id"`
+	CohortIDs []int  `json:"cohort_ids"` // bundles: a cohort for every course of the bundle that runs in cohorts
+	PromoCode string `json:"promo_code"`
+}
+
+// OrderFilter narrows the admin order list, zero values match everything
+type OrderFilter struct {
+	UserID   int
+	CourseID int
+	Status   string
+}
@@ -0,0 +1,49 @@
+package structures
+
+import "time"
+
+// LearningPath is an ordered sequence of courses, e.g. from basic sensory integration to neuro-games
+type LearningPath struct {
+	Id          int          `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Active      bool         `json:"active"`
+	Courses     []PathCourse `json:"courses"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+// PathCourse is a step of the path. The lessons of the course stay locked until
+// the prerequisite course (an earlier step of the same path) is completed.
+type PathCourse struct {
+	CourseID          int    `json:"course_id"`
+	CourseTitle       string `json:"course_title,omitempty"`
+	PrerequisiteID    int    `json:"prerequisite_id,omitempty"`
+	PrerequisiteTitle string `json:"prerequisite_title,omitempty"`
+}
+
+type LearningPathRequest struct {
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Active      *bool        `json:"active"` // true by default
+	Courses     []PathCourse `json:"courses"`
+}
+
+// PathProgress is the user's position in a learning path
+type PathProgress struct {
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Steps       []PathStep `json:"steps"`
+	Completed   int        `json:"completed"`
+	Total       int        `json:"total"`
+	// CurrentCourseID is the first course of the path that is not completed yet, 0 when the path is finished
+	CurrentCourseID int `json:"current_course_id"`
+}
+
+type PathStep struct {
+	PathCourse
+	HasAccess bool `json:"has_access"`
+	Unlocked  bool `json:"unlocked"` // prerequisites of the course in every active path are completed
+	Completed bool `json:"completed"`
+	Percent   int  `json:"percent"`
+}
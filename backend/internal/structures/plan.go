@@ -0,0 +1,35 @@
+package structures
+
+import "time"
+
+// Plan features. Lessons and their materials are part of every plan.
+const (
+	FeatureWebinars = "webinars"
+	FeatureHomework = "homework"
+	FeatureDiploma  = "diploma"
+)
+
+// AllFeatures are given by access without a plan
+var AllFeatures = []string{FeatureWebinars, FeatureHomework, FeatureDiploma}
+
+// CoursePlan is a tariff of a course, e.g. "videos only" or "with curator and webinars"
+type CoursePlan struct {
+	Id          int       `json:"id"`
+	CourseID    int       `json:"course_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Cost        int       `json:"cost"`
+	Features    []string  `json:"features"`
+	Position    int       `json:"position"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CoursePlanRequest struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Cost        int      `json:"cost"`
+	Features    []string `json:"features"`
+	Position    int      `json:"position"`
+	Active      *bool    `json:"active"` // true by default
+}
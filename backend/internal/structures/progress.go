@@ -0,0 +1,56 @@
+package structures
+
+import "time"
+
+type VideoProgress struct {
+	VideoID        int        `json:"video_id"`
+	LastPosition   int        `json:"last_position"`
+	WatchedSeconds int        `json:"watched_seconds"`
+	Duration       int        `json:"duration"`
+	Completed      bool       `json:"completed"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// LessonProgress is the user's progress on one video of a course, including videos that were never opened
+type LessonProgress struct {
+	CourseID int
+	Title    string
+	VideoProgress
+}
+
+// ProgressHeartbeat is sent by the player every few seconds while a video is playing
+type ProgressHeartbeat struct {
+	VideoID   int  `json:"video_id"`
+	Position  int  `json:"position"`  // current playback position, seconds
+	Watched   int  `json:"watched"`   // seconds actually played since the previous heartbeat
+	Duration  int  `json:"duration"`  // video duration reported by the player, seconds; informational, the lesson's duration is used
+	Completed bool `json:"completed"` // player reached the end of the video; informational, completion is decided by watched time
+}
+
+type ContinueWatching struct {
+	VideoID  int    `json:"video_id"`
+	Title    string `json:"title"`
+	Position int    `json:"position"`
+}
+
+type CourseProgress struct {
+	CompletedVideos  int               `json:"completed_videos"`
+	TotalVideos      int               `json:"total_videos"`
+	Percent          int               `json:"percent"`
+	ContinueWatching *ContinueWatching `json:"continue_watching,omitempty"`
+
+	// Quizzes with a pass score have to be passed before the course counts as complete
+	QuizzesRequired int  `json:"quizzes_required"`
+	QuizzesPassed   int  `json:"quizzes_passed"`
+	Completed       bool `json:"completed"`
+}
+
+type LearnerProgress struct {
+	UserID          int        `json:"user_id"`
+	Username        string     `json:"username"`
+	CompletedVideos int        `json:"completed_videos"`
+	TotalVideos     int        `json:"total_videos"`
+	Percent         int        `json:"percent"`
+	WatchedSeconds  int        `json:"watched_seconds"`
+	LastActivity    *time.Time `json:"last_activity,omitempty"`
+}
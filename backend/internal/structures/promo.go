@@ -0,0 +1,37 @@
+package structures
+
+import "time"
+
+const (
+	PromoPercent = "percent"
+	PromoFixed   = "fixed"
+)
+
+type PromoCode struct {
+	Id             int        `json:"id"`
+	Code           string     `json:"code"`
+	Kind           string     `json:"kind"`  // percent, fixed
+	Value          int        `json:"value"` // percent off or amount off
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+	MaxUses        int        `json:"max_uses"`          // 0 means unlimited
+	MaxUsesPerUser int        `json:"max_uses_per_user"` // 0 means unlimited
+	CourseIDs      []int      `json:"course_ids"`        // empty means every course
+	Active         bool       `json:"active"`
+	Uses           int        `json:"uses"` // paid and pending orders with the code
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// Price is the final price of a course, a plan or a bundle for a user, with the promo code applied
+type Price struct {
+	CourseID   int    `json:"course_id,omitempty"`
+	PlanID     int    `json:"plan_id,omitempty"`
+	BundleID   int    `json:"bundle_id,omitempty"`
+	Title      string `json:"title"`
+	Cost       int    `json:"cost"`
+	Discount   int    `json:"discount"`
+	FinalPrice int    `json:"final_price"`
+	Currency   string `json:"currency"`
+	PromoCode  string `json:"promo_code,omitempty"`
+
+	PromoCodeID int `json:"-"`
+}
@@ -0,0 +1,69 @@
+package structures
+
+import "time"
+
+const (
+	QuestionSingle   = "single"
+	QuestionMultiple = "multiple"
+	QuestionText     = "text"
+)
+
+type Quiz struct {
+	Id        int            `json:"id"`
+	CourseID  int            `json:"course_id"`
+	VideoID   int            `json:"video_id,omitempty"` // lesson the quiz belongs to, 0 for a course-level quiz
+	Title     string         `json:"title"`
+	PassScore int            `json:"pass_score"` // minimum percent, 0 means the quiz is optional
+	Questions []QuizQuestion `json:"questions,omitempty"`
+
+	// Filled for learners: their best attempt so far
+	BestAttempt *QuizAttempt `json:"best_attempt,omitempty"`
+}
+
+type QuizQuestion struct {
+	Id       int          `json:"id"`
+	Kind     string       `json:"kind"`
+	Text     string       `json:"text"`
+	Position int          `json:"position"`
+	Points   int          `json:"points"`
+	Options  []QuizOption `json:"options,omitempty"`
+
+	// Correct free-text answers, compared case-insensitively. Hidden from learners.
+	AcceptedAnswers []string `json:"accepted_answers,omitempty"`
+}
+
+type QuizOption struct {
+	Id        int    `json:"id"`
+	Text      string `json:"text"`
+	IsCorrect bool   `json:"is_correct,omitempty"` // hidden from learners
+}
+
+type QuizAnswer struct {
+	QuestionID int    `json:"question_id"`
+	OptionIDs  []int  `json:"option_ids,omitempty"`
+	Text       string `json:"text,omitempty"`
+	Correct    bool   `json:"correct"`
+}
+
+type QuizSubmission struct {
+	Answers []QuizAnswer `json:"answers"`
+}
+
+type QuizAttempt struct {
+	Id        int          `json:"id"`
+	QuizID    int          `json:"quiz_id"`
+	UserID    int          `json:"user_id"`
+	Username  string       `json:"username,omitempty"`
+	Score     int          `json:"score"`
+	MaxScore  int          `json:"max_score"`
+	Percent   int          `json:"percent"`
+	Passed    bool         `json:"passed"`
+	Answers   []QuizAnswer `json:"answers,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// QuizRequirement tells how many mandatory quizzes of a course the user has passed
+type QuizRequirement struct {
+	Required int
+	Passed   int
+}
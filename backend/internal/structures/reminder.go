@@ -0,0 +1,20 @@
+package structures
+
+import "time"
+
+// WebinarReminder is one notification that has to go to a user about an upcoming webinar
+type WebinarReminder struct {
+	WebinarID   int
+	Title       string
+	Link        string
+	Date        time.Time
+	CourseTitle string
+	UserID      int
+	Username    string
+}
+
+// CalendarWebinar is a webinar of the user's calendar feed
+type CalendarWebinar struct {
+	Webinar
+	CourseTitle string
+}
@@ -0,0 +1,48 @@
+package structures
+
+import "time"
+
+const (
+	CourseReviewPending  = "pending"
+	CourseReviewApproved = "approved"
+	CourseReviewRejected = "rejected"
+)
+
+// CourseReview is a rating with an optional text left by a user with access to the course.
+// Only approved reviews are shown publicly and counted in the course rating.
+type CourseReview struct {
+	Id           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Username     string     `json:"username"`
+	CourseID     int        `json:"course_id"`
+	CourseTitle  string     `json:"course_title,omitempty"`
+	Rating       int        `json:"rating"`
+	Text         string     `json:"text"`
+	Status       string     `json:"status,omitempty"`
+	AdminComment string     `json:"admin_comment,omitempty"`
+	ModeratedBy  int        `json:"moderated_by,omitempty"`
+	ModeratedAt  *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type CourseReviewRequest struct {
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+type CourseReviewFilter struct {
+	CourseID int
+	UserID   int
+	Status   string
+	Limit    int
+	Offset   int
+}
+
+// CourseReviewPage is one page of reviews, Total counts all reviews matching the filter
+type CourseReviewPage struct {
+	Reviews []CourseReview `json:"reviews"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+}
@@ -0,0 +1,23 @@
+package structures
+
+import "time"
+
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+)
+
+type UploadSession struct {
+	Id        string    `json:"id"`
+	CourseID  int       `json:"course_id"`
+	Title     string    `json:"title"`
+	Filename  string    `json:"filename"`
+	Size      int64     `json:"size"`
+	Duration  int       `json:"duration"` // seconds, becomes the duration of the lesson
+	Offset    int64     `json:"offset"`
+	Status    string    `json:"status"`
+	VideoPath string    `json:"video_path,omitempty"`
+	CreatedBy int       `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -2,10 +2,20 @@ package structures
 
 import "github.com/lib/pq"
 
+const (
+	RoleUser    = "user"
+	RoleAdmin   = "admin"
+	RoleCurator = "curator" // reviews homework, has no admin rights
+)
+
 type User struct {
-	Id        int64         `json:"id, omitempty"`
+	Id        int64         `json:"id"`
 	Username  string        `json:"username"`
 	Password  string        `json:"password"`
-	CourseIDs pq.Int64Array `db:"course_ids"`
+	CourseIDs pq.Int64Array `db:"course_ids"` // courses with an active enrollment
 	Role      string        `json:"role"`
 }
+
+type RoleRequest struct {
+	Role string `json:"role"`
+}
@@ -0,0 +1,44 @@
+package structures
+
+import "time"
+
+// VoucherBatch is a set of single-use codes that unlock one course
+type VoucherBatch struct {
+	Id          int        `json:"id"`
+	CourseID    int        `json:"course_id"`
+	CourseTitle string     `json:"course_title"`
+	Title       string     `json:"title"`
+	CohortID    int        `json:"cohort_id,omitempty"`
+	CohortTitle string     `json:"cohort_title,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Total       int        `json:"total"`
+	Redeemed    int        `json:"redeemed"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Vouchers    []Voucher  `json:"vouchers,omitempty"`
+}
+
+type Voucher struct {
+	Id         int        `json:"id"`
+	BatchID    int        `json:"batch_id"`
+	Code       string     `json:"code"`
+	RedeemedBy int        `json:"redeemed_by,omitempty"`
+	Username   string     `json:"username,omitempty"` // who redeemed it
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty"`
+
+	// Filled on redeem lookups from the batch
+	CourseID  int        `json:"-"`
+	CohortID  int        `json:"-"`
+	ExpiresAt *time.Time `json:"-"`
+}
+
+type VoucherBatchRequest struct {
+	CourseID  int        `json:"course_id"`
+	CohortID  int        `json:"cohort_id"` // required for courses with cohorts
+	Title     string     `json:"title"`
+	Count     int        `json:"count"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type RedeemRequest struct {
+	Code string `json:"code"`
+}
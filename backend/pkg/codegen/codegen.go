@@ -0,0 +1,35 @@
+package codegen
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strings"
+)
+
+// Alphabet without look-alike characters (0/O, 1/I) so codes can be typed from paper
+const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Generate returns a random code of `groups` groups with `size` characters each, joined by dashes (e.g. "K7QX-M2PA-9RTD")
+func Generate(groups, size int) (string, error) {
+	parts := make([]string, groups)
+	max := big.NewInt(int64(len(alphabet)))
+
+	for i := range parts {
+		b := make([]byte, size)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b[j] = alphabet[n.Int64()]
+		}
+		parts[i] = string(b)
+	}
+
+	return strings.Join(parts, "-"), nil
+}
+
+// Normalize upper-cases a user-typed code and strips spaces around it
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
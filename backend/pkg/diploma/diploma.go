@@ -0,0 +1,126 @@
+package diploma
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+// Field is a single line of text placed on a diploma template.
+// X and Y point to the start (or the middle / the end, depending on Align) of the text baseline.
+type Field struct {
+	Text     string
+	X        int
+	Y        int
+	FontSize float64
+	Color    color.Color
+	Align    string
+}
+
+// LoadFont parses a TTF/OTF font from path. An empty path falls back to the bundled Go Regular font.
+func LoadFont(path string) (*opentype.Font, error) {
+	data := goregular.TTF
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read font: %w", err)
+		}
+	}
+
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+
+	return f, nil
+}
+
+// Render draws fields on top of the template image stored at templatePath (PNG or JPEG)
+func Render(templatePath string, fields []Field, f *opentype.Font) (*image.RGBA, error) {
+	file, err := os.Open(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open template: %w", err)
+	}
+	defer file.Close()
+
+	tmpl, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode template: %w", err)
+	}
+
+	img := image.NewRGBA(tmpl.Bounds())
+	draw.Draw(img, img.Bounds(), tmpl, tmpl.Bounds().Min, draw.Src)
+
+	for _, field := range fields {
+		if field.Text == "" {
+			continue
+		}
+
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    field.FontSize,
+			DPI:     72,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create font face: %w", err)
+		}
+
+		clr := field.Color
+		if clr == nil {
+			clr = color.Black
+		}
+
+		d := &font.Drawer{
+			Dst:  img,
+			Src:  image.NewUniform(clr),
+			Face: face,
+		}
+
+		x := fixed.I(img.Bounds().Min.X + field.X)
+		switch field.Align {
+		case AlignCenter:
+			x -= d.MeasureString(field.Text) / 2
+		case AlignRight:
+			x -= d.MeasureString(field.Text)
+		}
+		d.Dot = fixed.Point26_6{X: x, Y: fixed.I(img.Bounds().Min.Y + field.Y)}
+		d.DrawString(field.Text)
+
+		face.Close()
+	}
+
+	return img, nil
+}
+
+// ParseColor parses "#RRGGBB" or "RRGGBB" into a color
+func ParseColor(s string) (color.Color, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(s) != 6 {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color %q", s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
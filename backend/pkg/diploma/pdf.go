@@ -0,0 +1,59 @@
+package diploma
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// EncodePNG writes img as PNG
+func EncodePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// EncodePDF writes img as a single-page PDF. The page has the size of the image (1px = 1pt),
+// the image itself is embedded as JPEG so no PDF library is required.
+func EncodePDF(w io.Writer, img image.Image) error {
+	var jpg bytes.Buffer
+	if err := jpeg.Encode(&jpg, img, &jpeg.Options{Quality: 92}); err != nil {
+		return fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+	content := fmt.Sprintf("q %d 0 0 %d 0 0 cm /Im0 Do Q", width, height)
+
+	var buf bytes.Buffer
+	offsets := make([]int, 0, 5)
+
+	buf.WriteString("%PDF-1.4\n")
+
+	obj := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj("<< /Type /Pages /Kids [3 0 R] /Count 1 >>")
+	obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /XObject << /Im0 4 0 R >> >> /Contents 5 0 R >>", width, height))
+
+	offsets = append(offsets, buf.Len())
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode /Length %d >>\nstream\n", width, height, jpg.Len())
+	buf.Write(jpg.Bytes())
+	buf.WriteString("\nendstream\nendobj\n")
+
+	obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
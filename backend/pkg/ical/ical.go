@@ -0,0 +1,88 @@
+package ical
+
+import (
+	"strings"
+	"time"
+)
+
+// Event is a VEVENT of an iCalendar feed (RFC 5545)
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	Cancelled   bool
+	Updated     time.Time
+}
+
+const timeFormat = "20060102T150405Z"
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Calendar renders the events as a text/calendar document
+func Calendar(name string, events []Event) []byte {
+	var b strings.Builder
+
+	line := func(s string) {
+		b.WriteString(fold(s))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//bala//webinars//RU")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escaper.Replace(name))
+
+	for _, e := range events {
+		stamp := e.Updated
+		if stamp.IsZero() {
+			stamp = time.Now()
+		}
+
+		line("BEGIN:VEVENT")
+		line("UID:" + e.UID)
+		line("DTSTAMP:" + stamp.UTC().Format(timeFormat))
+		line("DTSTART:" + e.Start.UTC().Format(timeFormat))
+		line("DTEND:" + e.End.UTC().Format(timeFormat))
+		line("SUMMARY:" + escaper.Replace(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escaper.Replace(e.Description))
+		}
+		if e.URL != "" {
+			line("URL:" + e.URL)
+		}
+		if e.Cancelled {
+			line("STATUS:CANCELLED")
+		} else {
+			line("STATUS:CONFIRMED")
+		}
+		line("END:VEVENT")
+	}
+
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// fold splits content lines longer than 75 octets without breaking UTF-8 characters
+func fold(s string) string {
+	if len(s) <= 75 {
+		return s
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range s {
+		size := len(string(r))
+		if n+size > 75 {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteRune(r)
+		n += size
+	}
+	return b.String()
+}
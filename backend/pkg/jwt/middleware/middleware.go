@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,35 +14,59 @@ func JWTMiddleware(secretKey string) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Missing token cookie"})
 		}
 
-		token, err := jwt.Parse(cookie, func(t *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-		if err != nil || !token.Valid {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token"})
+		userID, role, err := parseToken(cookie, secretKey)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
-		}
+		c.Locals("userId", userID)
+		c.Locals("role", role)
 
-		userIdFloat, ok := claims["userId"].(float64)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid userId in token"})
-		}
+		return c.Next()
+	}
+}
 
-		role, ok := claims["role"].(string)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid role in token"})
+// OptionalJWTMiddleware is for public routes that show more to signed-in users. A missing or
+// invalid cookie lets the request through anonymously, without userId and role locals.
+func OptionalJWTMiddleware(secretKey string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if cookie := c.Cookies("access_token"); cookie != "" {
+			if userID, role, err := parseToken(cookie, secretKey); err == nil {
+				c.Locals("userId", userID)
+				c.Locals("role", role)
+			}
 		}
 
-		c.Locals("userId", int(userIdFloat))
-		c.Locals("role", role)
-
 		return c.Next()
 	}
 }
 
+func parseToken(cookie, secretKey string) (int, string, error) {
+	token, err := jwt.Parse(cookie, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, "", errors.New("Invalid token")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, "", errors.New("Invalid token claims")
+	}
+
+	userIdFloat, ok := claims["userId"].(float64)
+	if !ok {
+		return 0, "", errors.New("Invalid userId in token")
+	}
+
+	role, ok := claims["role"].(string)
+	if !ok {
+		return 0, "", errors.New("Invalid role in token")
+	}
+
+	return int(userIdFloat), role, nil
+}
+
 func AdminOnly() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		role, ok := c.Locals("role").(string)
@@ -53,3 +79,19 @@ func AdminOnly() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// RolesOnly lets through users with one of the given roles
+func RolesOnly(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Insufficient permissions",
+		})
+	}
+}
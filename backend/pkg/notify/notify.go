@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	SenderLog  = "log"
+	SenderFile = "file"
+)
+
+// Message is a notification for one user
+type Message struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+}
+
+// Sender delivers messages to users. Real channels (SMS, WhatsApp, e-mail) implement it next to the local ones.
+type Sender interface {
+	Send(m Message) error
+}
+
+// New returns the sender configured by name
+func New(name, path string, log *slog.Logger) (Sender, error) {
+	switch name {
+	case "", SenderLog:
+		return NewLogSender(log), nil
+	case SenderFile:
+		return NewFileSender(path), nil
+	default:
+		return nil, fmt.Errorf("unknown notification sender %q", name)
+	}
+}
+
+// LogSender writes messages to the application log, for development
+type LogSender struct {
+	log *slog.Logger
+}
+
+func NewLogSender(log *slog.Logger) *LogSender {
+	return &LogSender{log: log}
+}
+
+func (s *LogSender) Send(m Message) error {
+	s.log.Info("notification",
+		slog.Int("user_id", m.UserID),
+		slog.String("username", m.Username),
+		slog.String("subject", m.Subject),
+		slog.String("body", m.Body),
+	)
+	return nil
+}
+
+// FileSender appends messages as JSON lines to a file, so they can be inspected without a real channel
+type FileSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+func (s *FileSender) Send(m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{m, time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
@@ -0,0 +1,85 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MockSignatureHeader carries hex(HMAC-SHA256(secret, body)) of mock webhooks
+const MockSignatureHeader = "X-Mock-Signature"
+
+// MockProvider is an offline gateway: payments are completed by calling the mock checkout
+// endpoint, which sends a signed webhook the same way a real provider would
+type MockProvider struct {
+	secret  []byte
+	baseURL string
+}
+
+func NewMockProvider(secret, baseURL string) *MockProvider {
+	return &MockProvider{secret: []byte(secret), baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+type mockEvent struct {
+	PaymentID string `json:"payment_id"`
+	Event     string `json:"event"`
+}
+
+func (p *MockProvider) Name() string {
+	return ProviderMock
+}
+
+func (p *MockProvider) CreatePayment(c Checkout) (Payment, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return Payment{}, err
+	}
+
+	id := "mock_" + hex.EncodeToString(buf)
+	return Payment{ID: id, URL: fmt.Sprintf("%s/api/v1/payments/mock/%s", p.baseURL, id)}, nil
+}
+
+func (p *MockProvider) ParseWebhook(body []byte, header func(string) string) (Event, error) {
+	sig, err := hex.DecodeString(header(MockSignatureHeader))
+	if err != nil || !hmac.Equal(sig, p.sign(body)) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var e mockEvent
+	if err := json.Unmarshal(body, &e); err != nil {
+		return Event{}, fmt.Errorf("invalid webhook body: %w", err)
+	}
+
+	switch e.Event {
+	case EventPaid, EventFailed, EventRefunded:
+	default:
+		return Event{}, fmt.Errorf("unknown webhook event %q", e.Event)
+	}
+
+	return Event{PaymentID: e.PaymentID, Type: e.Event}, nil
+}
+
+// Refund always succeeds, the refunded webhook is not sent because the caller already knows the result
+func (p *MockProvider) Refund(paymentID string, amount int) error {
+	return nil
+}
+
+// Webhook builds a signed webhook body for the payment, as the mock checkout page would send it
+func (p *MockProvider) Webhook(paymentID, event string) ([]byte, string, error) {
+	body, err := json.Marshal(mockEvent{PaymentID: paymentID, Event: event})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return body, hex.EncodeToString(p.sign(body)), nil
+}
+
+func (p *MockProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
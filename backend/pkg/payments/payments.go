@@ -0,0 +1,59 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+)
+
+const ProviderMock = "mock"
+
+const (
+	EventPaid     = "paid"
+	EventFailed   = "failed"
+	EventRefunded = "refunded"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Checkout describes what the user pays for
+type Checkout struct {
+	OrderID     int
+	Amount      int // in whole currency units, the way Course.Cost is stored
+	Currency    string
+	Description string
+	ReturnURL   string
+}
+
+// Payment is the provider side of an order
+type Payment struct {
+	ID  string // provider payment id, used to match webhooks to orders
+	URL string // where the user completes the payment
+}
+
+// Event is a verified webhook notification
+type Event struct {
+	PaymentID string
+	Type      string // paid, failed, refunded
+}
+
+// Provider is a payment gateway. Real gateways (Kaspi, CloudPayments, Stripe) implement it next to the mock one.
+type Provider interface {
+	Name() string
+	CreatePayment(c Checkout) (Payment, error)
+	// ParseWebhook verifies the signature of a webhook request and decodes it. header returns request headers by name.
+	ParseWebhook(body []byte, header func(string) string) (Event, error)
+	Refund(paymentID string, amount int) error
+}
+
+// New returns the provider configured by name. There is no default: the mock provider marks any order paid
+// without a payment, so it has to be chosen explicitly.
+func New(name, secret, baseURL string) (Provider, error) {
+	switch name {
+	case "":
+		return nil, errors.New("payment provider is not configured")
+	case ProviderMock:
+		return NewMockProvider(secret, baseURL), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", name)
+	}
+}
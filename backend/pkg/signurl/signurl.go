@@ -0,0 +1,21 @@
+package signurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Sign returns a hex HMAC-SHA256 of the parts joined with "|"
+func Sign(secretKey string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature produced by Sign in constant time
+func Verify(secretKey, signature string, parts ...string) bool {
+	expected := Sign(secretKey, parts...)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
@@ -9,6 +9,7 @@ import { Label } from "@/components/ui/label";
 import { ArrowLeft, Plus, Trash2 } from "lucide-react";
 import Link from "next/link";
 import { toast } from "sonner";
+import { videoDuration } from "@/lib/video";
 
 export default function EditCoursePage() {
   const router = useRouter();
@@ -163,10 +164,11 @@ export default function EditCoursePage() {
       if (newVideos.length > 0) {
         const videoFormData = new FormData();
         videoFormData.append("course_id", id);
-        newVideos.forEach((v, index) => {
-          videoFormData.append(`videos[${index}]`, v.file);
-          videoFormData.append(`titles[${index}]`, v.name);
-        });
+        for (const [index, v] of newVideos.entries()) {
+          videoFormData.append(`video[${index}]`, v.file);
+          videoFormData.append(`title[${index}]`, v.name);
+          videoFormData.append(`duration[${index}]`, String(await videoDuration(v.file)));
+        }
 
         const videoResponse = await fetch("/api/admin/courses/add-video", { method: "POST", credentials: "include", body: videoFormData });
         if (!videoResponse.ok) {
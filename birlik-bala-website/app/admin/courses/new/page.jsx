@@ -9,6 +9,7 @@ import { Label } from "@/components/ui/label";
 import { ArrowLeft, Plus, Trash2 } from "lucide-react";
 import Link from "next/link";
 import { toast } from "sonner";
+import { videoDuration } from "@/lib/video";
 
 export default function NewCoursePage() {
   const router = useRouter();
@@ -249,10 +250,11 @@ export default function NewCoursePage() {
         const videoFormData = new FormData();
         videoFormData.append("course_id", courseId.toString());
 
-        course.videos.forEach((video, index) => {
+        for (const [index, video] of course.videos.entries()) {
           if (video.file) {
             videoFormData.append(`video[${index}]`, video.file);
             videoFormData.append(`title[${index}]`, video.title);
+            videoFormData.append(`duration[${index}]`, String(await videoDuration(video.file)));
             if (video.extraFile && video.extraFile.size > 0) {
               videoFormData.append(`extra_file[${index}]`, video.extraFile);
 
@@ -260,7 +262,7 @@ export default function NewCoursePage() {
               videoFormData.append(`extra_file[${index}]`, "");
             }
           }
-        });
+        }
 
         const xhr = new XMLHttpRequest();
         xhr.upload.onprogress = (event) => {
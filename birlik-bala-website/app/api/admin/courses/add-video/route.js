@@ -22,19 +22,22 @@ export async function POST(request) {
     const videos = [];
     const titles = [];
     const extraFiles = [];
+    const durations = [];
     let index = 0;
 
-    // Собираем все video[], title[], extra_file[] с учетом их индексов
+    // Собираем все video[], title[], extra_file[], duration[] с учетом их индексов
     while (true) {
       const video = formData.get(`video[${index}]`);
       const title = formData.get(`title[${index}]`);
       const extraFile = formData.get(`extra_file[${index}]`);
+      const duration = formData.get(`duration[${index}]`);
 
       if (!video && !title && !extraFile) break; // Прерываем, если больше нет данных
 
       videos.push(video);
       titles.push(title);
       extraFiles.push(extraFile);
+      durations.push(duration);
       index++;
     }
 
@@ -73,6 +76,7 @@ export async function POST(request) {
     videos.forEach((video, index) => {
         uploadData.append("video[]", video); // Изменено на "video[]" для соответствия Go backend
         uploadData.append("title[]", titles[index] || `Урок ${index + 1}`);
+        uploadData.append("duration[]", durations[index] || "");
         if (extraFiles[index].size > 0) {
           uploadData.append("extra_file[]", extraFiles[index]); // Изменено на "extra_file[]"
         }
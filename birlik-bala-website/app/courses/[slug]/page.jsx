@@ -112,9 +112,9 @@ export default function CoursePage({ params }) {
     }
   };
 
-  // ✅ Открыть файл в новой вкладке
-  const openFileInNewTab = (filePath) => {
-    const url = `https://api.birlikbala.kz${filePath}`;
+  // ✅ Открыть файл в новой вкладке (подписанная ссылка file_url)
+  const openFileInNewTab = (fileUrl) => {
+    const url = `https://api.birlikbala.kz${fileUrl}`;
     window.open(url, "_blank", "noopener,noreferrer");
   };
 
@@ -156,14 +156,21 @@ export default function CoursePage({ params }) {
               {currentVideo ? (
                 <div>
                   <div className="aspect-video bg-black rounded-t-lg overflow-hidden">
-                    <video
-                      src={`https://api.birlikbala.kz${currentVideo.path}`}
-                      controls
-                      className="w-full h-full object-contain"
-                      onEnded={() =>
-                        handleVideoEnd(course.videos.findIndex((v) => v.id === currentVideo.id))
-                      }
-                    />
+                    {currentVideo.stream_url ? (
+                      <video
+                        key={currentVideo.id}
+                        src={`https://api.birlikbala.kz${currentVideo.stream_url}`}
+                        controls
+                        className="w-full h-full object-contain"
+                        onEnded={() =>
+                          handleVideoEnd(course.videos.findIndex((v) => v.id === currentVideo.id))
+                        }
+                      />
+                    ) : (
+                      <div className="w-full h-full flex items-center justify-center">
+                        <p className="text-gray-300">Урок пока закрыт</p>
+                      </div>
+                    )}
                   </div>
                   <div className="p-6">
                     <h2 className="text-xl font-semibold mb-2">{currentVideo.title}</h2>
@@ -172,13 +179,13 @@ export default function CoursePage({ params }) {
                     )}
 
                     {/* ✅ Открыть прикрепленный файл в новой вкладке */}
-                    {currentVideo.file && (
+                    {currentVideo.file_url && (
                       <div className="mt-4">
                         <Label>Прикреплённый файл:</Label>
                         <Button
                           variant="link"
                           className="text-blue-600 hover:underline flex items-center gap-2 p-0"
-                          onClick={() => openFileInNewTab(currentVideo.file)}
+                          onClick={() => openFileInNewTab(currentVideo.file_url)}
                         >
                           <Download className="w-4 h-4" />
                           Открыть файл
@@ -225,37 +232,41 @@ export default function CoursePage({ params }) {
             </Card>
           ))}
 
-          {/* Вебинар */}
-          {course.webinars && (
-            <Card className="cursor-pointer transition-all hover:shadow-md">
+          {/* Вебинары */}
+          {course.webinars?.length > 0 && (
+            <h3 className="text-lg font-semibold pt-2">Вебинары</h3>
+          )}
+          {course.webinars?.map((webinar) => (
+            <Card key={webinar.id} className="transition-all hover:shadow-md">
               <CardHeader className="pb-2 flex items-center justify-between">
-                <CardTitle className="text-sm leading-tight">Вебинар</CardTitle>
+                <CardTitle className="text-sm leading-tight">{webinar.title || "Вебинар"}</CardTitle>
                 <div className="flex items-center gap-1 text-xs text-gray-500">
                   <Calendar className="w-4 h-4" />
-                  {course.webinars.date
-                    ? new Date(course.webinars.date).toLocaleString("ru-RU", {
-                        day: "2-digit",
-                        month: "long",
-                        year: "numeric",
-                        hour: "2-digit",
-                        minute: "2-digit",
-                      })
-                    : "Дата не указана"}
+                  {new Date(webinar.date).toLocaleString("ru-RU", {
+                    day: "2-digit",
+                    month: "long",
+                    year: "numeric",
+                    hour: "2-digit",
+                    minute: "2-digit",
+                  })}
+                  {webinar.duration > 0 && `, ${webinar.duration} мин`}
                 </div>
               </CardHeader>
-              <CardContent>
-                <a
-                  href={course.webinars.link}
-                  target="_blank"
-                  rel="noopener noreferrer"
-                  className="text-blue-600 font-medium hover:underline flex items-center gap-2"
-                >
-                  <ExternalLink className="w-4 h-4" />
-                  Перейти к вебинару
-                </a>
-              </CardContent>
+              {webinar.link && (
+                <CardContent>
+                  <a
+                    href={webinar.link}
+                    target="_blank"
+                    rel="noopener noreferrer"
+                    className="text-blue-600 font-medium hover:underline flex items-center gap-2"
+                  >
+                    <ExternalLink className="w-4 h-4" />
+                    Перейти к вебинару
+                  </a>
+                </CardContent>
+              )}
             </Card>
-          )}
+          ))}
         </div>
       </div>
     </div>
@@ -0,0 +1,17 @@
+// Длительность видеофайла в секундах; бэкенд засчитывает урок только по ней
+export function videoDuration(file) {
+  return new Promise((resolve, reject) => {
+    const url = URL.createObjectURL(file);
+    const video = document.createElement("video");
+    video.preload = "metadata";
+    video.onloadedmetadata = () => {
+      URL.revokeObjectURL(url);
+      resolve(Math.round(video.duration));
+    };
+    video.onerror = () => {
+      URL.revokeObjectURL(url);
+      reject(new Error(`Не удалось прочитать длительность видео ${file.name}`));
+    };
+    video.src = url;
+  });
+}